@@ -1,7 +1,13 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/mallendem/gh-pr-review/pkg/approve"
+	"github.com/mallendem/gh-pr-review/pkg/gh"
 	"github.com/mallendem/gh-pr-review/pkg/gui"
 
 	"github.com/spf13/cobra"
@@ -12,47 +18,277 @@ var approveCmd = &cobra.Command{
 	Use:   "approve",
 	Short: "",
 	Long:  ``,
-	Run: func(cmd *cobra.Command, args []string) {
-		if onlyUsers, _ := cmd.Flags().GetBool("only-users"); onlyUsers {
-			approve.PrintUsersWithPrs()
-			return
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fetch, err := fetchOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if hashesFile, _ := cmd.Flags().GetString("approve-hashes-file"); hashesFile != "" {
+			if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+				return errors.New("--approve-hashes-file approves without prompting; pass --yes to confirm")
+			}
+			approveOpts, err := approveOptions(cmd)
+			if err != nil {
+				return err
+			}
+			if err := approve.ApproveHashesFromFile(cmd.Context(), cmd.OutOrStdout(), hashesFile, approveOpts, fetch, clientOptions(cmd)...); err != nil {
+				return fmt.Errorf("failed to approve from hash file: %w", err)
+			}
+			return nil
+		}
+
+		onlyUsers, _ := cmd.Flags().GetBool("only-users")
+		if workload, _ := cmd.Flags().GetBool("workload"); workload || onlyUsers {
+			sortFlag, _ := cmd.Flags().GetString("sort")
+			sortBy, err := approve.ParseUserSort(sortFlag)
+			if err != nil {
+				return err
+			}
+			if err := approve.PrintUsersWithPrs(cmd.Context(), cmd.OutOrStdout(), sortBy, !workload, fetch, clientOptions(cmd)...); err != nil {
+				return fmt.Errorf("failed to list users: %w", err)
+			}
+			return nil
 		}
 
 		if hashes, _ := cmd.Flags().GetStringSlice("hash"); len(hashes) > 0 {
-			approve.ApprovePrByHash(hashes)
-			return
+			// without --yes the matched PRs are only previewed
+			yes, _ := cmd.Flags().GetBool("yes")
+			approveOpts, err := approveOptions(cmd)
+			if err != nil {
+				return err
+			}
+			if err := approve.ApprovePrByHash(cmd.Context(), cmd.OutOrStdout(), hashes, yes, approveOpts, fetch, clientOptions(cmd)...); err != nil {
+				return fmt.Errorf("failed to approve by hash: %w", err)
+			}
+			return nil
 		}
 
+		// --sort defaults to count, which only applies to the user listings
+		var sortFlag string
+		if cmd.Flags().Changed("sort") {
+			sortFlag, _ = cmd.Flags().GetString("sort")
+		}
+		sortBy, err := gh.ParsePRSort(sortFlag)
+		if err != nil {
+			return err
+		}
 		users, _ := cmd.Flags().GetStringSlice("user")
-		_ = approve.ApprovePullRequest(users)
+		dedupe, _ := cmd.Flags().GetBool("dedupe")
+		viewFlag, _ := cmd.Flags().GetString("view")
+		view, err := gh.ParseChangeView(viewFlag)
+		if err != nil {
+			return err
+		}
+		if dedupe && view == gh.ViewFiles {
+			return errors.New("--dedupe lists hashes, so it can't be combined with --view files")
+		}
+		// --approve lists the changes of --user before approving them,
+		// --approve-user approves straight away
+		approveUsers, _ := cmd.Flags().GetStringSlice("approve-user")
+		list, _ := cmd.Flags().GetBool("approve")
+		if list {
+			if len(users) == 0 {
+				return errors.New("--approve needs --user to name whose PRs to approve")
+			}
+			approveUsers = users
+		}
+		if len(approveUsers) > 0 {
+			if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+				return errors.New("--approve and --approve-user approve without prompting; pass --yes to confirm")
+			}
+			approveOpts, err := approveOptions(cmd)
+			if err != nil {
+				return err
+			}
+			if err := approve.ApproveUsers(cmd.Context(), cmd.OutOrStdout(), approveUsers, list, sortBy, dedupe, view, approveOpts, fetch, clientOptions(cmd)...); err != nil {
+				return fmt.Errorf("failed to approve users' PRs: %w", err)
+			}
+			return nil
+		}
+		if err := approve.PrintChangesForUsers(cmd.Context(), users, sortBy, dedupe, view, fetch, clientOptions(cmd)...); err != nil {
+			return fmt.Errorf("failed to show changes: %w", err)
+		}
+		return nil
 	},
 }
 
 var manualCmd = &cobra.Command{
 	Use:   "manual",
 	Short: "Interactive manual approval for a user",
-	Run: func(cmd *cobra.Command, args []string) {
-		user, _ := cmd.Flags().GetString("user")
-		if user == "" {
-			cmd.PrintErrln("--user is required for manual mode")
-			return
+	RunE: func(cmd *cobra.Command, args []string) error {
+		users, _ := cmd.Flags().GetStringSlice("user")
+		if len(users) == 0 {
+			return errors.New("--user is required for manual mode")
+		}
+		user := strings.Join(users, ",")
+		fetch, err := fetchOptions(cmd)
+		if err != nil {
+			return err
+		}
+		approveOpts, err := approveOptions(cmd)
+		if err != nil {
+			return err
+		}
+		outputFlag, _ := cmd.Flags().GetString("output")
+		format, err := approve.ParseOutputFormat(outputFlag)
+		if err != nil {
+			return err
+		}
+		// keep stdout for the JSON report alone so it can be redirected
+		out := cmd.OutOrStdout()
+		if format == approve.OutputJSON {
+			out = cmd.ErrOrStderr()
+		}
+		sortFlag, _ := cmd.Flags().GetString("sort")
+		order, err := approve.ParseQueueSort(sortFlag)
+		if err != nil {
+			return err
 		}
 		propagate, _ := cmd.Flags().GetBool("propagate")
-		dryRun, _ := cmd.Flags().GetBool("dry-run")
-		_ = approve.ManualApproval(user, propagate, dryRun)
+		report, err := approve.ManualApproval(cmd.Context(), cmd.InOrStdin(), out, user, order, propagate, approveOpts, fetch, clientOptions(cmd)...)
+		// a report comes back along with failed approvals, to show what did go through
+		if report != nil {
+			if err := report.Write(cmd.OutOrStdout(), format); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+			if summaryFile, _ := cmd.Flags().GetString("summary-file"); summaryFile != "" {
+				if err := report.WriteSummaryFile(summaryFile); err != nil {
+					return fmt.Errorf("failed to write summary file: %w", err)
+				}
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to run manual approval: %w", err)
+		}
+		return nil
+	},
+}
+
+var prCmd = &cobra.Command{
+	Use:   "pr owner/repo#123",
+	Short: "Approve a single PR directly, even if no review was requested from you",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		approveOpts, err := approveOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if err := approve.ApprovePrByRef(cmd.Context(), cmd.OutOrStdout(), args[0], approveOpts, clientOptions(cmd)...); err != nil {
+			return fmt.Errorf("failed to approve PR: %w", err)
+		}
+		return nil
+	},
+}
+
+var diffSinceCmd = &cobra.Command{
+	Use:   "diff-since <time-or-file>",
+	Short: "Show which review requests are new, gone, updated or newly green since a saved queue",
+	Long: `Fetches the review queue and compares it with the newest one saved by an
+earlier diff-since run at or before the given time (e.g. 24h or 2006-01-02),
+or with a saved queue file. Every run saves the queue for later runs.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fetch, err := fetchOptions(cmd)
+		if err != nil {
+			return err
+		}
+		outputFlag, _ := cmd.Flags().GetString("output")
+		format, err := approve.ParseOutputFormat(outputFlag)
+		if err != nil {
+			return err
+		}
+		historyDir, err := gh.DefaultQueueHistoryDir()
+		if err != nil {
+			return err
+		}
+		if err := approve.DiffSince(cmd.Context(), cmd.OutOrStdout(), args[0], historyDir, format, fetch, clientOptions(cmd)...); err != nil {
+			return fmt.Errorf("failed to diff the review queue: %w", err)
+		}
+		return nil
+	},
+}
+
+var whichCmd = &cobra.Command{
+	Use:   "which <hash>",
+	Short: "Show the PRs containing a hash and its changes",
+	Long: `Prints the changes of the hash and every PR in the review queue containing
+it, with its title and author. A prefix of the hash, such as the short one the
+GUI shows, matches every hash starting with it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fetch, err := fetchOptions(cmd)
+		if err != nil {
+			return err
+		}
+		return approve.PrintHashPRs(cmd.Context(), cmd.OutOrStdout(), args[0], fetch, clientOptions(cmd)...)
+	},
+}
+
+var stagedCmd = &cobra.Command{
+	Use:   "staged",
+	Short: "List the PRs that would be approved, without approving them",
+	Long: `Fetches the review queue and lists every PR whose hashes are all approved,
+the same rule the GUI's staged list follows. The approved hashes are read from
+--hashes-file (one per line); without it, the hashes of PRs made of a single
+hash are taken as approved.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fetch, err := fetchOptions(cmd)
+		if err != nil {
+			return err
+		}
+		outputFlag, _ := cmd.Flags().GetString("output")
+		format, err := approve.ParseOutputFormat(outputFlag)
+		if err != nil {
+			return err
+		}
+		users, _ := cmd.Flags().GetStringSlice("user")
+		hashesFile, _ := cmd.Flags().GetString("hashes-file")
+		if err := approve.PrintStagedPRs(cmd.Context(), cmd.OutOrStdout(), strings.Join(users, ","), hashesFile, format, fetch, clientOptions(cmd)...); err != nil {
+			return fmt.Errorf("failed to list staged PRs: %w", err)
+		}
+		return nil
 	},
 }
 
 var guiCmd = &cobra.Command{
 	Use:   "gui",
 	Short: "Open interactive GUI for manual approvals",
-	Run: func(cmd *cobra.Command, args []string) {
-		user, _ := cmd.Flags().GetString("user")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fetch, err := fetchOptions(cmd)
+		if err != nil {
+			return err
+		}
+		approveOpts, err := approveOptions(cmd)
+		if err != nil {
+			return err
+		}
+		sortFlag, _ := cmd.Flags().GetString("sort")
+		order, err := approve.ParseQueueSort(sortFlag)
+		if err != nil {
+			return err
+		}
+		viewFlag, _ := cmd.Flags().GetString("view")
+		view, err := gh.ParseChangeView(viewFlag)
+		if err != nil {
+			return err
+		}
+		users, _ := cmd.Flags().GetStringSlice("user")
+		user := strings.Join(users, ",")
 		propagate, _ := cmd.Flags().GetBool("propagate")
-		dryRun, _ := cmd.Flags().GetBool("dry-run")
-		if err := gui.Run(user, propagate, dryRun); err != nil {
-			cmd.PrintErrf("failed to run gui: %v\n", err)
+		fresh, _ := cmd.Flags().GetBool("fresh")
+		var watch time.Duration
+		if on, _ := cmd.Flags().GetBool("watch"); on {
+			watch, _ = cmd.Flags().GetDuration("watch-interval")
+			if watch <= 0 {
+				return errors.New("--watch-interval must be positive")
+			}
+		}
+		if err := gui.Run(cmd.Context(), user, order, view, propagate, fresh, watch, approveOpts, fetch, clientOptions(cmd)...); err != nil {
+			return fmt.Errorf("failed to run gui: %w", err)
 		}
+		return nil
 	},
 }
 
@@ -60,18 +296,48 @@ func init() {
 	rootCmd.AddCommand(approveCmd)
 	approveCmd.AddCommand(manualCmd)
 
-	approveCmd.Flags().StringSliceP("user", "u", nil, "Comma-separated list of users to show changes for (e.g. alice,bob)")
+	approveCmd.Flags().StringSliceP("user", "u", nil, "Comma-separated list of users to show changes for (e.g. alice,bob); only a preview unless --approve is given")
+	approveCmd.Flags().Bool("approve", false, "After listing the changes of --user, approve every PR of theirs without prompting")
+	approveCmd.Flags().Bool("dedupe", false, "List each change once with the users and PRs it is in, instead of once per user")
+	approveCmd.Flags().String("view", string(gh.ViewHunks), "How the PR listing shows changes: hunks (each hunk under its hash) or files (each PR's changed files with +/- counts and the start of their patch)")
+	approveCmd.Flags().StringSlice("approve-user", nil, "Comma-separated list of users whose PRs to approve without prompting or listing their changes first, with a summary per user")
 	approveCmd.Flags().StringSliceP("hash", "x", nil, "Comma-separated list of hash values to approve PRs for (e.g. abc123,def456)")
 	approveCmd.Flags().BoolP("only-users", "o", false, "Return only the list of users with pending PR reviews")
+	approveCmd.Flags().BoolP("workload", "w", false, "List users with pending PR reviews with their number of pending hashes and PRs")
+	approveCmd.Flags().String("sort", string(approve.SortByCount), "Order of --workload and --only-users: count (most pending hashes first) or name; of the PR listing: repo (default), name, updated (newest first) or created (oldest first)")
+	approveCmd.Flags().String("approve-hashes-file", "", "Approve, without prompting, every PR whose hashes are all listed in this file (one per line)")
+	approveCmd.Flags().BoolP("yes", "y", false, "Confirm non-interactive approval with --approve, --approve-user or --approve-hashes-file, or approve the PRs matched by --hash instead of only listing them")
+	approveCmd.Flags().BoolP("dry-run", "d", false, "Dry run: do not submit approvals, only print what would be approved")
 
 	// manual subcommand flags
-	manualCmd.Flags().StringP("user", "m", "", "User to run manual approval for (required)")
+	manualCmd.Flags().StringSliceP("user", "m", nil, "Users to run manual approval for, comma-separated or repeated (required); their changes are reviewed grouped by author")
 	manualCmd.Flags().BoolP("propagate", "p", false, "When approving a hash, automatically approve linked hashes in the same PR")
 	manualCmd.Flags().BoolP("dry-run", "d", false, "Dry run: do not submit approvals, only print what would be approved")
+	manualCmd.Flags().String("sort", string(approve.SortByHash), "Order hashes are reviewed in: hash, or ready for PRs closest to their required approval count first")
+	manualCmd.Flags().String("output", string(approve.OutputText), "Format of the end-of-run summary: text or json (json goes to stdout and everything else to stderr)")
+	manualCmd.Flags().String("summary-file", "", "Write an audit record of the run to this JSON file: who decided what on which hashes and PRs, when, and any errors")
+
+	approveCmd.AddCommand(diffSinceCmd)
+	diffSinceCmd.Flags().String("output", string(approve.OutputText), "Format of the diff: text or json")
+
+	approveCmd.AddCommand(whichCmd)
+
+	approveCmd.AddCommand(stagedCmd)
+	stagedCmd.Flags().StringSliceP("user", "u", nil, "Only list PRs by these users, comma-separated or repeated (default: every author)")
+	stagedCmd.Flags().String("hashes-file", "", "File of approved hashes, one per line (default: the hashes of single-hash PRs)")
+	stagedCmd.Flags().String("output", string(approve.OutputText), "Format of the list: text or json")
+
+	approveCmd.AddCommand(prCmd)
+	prCmd.Flags().BoolP("dry-run", "d", false, "Dry run: do not submit the approval, only print that it would be made")
 
 	// add gui subcommand flags
 	approveCmd.AddCommand(guiCmd)
-	guiCmd.Flags().StringP("user", "u", "", "User to run GUI manual approval for (shows selection panel if omitted)")
+	guiCmd.Flags().StringSliceP("user", "u", nil, "Users to run GUI manual approval for, comma-separated or repeated (shows selection panel if omitted)")
 	guiCmd.Flags().BoolP("propagate", "p", false, "When approving a hash, automatically approve linked hashes in the same PR")
 	guiCmd.Flags().BoolP("dry-run", "d", false, "Dry run: do not submit approvals, only print what would be approved")
+	guiCmd.Flags().Bool("fresh", false, "Ignore any saved GUI session instead of offering to resume it")
+	guiCmd.Flags().Bool("watch", false, "Refresh the review queue every --watch-interval, keeping your decisions on hashes still in it")
+	guiCmd.Flags().Duration("watch-interval", gui.DefaultWatchInterval, "How often --watch refreshes the review queue")
+	guiCmd.Flags().String("sort", string(approve.SortByHash), "Order hashes are reviewed in: hash, or ready for PRs closest to their required approval count first")
+	guiCmd.Flags().String("view", string(gh.ViewHunks), "What the bottom pane shows besides the diff: hunks for the PR body, or files for the selected PR's changed files")
 }
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// setupLogging installs the default logger on stderr, so results printed to
+// stdout can be piped without warnings mixed in. --verbose adds debug lines
+// and --quiet drops everything below errors.
+func setupLogging(cmd *cobra.Command, _ []string) {
+	level := slog.LevelInfo
+	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+		level = slog.LevelDebug
+	}
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		level = slog.LevelError
+	}
+	slog.SetDefault(slog.New(newLogHandler(os.Stderr, level)))
+}
+
+// logHandler writes records as plain lines, prefixed with the level for
+// anything but info, e.g. "warning: rate limit low remaining=10".
+type logHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+func newLogHandler(w io.Writer, level slog.Leveler) *logHandler {
+	return &logHandler{mu: new(sync.Mutex), w: w, level: level}
+}
+
+func (h *logHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *logHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString("error: ")
+	case r.Level >= slog.LevelWarn:
+		b.WriteString("warning: ")
+	case r.Level < slog.LevelInfo:
+		b.WriteString("debug: ")
+	}
+	b.WriteString(r.Message)
+	writeAttr := func(a slog.Attr) bool {
+		if !a.Equal(slog.Attr{}) {
+			fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)
+	return &h2
+}
+
+// WithGroup is a no-op: nothing in this tool logs grouped attributes.
+func (h *logHandler) WithGroup(string) slog.Handler {
+	return h
+}
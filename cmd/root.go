@@ -1,9 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
+	"github.com/mallendem/gh-pr-review/pkg/approve"
+	"github.com/mallendem/gh-pr-review/pkg/gh"
 	"github.com/mallendem/gh-pr-review/pkg/gui"
 
 	"github.com/spf13/cobra"
@@ -14,26 +22,232 @@ var rootCmd = &cobra.Command{
 	Short: "Bulk-review and approve GitHub PRs requesting your review",
 	Long:  `Fetches GitHub notifications for review-requested PRs, groups changes by content hash, and provides CLI and TUI interfaces to approve or decline them.`,
 	// When invoked without a subcommand, open the approval GUI by default.
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Fprintln(cmd.OutOrStdout(), "No command provided, opening GUI by default...")
-		user, _ := cmd.Flags().GetString("user")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		slog.Info("No command provided, opening GUI by default...")
+		fetch, err := fetchOptions(cmd)
+		if err != nil {
+			return err
+		}
+		approveOpts, err := approveOptions(cmd)
+		if err != nil {
+			return err
+		}
+		sortFlag, _ := cmd.Flags().GetString("sort")
+		order, err := approve.ParseQueueSort(sortFlag)
+		if err != nil {
+			return err
+		}
+		viewFlag, _ := cmd.Flags().GetString("view")
+		view, err := gh.ParseChangeView(viewFlag)
+		if err != nil {
+			return err
+		}
+		users, _ := cmd.Flags().GetStringSlice("user")
+		user := strings.Join(users, ",")
 		propagate, _ := cmd.Flags().GetBool("propagate")
-		dryRun, _ := cmd.Flags().GetBool("dry-run")
-		if err := gui.Run(user, propagate, dryRun); err != nil {
-			cmd.PrintErrf("failed to run gui: %v\n", err)
+		fresh, _ := cmd.Flags().GetBool("fresh")
+		var watch time.Duration
+		if on, _ := cmd.Flags().GetBool("watch"); on {
+			watch, _ = cmd.Flags().GetDuration("watch-interval")
+			if watch <= 0 {
+				return errors.New("--watch-interval must be positive")
+			}
 		}
+		if err := gui.Run(cmd.Context(), user, order, view, propagate, fresh, watch, approveOpts, fetch, clientOptions(cmd)...); err != nil {
+			return fmt.Errorf("failed to run gui: %w", err)
+		}
+		return nil
+	},
+	// Every subcommand logs to stderr at the --verbose/--quiet level. Bad
+	// flags and arguments are reported before this runs, so only they are
+	// followed by the usage; a failed run just prints its error.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+		setupLogging(cmd, args)
 	},
 }
 
 func init() {
+	rootCmd.PersistentFlags().String("since", "", "Only consider review requests updated since this duration ago (e.g. 168h) or date (e.g. 2006-01-02); defaults to 72h")
+	rootCmd.PersistentFlags().String("source", string(gh.SourceNotifications), "Where to find review requests: notifications (within --since, can be marked read) or search (every open request, however old)")
+	rootCmd.PersistentFlags().Bool("ignore-whitespace", false, "Hash changes ignoring trailing whitespace and re-indented lines")
+	rootCmd.PersistentFlags().StringSlice("ignore-paths", gh.DefaultIgnorePaths, "Comma-separated file patterns (base name, dir/, or path glob) left out of hashing")
+	rootCmd.PersistentFlags().Bool("include-generated", false, "Hash lockfiles and vendored files too (disables --ignore-paths)")
+	rootCmd.PersistentFlags().StringSlice("repo", nil, "Only review PRs in these repositories: owner/name or owner/* (repeatable, case-insensitive)")
+	rootCmd.PersistentFlags().StringSlice("base-branch", nil, "Only review PRs targeting these base branches; globs allowed, e.g. main,release/* (repeatable)")
+	rootCmd.PersistentFlags().Bool("include-drafts", false, "Review draft PRs too (they are skipped by default)")
+	rootCmd.PersistentFlags().StringSlice("reasons", []string{gh.ReasonReviewRequested}, "Notification reasons whose PRs are reviewed, e.g. review_requested,mention,assign; PRs are tagged with reasons other than review_requested")
+	rootCmd.PersistentFlags().Bool("include-team-requests", false, "Also review PRs requesting the review of one of your teams, tagged with the team")
+	rootCmd.PersistentFlags().Bool("fail-fast", false, "Abort if any single PR fails to load instead of showing the rest of the queue")
+	rootCmd.PersistentFlags().Int("concurrency", gh.CONCURRENCY_LIMIT, "Number of PRs to fetch in parallel (lower it if you hit secondary rate limits)")
+	rootCmd.PersistentFlags().Int("max-retries", gh.DefaultMaxRetries, "Retries with exponential backoff when GitHub rate-limits a request (0 disables)")
+	rootCmd.PersistentFlags().Duration("timeout", gh.DefaultTimeout, "Deadline for each request to GitHub, reading the response included (0 disables)")
+	rootCmd.PersistentFlags().Duration("timeout-per-pr", 0, "Deadline for loading each PR with its diff and commits; slower PRs are skipped with a warning (0 disables)")
+	rootCmd.PersistentFlags().Duration("load-deadline", gh.DefaultLoadDeadline, "Deadline for loading the whole review queue, after which the PRs loaded so far are shown as a partial queue (0 disables)")
+	rootCmd.PersistentFlags().String("merge-method", string(gh.DefaultMergeMethod), "How approved PRs are merged: merge, squash or rebase")
+	rootCmd.PersistentFlags().Bool("approve-only", false, "Only submit the approval review; don't enable auto-merge or merge (auto-merge is on by default)")
+	rootCmd.PersistentFlags().Bool("update-branch", false, "Update a PR's branch with its base before approving when it is behind")
+	rootCmd.PersistentFlags().String("comment", "", "Body of the approval review, e.g. \"Approved via gh-pr-review\"; overrides the GUI's review_comment setting")
+	rootCmd.PersistentFlags().Bool("submit-declines", false, "Request changes on GitHub for PRs whose changes were all declined (by default declines stay local)")
+	rootCmd.PersistentFlags().String("decline-comment", "", "Body of the review submitted with --submit-declines (default \""+gh.DefaultDeclineComment+"\")")
+	rootCmd.PersistentFlags().Bool("force", false, "Approve PRs you already approved at their current head commit, or that changed since they were loaded")
+	rootCmd.PersistentFlags().Bool("require-green", false, "Skip approving PRs whose CI statuses and checks have not all passed")
+	rootCmd.PersistentFlags().Bool("mark-read", false, "Mark the review-request notification of each approved PR read")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Always download PR diffs and the review queue instead of reusing the on-disk caches")
+	rootCmd.PersistentFlags().Bool("refresh", false, "Fetch the review queue again instead of reusing the one a run saved in the last 5 minutes")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Log debug details (requests, retries, cache use) to stderr")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Only log errors; hides warnings and status lines on stderr")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+	rootCmd.PersistentFlags().String("github-url", "", "GitHub Enterprise Server URL (e.g. https://ghe.example.com); defaults to $GITHUB_API_URL or github.com")
+
 	// Flags for the default (GUI) invocation when no subcommand is given.
-	rootCmd.Flags().StringP("user", "u", "", "User to run GUI manual approval for (shows selection panel if omitted)")
+	rootCmd.Flags().StringSliceP("user", "u", nil, "Users to run GUI manual approval for, comma-separated or repeated (shows selection panel if omitted)")
 	rootCmd.Flags().BoolP("propagate", "p", false, "When approving a hash, automatically approve linked hashes in the same PR")
 	rootCmd.Flags().BoolP("dry-run", "d", false, "Dry run: do not submit approvals, only print what would be approved")
+	rootCmd.Flags().Bool("fresh", false, "Ignore any saved GUI session instead of offering to resume it")
+	rootCmd.Flags().Bool("watch", false, "Refresh the review queue every --watch-interval, keeping your decisions on hashes still in it")
+	rootCmd.Flags().Duration("watch-interval", gui.DefaultWatchInterval, "How often --watch refreshes the review queue")
+	rootCmd.Flags().String("sort", string(approve.SortByHash), "Order hashes are reviewed in: hash, or ready for PRs closest to their required approval count first")
+	rootCmd.Flags().String("view", string(gh.ViewHunks), "What the bottom pane shows besides the diff: hunks for the PR body, or files for the selected PR's changed files")
+}
+
+// clientOptions builds the GitHub client options from the persistent flags.
+func clientOptions(cmd *cobra.Command) []gh.Option {
+	githubURL, _ := cmd.Flags().GetString("github-url")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	maxRetries, _ := cmd.Flags().GetInt("max-retries")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	opts := []gh.Option{gh.WithBaseURL(githubURL), gh.WithConcurrency(concurrency), gh.WithMaxRetries(maxRetries), gh.WithTimeout(timeout)}
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); !noCache {
+		if dir, err := gh.DefaultDiffCacheDir(); err == nil {
+			opts = append(opts, gh.WithDiffCache(dir))
+		} else {
+			slog.Debug("diff cache disabled", "err", err)
+		}
+		ttl := gh.QueueSnapshotTTL
+		if refresh, _ := cmd.Flags().GetBool("refresh"); refresh {
+			ttl = 0
+		}
+		if dir, err := gh.DefaultQueueSnapshotDir(); err == nil {
+			opts = append(opts, gh.WithQueueSnapshot(dir, ttl))
+		} else {
+			slog.Debug("review queue snapshot disabled", "err", err)
+		}
+	}
+	return opts
+}
+
+// fetchOptions builds the review-queue fetch options from the persistent flags.
+func fetchOptions(cmd *cobra.Command) (gh.FetchOptions, error) {
+	sinceFlag, _ := cmd.Flags().GetString("since")
+	since, err := gh.ParseSince(sinceFlag)
+	if err != nil {
+		return gh.FetchOptions{}, err
+	}
+	repoFlag, _ := cmd.Flags().GetStringSlice("repo")
+	repos, err := gh.ParseRepoFilter(repoFlag)
+	if err != nil {
+		return gh.FetchOptions{}, err
+	}
+	baseFlag, _ := cmd.Flags().GetStringSlice("base-branch")
+	baseBranches, err := gh.ParseBaseBranchFilter(baseFlag)
+	if err != nil {
+		return gh.FetchOptions{}, err
+	}
+	sourceFlag, _ := cmd.Flags().GetString("source")
+	source, err := gh.ParseQueueSource(sourceFlag)
+	if err != nil {
+		return gh.FetchOptions{}, err
+	}
+	reasonsFlag, _ := cmd.Flags().GetStringSlice("reasons")
+	reasons, err := gh.ParseReasons(reasonsFlag)
+	if err != nil {
+		return gh.FetchOptions{}, err
+	}
+	includeDrafts, _ := cmd.Flags().GetBool("include-drafts")
+	includeTeams, _ := cmd.Flags().GetBool("include-team-requests")
+	deadline, _ := cmd.Flags().GetDuration("load-deadline")
+	prTimeout, _ := cmd.Flags().GetDuration("timeout-per-pr")
+	if deadline < 0 || prTimeout < 0 {
+		return gh.FetchOptions{}, errors.New("--load-deadline and --timeout-per-pr can't be negative")
+	}
+	failFast, _ := cmd.Flags().GetBool("fail-fast")
+	ignoreWhitespace, _ := cmd.Flags().GetBool("ignore-whitespace")
+	ignorePaths, _ := cmd.Flags().GetStringSlice("ignore-paths")
+	if includeGenerated, _ := cmd.Flags().GetBool("include-generated"); includeGenerated {
+		ignorePaths = nil
+	}
+	return gh.FetchOptions{
+		Since:               since,
+		FailFast:            failFast,
+		IgnoreWhitespace:    ignoreWhitespace,
+		IgnorePaths:         ignorePaths,
+		Repos:               repos,
+		BaseBranches:        baseBranches,
+		IncludeDrafts:       includeDrafts,
+		Source:              source,
+		IncludeTeamRequests: includeTeams,
+		Reasons:             reasons,
+		Deadline:            deadline,
+		PRTimeout:           prTimeout,
+	}, nil
+}
+
+// approveOptions builds the post-approval behavior from the persistent flags.
+func approveOptions(cmd *cobra.Command) (gh.ApproveOptions, error) {
+	methodFlag, _ := cmd.Flags().GetString("merge-method")
+	method, err := gh.ParseMergeMethod(methodFlag)
+	if err != nil {
+		return gh.ApproveOptions{}, err
+	}
+	approveOnly, _ := cmd.Flags().GetBool("approve-only")
+	updateBranch, _ := cmd.Flags().GetBool("update-branch")
+	comment, _ := cmd.Flags().GetString("comment")
+	submitDeclines, _ := cmd.Flags().GetBool("submit-declines")
+	declineComment, _ := cmd.Flags().GetString("decline-comment")
+	force, _ := cmd.Flags().GetBool("force")
+	requireGreen, _ := cmd.Flags().GetBool("require-green")
+	markRead, _ := cmd.Flags().GetBool("mark-read")
+	// commands without --dry-run leave it off
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	return gh.ApproveOptions{
+		MergeMethod:    method,
+		ApproveOnly:    approveOnly,
+		UpdateBranch:   updateBranch,
+		Comment:        comment,
+		SubmitDeclines: submitDeclines,
+		DeclineComment: declineComment,
+		Force:          force,
+		RequireGreen:   requireGreen,
+		MarkRead:       markRead,
+		DryRun:         dryRun,
+	}, nil
+}
+
+// Exit codes, so scripts can tell a run that approved some PRs before
+// failing on others from one that failed outright.
+const (
+	exitFailure        = 1
+	exitPartialFailure = 2
+)
+
+// exitCode returns the process exit code for err, returned by a command.
+func exitCode(err error) int {
+	var ae *approve.ApprovalError
+	if errors.As(err, &ae) && ae.Partial() {
+		return exitPartialFailure
+	}
+	return exitFailure
 }
 
+// Execute runs the root command and exits non-zero if it fails (see
+// exitCode). Ctrl-C cancels the command's context so a long fetch stops
+// instead of running to completion.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		os.Exit(exitCode(err))
 	}
 }
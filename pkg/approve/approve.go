@@ -2,13 +2,23 @@ package approve
 
 import (
 	"bufio"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/google/go-github/v72/github"
+	"github.com/mallendem/gh-pr-review/pkg/browser"
 	"github.com/mallendem/gh-pr-review/pkg/gh"
+	"golang.org/x/term"
 )
 
 const (
@@ -20,48 +30,324 @@ const (
 	cOrange = "\033[38;5;208m"
 )
 
+// colorEnabled turns colorize on. It is off when stdout is piped or
+// redirected, so scripts grepping the output see plain text, and when
+// NO_COLOR is set.
+var colorEnabled = isTTY() && os.Getenv("NO_COLOR") == ""
+
+// isTTY reports whether stdout is an interactive terminal.
+func isTTY() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
 func colorize(col, s string) string {
+	if !colorEnabled {
+		return s
+	}
 	return col + s + cReset
 }
 
-func ApprovePullRequest(users []string) error {
-	c := gh.NewGhClient()
-	c.PrintChangesPerUser(users)
+// PrintChangesForUsers prints every pending change grouped by author, limited
+// to users when it is non-empty, with PRs ordered per sortBy; with dedupe,
+// each change is printed once followed by the users and PRs it is in, and
+// with gh.ViewFiles each PR is summarized by the files it changes. It only
+// previews; see ApproveUsers to act on the listing.
+func PrintChangesForUsers(ctx context.Context, users []string, sortBy gh.PRSort, dedupe bool, view gh.ChangeView, fetch gh.FetchOptions, opts ...gh.Option) error {
+	c, err := gh.NewGhClient(opts...)
+	if err != nil {
+		return err
+	}
+	reportAuthenticatedUser(ctx, c)
+	if _, err := c.PrintChangesPerUser(ctx, users, sortBy, dedupe, view, fetch); err != nil {
+		return err
+	}
+	reportRateLimit(ctx, c)
 	return nil
 }
 
-func PrintUsersWithPrs() {
-	g := gh.NewGhClient()
-	userHashPrMap, _, _, _, _, _, _, err := g.GetPrReviewRequested()
+// ApproveUsers approves, without prompting, every PR of users in the review
+// queue, printing to w what was done and a summary per user. With list, the
+// changes of users are first listed as PrintChangesForUsers does. It returns
+// an error if any approval failed.
+func ApproveUsers(ctx context.Context, w io.Writer, users []string, list bool, sortBy gh.PRSort, dedupe bool, view gh.ChangeView, approveOpts gh.ApproveOptions, fetch gh.FetchOptions, opts ...gh.Option) error {
+	if len(users) == 0 {
+		return errors.New("approving needs the users whose PRs to approve")
+	}
+	g, err := gh.NewGhClient(opts...)
 	if err != nil {
-		fmt.Println(colorize(cYellow, fmt.Sprintf("Error fetching PR review requests: %v", err)))
-		return
+		return err
 	}
-	var users []string
-	for user := range userHashPrMap {
-		users = append(users, user)
+	reportAuthenticatedUser(ctx, g)
+	var res *gh.FetchResult
+	if list {
+		if res, err = g.PrintChangesPerUser(ctx, users, sortBy, dedupe, view, fetch); err != nil {
+			return err
+		}
+	} else {
+		if res, err = g.GetPrReviewRequestedForUser(ctx, users, fetch); err != nil {
+			return fmt.Errorf("error fetching PR review requests: %w", err)
+		}
+		printFetchSummary(res)
+	}
+	reportRateLimit(ctx, g)
+	return approveForUsers(ctx, w, users, res, g, approveOpts)
+}
+
+// approveForUsers approves, for each of users in turn, every PR in res they
+// authored, printing what was done and a summary per user to w. A PR's
+// hashes are all its author's, so only approveOpts (e.g. RequireGreen) or an
+// earlier approval skip one. PRs shared with other authors' hashes are never
+// approved on their behalf.
+func approveForUsers(ctx context.Context, w io.Writer, users []string, res *gh.FetchResult, g *gh.GhClient, approveOpts gh.ApproveOptions) error {
+	var all approvalSummary
+	seen := map[string]bool{}
+	for _, u := range splitUsers(strings.Join(users, ",")) {
+		u = strings.TrimSpace(u)
+		if u == "" || seen[strings.ToLower(u)] {
+			continue
+		}
+		seen[strings.ToLower(u)] = true
+		prMap := userPrMap(u, res)
+		approved := map[string]bool{}
+		for _, phashes := range prMap {
+			for _, h := range phashes {
+				approved[h] = true
+			}
+		}
+		fmt.Fprintln(w, colorize(cYellow, "User: "+u))
+		sum := processApprovals(ctx, prMap, approved, map[string]bool{}, map[string]bool{}, res.HashPrMap, g, approveOpts, nil)
+		printApprovals(w, sum)
+		fmt.Fprintf(w, "%s: %d approved, %d skipped, %d failed\n", u, len(sum.approved), len(sum.skipped), len(sum.failed))
+		all.approved = append(all.approved, sum.approved...)
+		all.failed = append(all.failed, sum.failed...)
 	}
-	sort.Strings(users)
-	for _, user := range users {
-		fmt.Println(colorize(cYellow, user))
+	return all.err()
+}
+
+// userPrMap returns the hashes of each PR in res authored by user, matched
+// case-insensitively.
+func userPrMap(user string, res *gh.FetchResult) map[string][]string {
+	prMap := map[string][]string{}
+	for uname, hashMap := range res.UserHashPrMap {
+		if !strings.EqualFold(uname, user) {
+			continue
+		}
+		for _, prs := range hashMap {
+			for _, pr := range prs {
+				prKey := pr.GetHTMLURL()
+				if phashes, ok := res.PrMap[prKey]; ok {
+					prMap[prKey] = phashes
+				}
+			}
+		}
+	}
+	return prMap
+}
+
+// UserSort orders the users listed by PrintUsersWithPrs.
+type UserSort string
+
+const (
+	// SortByCount lists users with the most pending hashes first.
+	SortByCount UserSort = "count"
+	// SortByName lists users alphabetically.
+	SortByName UserSort = "name"
+)
+
+// ParseUserSort validates a --sort value; empty selects SortByCount.
+func ParseUserSort(s string) (UserSort, error) {
+	switch us := UserSort(strings.ToLower(strings.TrimSpace(s))); us {
+	case "":
+		return SortByCount, nil
+	case SortByCount, SortByName:
+		return us, nil
+	default:
+		return "", fmt.Errorf("invalid sort %q: want count or name", s)
+	}
+}
+
+// QueueSort orders the hashes reviewed in manual mode and the GUI.
+type QueueSort string
+
+const (
+	// SortByHash reviews hashes in hash order, which is stable across runs.
+	SortByHash QueueSort = "hash"
+	// SortByReady reviews first the hashes of PRs closest to the approval
+	// count their base branch requires (see gh.ApprovalState).
+	SortByReady QueueSort = "ready"
+)
+
+// ParseQueueSort validates a manual or GUI --sort value; empty selects
+// SortByHash.
+func ParseQueueSort(s string) (QueueSort, error) {
+	switch qs := QueueSort(strings.ToLower(strings.TrimSpace(s))); qs {
+	case "":
+		return SortByHash, nil
+	case SortByHash, SortByReady:
+		return qs, nil
+	default:
+		return "", fmt.Errorf("invalid sort %q: want hash or ready", s)
 	}
 }
 
-func ApprovePrByHash(hashes []string) {
-	g := gh.NewGhClient()
-	_, changeMap, hMap, prMap, _, _, _, err := g.GetPrReviewRequested()
+// readinessRank orders PRs for SortByReady: those one approval away from
+// their requirement first, then by approvals missing, then PRs without a
+// known requirement, and last those that already have enough approvals or
+// whose state is unknown. Conflicting PRs go after mergeable ones of the same
+// rank.
+func readinessRank(state gh.ApprovalState, ok bool) int {
+	var rank int
+	switch {
+	case !ok:
+		return math.MaxInt
+	case state.Missing() > 0:
+		rank = state.Missing()
+	case state.Required == 0:
+		rank = 1 << 20
+	default:
+		rank = 1 << 21
+	}
+	rank *= 2
+	if !state.Mergeable {
+		rank++
+	}
+	return rank
+}
+
+// SortHashesByReadiness stably reorders hashes by the readiness of their most
+// ready PR, per readinessRank, given the approval states by PR URL.
+func SortHashesByReadiness(hashes []string, hashPrMap gh.HashPrMap, states map[string]gh.ApprovalState) {
+	rank := make(map[string]int, len(hashes))
+	for _, h := range hashes {
+		best := math.MaxInt
+		for _, pr := range hashPrMap[h] {
+			state, ok := states[pr.GetHTMLURL()]
+			best = min(best, readinessRank(state, ok))
+		}
+		rank[h] = best
+	}
+	sort.SliceStable(hashes, func(i, j int) bool { return rank[hashes[i]] < rank[hashes[j]] })
+}
+
+// queuePRs returns the distinct PRs of hashes, in order of first appearance.
+func queuePRs(hashes []string, hashPrMap gh.HashPrMap) []*github.PullRequest {
+	seen := map[string]bool{}
+	var prs []*github.PullRequest
+	for _, h := range hashes {
+		for _, pr := range hashPrMap[h] {
+			if !seen[pr.GetHTMLURL()] {
+				seen[pr.GetHTMLURL()] = true
+				prs = append(prs, pr)
+			}
+		}
+	}
+	return prs
+}
+
+// userWorkload is the pending review work for one PR author.
+type userWorkload struct {
+	user   string
+	hashes int // distinct change hashes
+	prs    int // distinct PRs
+}
+
+// userWorkloads counts the hashes and PRs of every user in m. With
+// SortByCount users are ordered by hashes, then PRs, descending; ties and
+// SortByName fall back to the user name.
+func userWorkloads(m gh.GhPrHashMap, sortBy UserSort) []userWorkload {
+	var out []userWorkload
+	for user, hashes := range m {
+		prs := map[string]bool{}
+		for _, list := range hashes {
+			for _, pr := range list {
+				prs[pr.GetHTMLURL()] = true
+			}
+		}
+		out = append(out, userWorkload{user: user, hashes: len(hashes), prs: len(prs)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if sortBy == SortByCount {
+			if a.hashes != b.hashes {
+				return a.hashes > b.hashes
+			}
+			if a.prs != b.prs {
+				return a.prs > b.prs
+			}
+		}
+		return a.user < b.user
+	})
+	return out
+}
+
+// PrintUsersWithPrs prints to w the authors of PRs awaiting the user's
+// review, ordered by sortBy, with their number of pending hashes and PRs.
+// With namesOnly it prints just the usernames, one per line, for scripting.
+func PrintUsersWithPrs(ctx context.Context, w io.Writer, sortBy UserSort, namesOnly bool, fetch gh.FetchOptions, opts ...gh.Option) error {
+	g, err := gh.NewGhClient(opts...)
 	if err != nil {
-		fmt.Println(colorize(cYellow, fmt.Sprintf("Error fetching PR review requests: %v", err)))
-		return
+		return err
+	}
+	reportAuthenticatedUser(ctx, g)
+	res, err := g.GetPrReviewRequested(ctx, fetch)
+	if err != nil {
+		return fmt.Errorf("error fetching PR review requests: %w", err)
+	}
+	printFetchSummary(res)
+	reportRateLimit(ctx, g)
+	workloads := userWorkloads(res.UserHashPrMap, sortBy)
+	if namesOnly {
+		for _, wl := range workloads {
+			fmt.Fprintln(w, wl.user)
+		}
+		return nil
+	}
+	width := 0
+	for _, wl := range workloads {
+		width = max(width, len(wl.user))
+	}
+	for _, wl := range workloads {
+		fmt.Fprintf(w, "%s %5d hashes %4d PRs\n", colorize(cYellow, fmt.Sprintf("%-*s", width, wl.user)), wl.hashes, wl.prs)
+	}
+	return nil
+}
+
+// ApprovePrByHash lists to w the PRs containing each of hashes, along with
+// the other changes in those PRs that would be approved with them. With
+// approveIt it then approves every listed PR whose hashes are all among
+// hashes, reporting the others as skipped, and returns an error if any
+// approval failed.
+func ApprovePrByHash(ctx context.Context, w io.Writer, hashes []string, approveIt bool, approveOpts gh.ApproveOptions, fetch gh.FetchOptions, opts ...gh.Option) error {
+	g, err := gh.NewGhClient(opts...)
+	if err != nil {
+		return err
 	}
+	reportAuthenticatedUser(ctx, g)
+	res, err := g.GetPrReviewRequested(ctx, fetch)
+	if err != nil {
+		return fmt.Errorf("error fetching PR review requests: %w", err)
+	}
+	printFetchSummary(res)
+	reportRateLimit(ctx, g)
+	printPrsForHashes(w, hashes, res)
+	if !approveIt {
+		return nil
+	}
+	return approveHashes(ctx, w, hashes, res, g, approveOpts)
+}
+
+// printPrsForHashes lists to w the PRs in res containing each of hashes and
+// the other changes linked to them.
+func printPrsForHashes(w io.Writer, hashes []string, res *gh.FetchResult) {
+	changeMap, hMap, prMap := res.ChangeMap, res.HashPrMap, res.PrMap
 	for _, h := range hashes {
 		prs, ok := hMap[h]
 		if !ok {
-			fmt.Println(colorize(cYellow, fmt.Sprintf("No PRs found for hash: %s", h)))
+			fmt.Fprintln(w, colorize(cYellow, fmt.Sprintf("No PRs found for hash: %s", h)))
 			continue
 		}
 		for _, pr := range prs {
-			fmt.Printf("%s %s\n", colorize(cYellow, "Found PR for hash"), colorize(cYellow, fmt.Sprintf("%s: %s", h, pr.GetHTMLURL())))
+			fmt.Fprintf(w, "%s %s\n", colorize(cYellow, "Found PR for hash"), colorize(cYellow, fmt.Sprintf("%s: %s", h, pr.GetHTMLURL())))
 			prKey := pr.GetHTMLURL()
 			linked, ok := prMap[prKey]
 			if !ok {
@@ -74,13 +360,14 @@ func ApprovePrByHash(hashes []string) {
 				}
 			}
 			if len(extras) > 0 {
-				fmt.Println(colorize(cYellow, "  There are also other hashes linked to this PR:"))
+				fmt.Fprintln(w, colorize(cYellow, "  There are also other hashes linked to this PR:"))
 				for _, ex := range extras {
-					fmt.Println(colorize(cGreen, fmt.Sprintf("    %s", ex)))
-					fmt.Println(colorize(cYellow, fmt.Sprintf("\t  Changes for hash %s:", ex)))
-					if changes, ok := changeMap[ex]; ok {
-						for _, line := range changes {
-							fmt.Println(colorize(cRed, fmt.Sprintf("\t    %s", line)))
+					fmt.Fprintln(w, colorize(cGreen, fmt.Sprintf("    %s", ex)))
+					fmt.Fprintln(w, colorize(cYellow, fmt.Sprintf("\t  Changes for hash %s:", ex)))
+					if hunk, ok := changeMap[ex]; ok {
+						fmt.Fprintln(w, colorize(cCyan, fmt.Sprintf("\t    %s", hunk.Header())))
+						for _, line := range hunk.Lines {
+							fmt.Fprintln(w, colorize(cRed, fmt.Sprintf("\t    %s", line)))
 						}
 					}
 				}
@@ -89,59 +376,241 @@ func ApprovePrByHash(hashes []string) {
 	}
 }
 
-// ManualApproval interactively reviews hashes for the given user and approves PRs
-// where all hashes are approved. propagate auto-approves linked hashes; dryRun
-// skips actual GitHub API calls.
-func ManualApproval(user string, propagate bool, dryRun bool) error {
-	g := gh.NewGhClient()
-	userHashPrMap, changeMap, hashPrMap, prMap, verifiedMap, _, _, err := g.GetPrReviewRequested()
+// approveHashes approves the PRs in res containing any of hashes, skipping
+// those that also contain a hash outside of them. PRs none of hashes touch
+// are left alone.
+func approveHashes(ctx context.Context, w io.Writer, hashes []string, res *gh.FetchResult, g *gh.GhClient, approveOpts gh.ApproveOptions) error {
+	approved := map[string]bool{}
+	prMap := map[string][]string{}
+	for _, h := range hashes {
+		approved[h] = true
+		for _, pr := range res.HashPrMap[h] {
+			prKey := pr.GetHTMLURL()
+			if phashes, ok := res.PrMap[prKey]; ok {
+				prMap[prKey] = phashes
+			}
+		}
+	}
+	sum := processApprovals(ctx, prMap, approved, map[string]bool{}, map[string]bool{}, res.HashPrMap, g, approveOpts, nil)
+	printApprovals(w, sum)
+	fmt.Fprintf(w, "%d approved, %d skipped, %d failed\n", len(sum.approved), len(sum.skipped), len(sum.failed))
+	return sum.err()
+}
+
+// printApprovals prints to w what processApprovals did and why each skipped
+// PR was skipped.
+func printApprovals(w io.Writer, sum approvalSummary) {
+	for _, line := range sum.logs {
+		fmt.Fprintln(w, line)
+	}
+	for _, prKey := range sum.skipped {
+		fmt.Fprintln(w, colorize(cYellow, fmt.Sprintf("Skipped PR %s (%s)", prKey, sum.reasons[prKey])))
+	}
+}
+
+// ApproveHashesFromFile approves, without prompting, every PR whose hashes
+// are all listed in the newline-delimited file at path. Hashes missing from
+// the file count as not approved, so partially covered PRs are skipped. It
+// returns an error if any approval failed. Progress is printed to w.
+func ApproveHashesFromFile(ctx context.Context, w io.Writer, path string, approveOpts gh.ApproveOptions, fetch gh.FetchOptions, opts ...gh.Option) error {
+	allowed, err := readHashFile(path)
+	if err != nil {
+		return err
+	}
+	g, err := gh.NewGhClient(opts...)
+	if err != nil {
+		return err
+	}
+	reportAuthenticatedUser(ctx, g)
+	res, err := g.GetPrReviewRequested(ctx, fetch)
 	if err != nil {
 		return fmt.Errorf("error fetching PR review requests: %w", err)
 	}
+	printFetchSummary(res)
+	reportRateLimit(ctx, g)
+
+	approved := map[string]bool{}
+	for _, h := range allowed {
+		approved[h] = true
+	}
+	sum := processApprovals(ctx, res.PrMap, approved, map[string]bool{}, map[string]bool{}, res.HashPrMap, g, approveOpts, nil)
+	for _, line := range sum.logs {
+		fmt.Fprintln(w, line)
+	}
+	for _, prKey := range sum.skipped {
+		fmt.Fprintln(w, colorize(cYellow, fmt.Sprintf("Skipped PR %s (not every change is in %s)", prKey, path)))
+	}
+	fmt.Fprintf(w, "%d approved, %d skipped, %d failed\n", len(sum.approved), len(sum.skipped), len(sum.failed))
+	return sum.err()
+}
+
+// ApprovePrByRef approves the PR given as "owner/repo#123" or by URL
+// directly, whether or not a review was requested on it, honoring the same
+// approveOpts as the review queue.
+func ApprovePrByRef(ctx context.Context, w io.Writer, ref string, approveOpts gh.ApproveOptions, opts ...gh.Option) error {
+	r, err := gh.ParsePRRef(ref)
+	if err != nil {
+		return err
+	}
+	g, err := gh.NewGhClient(opts...)
+	if err != nil {
+		return err
+	}
+	pr, err := g.GetPR(ctx, r.Owner, r.Repo, r.Number)
+	if err != nil {
+		return err
+	}
+	if pr.GetState() != "open" {
+		return fmt.Errorf("PR %s is %s", r, pr.GetState())
+	}
+	prKey := pr.GetHTMLURL()
+	var sum approvalSummary
+	switch {
+	case approveOpts.RequireGreen && !checksGreen(ctx, &sum, prKey, pr, g):
+		sum.logs = append(sum.logs, colorize(cYellow, fmt.Sprintf("Not approving PR %s (checks not green: %s)", prKey, strings.Join(sum.notGreen, ", "))))
+	case alreadyApproved(ctx, &sum, prKey, pr, g, approveOpts):
+	default:
+		res, err := g.ApprovePr(ctx, pr, approveOpts)
+		if err != nil {
+			return err
+		}
+		sum.logs = append(sum.logs, approvedLine(prKey, res, approveOpts))
+	}
+	for _, line := range sum.logs {
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
+
+// readHashFile reads one hash per line, ignoring blank lines and # comments.
+func readHashFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hash file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	var hashes []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hashes = append(hashes, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hash file: %w", err)
+	}
+	return hashes, nil
+}
 
-	hashes := collectHashesForUsers(user, userHashPrMap)
+// ManualApproval interactively reviews hashes for the given users,
+// comma-separated, grouped by author, and approves PRs where all hashes are
+// approved. Answers are read from in and everything is
+// printed to out. order sets the order hashes are reviewed in; propagate
+// auto-approves linked hashes; approveOpts.DryRun skips actual GitHub API
+// calls. It returns a report of what was, or in a dry run would be,
+// approved, along with an *ApprovalError if any review failed; quitting early returns a nil
+// report without approving anything.
+func ManualApproval(ctx context.Context, in io.Reader, out io.Writer, user string, order QueueSort, propagate bool, approveOpts gh.ApproveOptions, fetch gh.FetchOptions, opts ...gh.Option) (*ManualReport, error) {
+	g, err := gh.NewGhClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	reportAuthenticatedUser(ctx, g)
+	res, err := g.GetPrReviewRequestedForUser(ctx, splitUsers(user), fetch)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching PR review requests: %w", err)
+	}
+	printFetchSummary(res)
+	reportRateLimit(ctx, g)
+	authors := HashAuthors(user, res.UserHashPrMap)
+	hashes := collectHashesForUsers(user, res.UserHashPrMap)
 	if len(hashes) == 0 {
-		fmt.Println(colorize(cYellow, fmt.Sprintf("No hashes found for user %s", user)))
-		return nil
+		fmt.Fprintln(out, colorize(cYellow, fmt.Sprintf("No hashes found for user %s", user)))
+		return nil, nil
+	}
+	if order == SortByReady {
+		// readiness orders each author's hashes, which stay together
+		SortHashesByReadiness(hashes, res.HashPrMap, g.ApprovalStates(ctx, queuePRs(hashes, res.HashPrMap)))
+		GroupHashesByAuthor(hashes, authors)
 	}
 
-	approved := map[string]bool{}
-	declined := map[string]bool{}
-	prSkipped := map[string]bool{}
+	approved, declined, prSkipped, quit := reviewHashes(ctx, bufio.NewReader(in), out, hashes, authors, res, g, propagate)
+	if quit {
+		return nil, nil
+	}
+	sum := processApprovals(ctx, res.PrMap, approved, declined, prSkipped, res.HashPrMap, g, approveOpts, nil)
+	for _, line := range sum.logs {
+		fmt.Fprintln(out, line)
+	}
+	reviewer, _ := g.CurrentUser(ctx)
+	return newManualReport(reviewer, approveOpts.DryRun, hashes, approved, declined, sum), sum.err()
+}
 
-	in := bufio.NewReader(os.Stdin)
+// reviewHashes prompts on out for a decision on each of hashes, reading the
+// answers from answers, and returns the decisions. When authors, which maps
+// hashes to whose changes they are reviewed as, holds more than one author,
+// each author's hashes are introduced with a header. quit reports that the
+// user quit, in which case nothing should be approved.
+func reviewHashes(ctx context.Context, answers *bufio.Reader, out io.Writer, hashes []string, authors map[string]string, res *gh.FetchResult, g *gh.GhClient, propagate bool) (approved, declined, prSkipped map[string]bool, quit bool) {
+	changeMap, hashPrMap, prMap := res.ChangeMap, res.HashPrMap, res.PrMap
+	approved = map[string]bool{}
+	declined = map[string]bool{}
+	prSkipped = map[string]bool{}
 	firstSeen := map[string]string{}
 	total := len(hashes)
 
 	uniquePrKeys, prIndexMap := buildUniquePrKeys(hashes, hashPrMap)
 	totalPRs := len(uniquePrKeys)
 
-	for idx, h := range hashes {
+	authorHashes := map[string]int{}
+	for _, h := range hashes {
+		authorHashes[authors[h]]++
+	}
+	shownAuthor := ""
+
+	// history holds the state before each answered prompt, so b can go back
+	// to the previous hash and undo everything decided since.
+	var history []manualState
+review:
+	for idx := 0; idx < len(hashes); idx++ {
+		h := hashes[idx]
 		if approved[h] || declined[h] {
 			continue
 		}
 
 		if isHashSkipped(h, hashPrMap, prSkipped) {
-			fmt.Printf("Skipping hash %s because one of its PRs was previously skipped\n", h)
+			fmt.Fprintf(out, "Skipping hash %s because one of its PRs was previously skipped\n", h)
 			continue
 		}
 
 		if allDup, originals := isAllDuplicateApproved(h, changeMap, firstSeen, approved); allDup {
 			approved[h] = true
-			fmt.Printf("All changes for hash %s are duplicates of %v and already approved — auto-approving.\n", h, originals)
+			fmt.Fprintf(out, "All changes for hash %s are duplicates of %v and already approved — auto-approving.\n", h, originals)
 			continue
 		}
 
-		if changes, ok := changeMap[h]; ok {
-			fmt.Println("Changes:")
-			printChangesAndMarkFirstSeen(h, changes, firstSeen)
+		if author := authors[h]; len(authorHashes) > 1 && author != shownAuthor {
+			fmt.Fprintln(out, colorize(cYellow, fmt.Sprintf("== %s: %d hash(es) ==", author, authorHashes[author])))
+			shownAuthor = author
+		}
+
+		before := saveManualState(idx, approved, declined, prSkipped, firstSeen)
+		if hunk, ok := changeMap[h]; ok {
+			header := hunk.Header()
+			if sha := res.CommitMap[h]; sha != "" {
+				header += " @ " + gh.ShortSHA(sha)
+			}
+			fmt.Fprintf(out, "Changes in %s:\n", colorize(cCyan, header))
+			printChangesAndMarkFirstSeen(out, h, hunk.Lines, firstSeen)
 		} else {
-			fmt.Println("No changes recorded for this hash.")
+			fmt.Fprintln(out, "No changes recorded for this hash.")
 		}
 
-		prCount, firstPrKey := showAssociatedPRs(h, hashPrMap, verifiedMap)
+		prCount, firstPrKey := showAssociatedPRs(out, h, res)
 		if prCount == 0 {
-			fmt.Println("No PRs associated with this hash.")
+			fmt.Fprintln(out, "No PRs associated with this hash.")
 		}
 
 		prProgressIndex := 1
@@ -151,13 +620,26 @@ func ManualApproval(user string, propagate bool, dryRun bool) error {
 			}
 		}
 
-		promptActionForHash(h, idx, total, prProgressIndex, totalPRs, in, g, propagate, approved, declined, prSkipped, hashPrMap, prMap)
+		switch promptActionForHash(ctx, out, h, hashes[idx:], idx, total, prProgressIndex, totalPRs, answers, g, propagate, approved, declined, prSkipped, hashPrMap, prMap) {
+		case promptQuit:
+			return nil, nil, nil, true
+		case promptApprovedRest:
+			break review
+		case promptBack:
+			if len(history) == 0 {
+				fmt.Fprintln(out, colorize(cYellow, "Already at the first hash."))
+			} else {
+				before = history[len(history)-1]
+				history = history[:len(history)-1]
+			}
+			approved, declined, prSkipped, firstSeen = before.approved, before.declined, before.prSkipped, before.firstSeen
+			idx = before.idx - 1
+			continue
+		}
+		history = append(history, before)
 	}
 
-	for _, line := range ProcessApprovals(prMap, approved, declined, prSkipped, hashPrMap, g, dryRun, "") {
-		fmt.Println(line)
-	}
-	return nil
+	return approved, declined, prSkipped, false
 }
 
 func isHashSkipped(h string, hashPrMap gh.HashPrMap, prSkipped map[string]bool) bool {
@@ -171,32 +653,74 @@ func isHashSkipped(h string, hashPrMap gh.HashPrMap, prSkipped map[string]bool)
 	return false
 }
 
+// splitUsers splits a comma-separated --user value into usernames.
+func splitUsers(user string) []string {
+	return strings.Split(user, ",")
+}
+
+// collectHashesForUsers returns the hashes of the users in the
+// comma-separated user, grouped by author as HashAuthors assigns them, in
+// alphabetical order of author and then hash.
 func collectHashesForUsers(user string, userHashPrMap gh.GhPrHashMap) []string {
-	hashesMap := map[string]struct{}{}
-	for _, u := range strings.Split(user, ",") {
-		if userMap, ok := userHashPrMap[u]; ok {
-			for h := range userMap {
-				hashesMap[h] = struct{}{}
-			}
-		} else {
-			for uname, userMap := range userHashPrMap {
-				if strings.EqualFold(uname, u) {
-					for h := range userMap {
-						hashesMap[h] = struct{}{}
-					}
-					break
-				}
+	authors := HashAuthors(user, userHashPrMap)
+	hashes := slices.Collect(maps.Keys(authors))
+	sort.Strings(hashes)
+	GroupHashesByAuthor(hashes, authors)
+	return hashes
+}
+
+// HashAuthors returns, for each hash of the users in the comma-separated
+// user, the author it is reviewed under: the alphabetically first of them
+// with a PR containing it. Users are matched case-insensitively and mapped to
+// their login as fetched.
+func HashAuthors(user string, userHashPrMap gh.GhPrHashMap) map[string]string {
+	var logins []string
+	for _, u := range splitUsers(user) {
+		u = strings.TrimSpace(u)
+		if _, ok := userHashPrMap[u]; ok {
+			logins = append(logins, u)
+			continue
+		}
+		for uname := range userHashPrMap {
+			if strings.EqualFold(uname, u) {
+				logins = append(logins, uname)
+				break
 			}
 		}
 	}
-	var hashes []string
-	for h := range hashesMap {
-		hashes = append(hashes, h)
+	slices.SortFunc(logins, compareAuthors)
+	authors := map[string]string{}
+	for _, login := range logins {
+		for h := range userHashPrMap[login] {
+			if _, ok := authors[h]; !ok {
+				authors[h] = login
+			}
+		}
 	}
-	sort.Strings(hashes)
-	return hashes
+	return authors
+}
+
+// GroupHashesByAuthor stably reorders hashes so each author's in authors
+// come together, authors in alphabetical order. Hashes without an author go
+// last.
+func GroupHashesByAuthor(hashes []string, authors map[string]string) {
+	slices.SortStableFunc(hashes, func(a, b string) int {
+		aa, ab := authors[a], authors[b]
+		if (aa == "") != (ab == "") {
+			return cmp.Compare(ab, aa)
+		}
+		return compareAuthors(aa, ab)
+	})
 }
 
+// compareAuthors orders logins alphabetically, ignoring case.
+func compareAuthors(a, b string) int {
+	return cmp.Or(strings.Compare(strings.ToLower(a), strings.ToLower(b)), strings.Compare(a, b))
+}
+
+// buildUniquePrKeys numbers the PRs of hashes from 1 in the order they are
+// first reached going through hashes, so the PR count of the prompt only
+// goes up during a review.
 func buildUniquePrKeys(hashes []string, hashPrMap gh.HashPrMap) ([]string, map[string]int) {
 	prKeySet := map[string]struct{}{}
 	var uniquePrKeys []string
@@ -211,7 +735,6 @@ func buildUniquePrKeys(hashes []string, hashPrMap gh.HashPrMap) ([]string, map[s
 			}
 		}
 	}
-	sort.Strings(uniquePrKeys)
 	prIndexMap := map[string]int{}
 	for i, k := range uniquePrKeys {
 		prIndexMap[k] = i + 1
@@ -220,10 +743,11 @@ func buildUniquePrKeys(hashes []string, hashPrMap gh.HashPrMap) ([]string, map[s
 }
 
 func isAllDuplicateApproved(h string, changeMap gh.HashChangeMap, firstSeen map[string]string, approved map[string]bool) (bool, []string) {
-	changes, ok := changeMap[h]
+	hunk, ok := changeMap[h]
 	if !ok {
 		return false, nil
 	}
+	changes := hunk.Lines
 	originalsSet := map[string]struct{}{}
 	for _, line := range changes {
 		first, seen := firstSeen[line]
@@ -243,31 +767,39 @@ func isAllDuplicateApproved(h string, changeMap gh.HashChangeMap, firstSeen map[
 	return true, originals
 }
 
-func printChangesAndMarkFirstSeen(h string, changes []string, firstSeen map[string]string) {
+func printChangesAndMarkFirstSeen(w io.Writer, h string, changes []string, firstSeen map[string]string) {
 	for _, line := range changes {
 		if first, seen := firstSeen[line]; seen {
-			fmt.Printf("  %s %s\n", colorize(cGreen, fmt.Sprintf("[duplicate of %s]", first)), colorize(cGreen, line))
+			fmt.Fprintf(w, "  %s %s\n", colorize(cGreen, fmt.Sprintf("[duplicate of %s]", first)), colorize(cGreen, line))
 		} else {
-			fmt.Printf("  %s\n", colorize(cCyan, line))
+			fmt.Fprintf(w, "  %s\n", colorize(cCyan, line))
 			firstSeen[line] = h
 		}
 	}
 }
 
-// showAssociatedPRs prints associated PRs for a given hash with verification status
-// and returns the count and the first PR's URL.
-func showAssociatedPRs(h string, hashPrMap gh.HashPrMap, verifiedMap gh.PrVerifiedMap) (int, string) {
-	prs, ok := hashPrMap[h]
+// showAssociatedPRs prints to w the PRs of res associated with a hash with
+// their verification status, tagging team review requests and PRs in the
+// queue for another notification reason, and returns the count and the
+// first PR's URL.
+func showAssociatedPRs(w io.Writer, h string, res *gh.FetchResult) (int, string) {
+	prs, ok := res.HashPrMap[h]
 	if !ok {
 		return 0, ""
 	}
-	fmt.Println("Associated PRs:")
+	fmt.Fprintln(w, "Associated PRs:")
 	firstPrKey := ""
 	for i, pr := range prs {
 		prKey := pr.GetHTMLURL()
-		verifiedIcon := VerifiedIcon(verifiedMap[prKey])
-		fmt.Printf("  %s %s %s\n", colorize(cYellow, fmt.Sprintf("[%d/%d]", i+1, len(prs))), verifiedIcon, colorize(cYellow, pr.GetTitle()))
-		fmt.Printf("    %s\n", colorize(cYellow, prKey))
+		verifiedIcon := VerifiedIcon(res.VerifiedMap[prKey])
+		title := pr.GetTitle()
+		for _, tag := range []string{res.TeamMap.Tag(prKey), res.ReasonMap.Tag(prKey)} {
+			if tag != "" {
+				title += " (" + tag + ")"
+			}
+		}
+		fmt.Fprintf(w, "  %s %s %s\n", colorize(cYellow, fmt.Sprintf("[%d/%d]", i+1, len(prs))), verifiedIcon, colorize(cYellow, title))
+		fmt.Fprintf(w, "    %s\n", colorize(cYellow, prKey))
 		if i == 0 {
 			firstPrKey = prKey
 		}
@@ -283,39 +815,168 @@ func VerifiedIcon(verified bool) string {
 	return "❌"
 }
 
-func promptActionForHash(h string, idx, total, prProgressIndex, totalPRs int, in *bufio.Reader, g *gh.GhClient, propagate bool, approved, declined, prSkipped map[string]bool, hashPrMap gh.HashPrMap, prMap map[string][]string) {
+// manualState is a copy of the decisions made in ManualApproval before the
+// prompt for hashes[idx], restored when the user goes back.
+type manualState struct {
+	idx                           int
+	approved, declined, prSkipped map[string]bool
+	firstSeen                     map[string]string
+}
+
+func saveManualState(idx int, approved, declined, prSkipped map[string]bool, firstSeen map[string]string) manualState {
+	return manualState{
+		idx:       idx,
+		approved:  maps.Clone(approved),
+		declined:  maps.Clone(declined),
+		prSkipped: maps.Clone(prSkipped),
+		firstSeen: maps.Clone(firstSeen),
+	}
+}
+
+// promptResult is what the user chose at the manual approval prompt.
+type promptResult int
+
+const (
+	promptDecided      promptResult = iota // h was approved or declined
+	promptQuit                             // stop without approving anything
+	promptApprovedRest                     // h and every undecided hash after it were approved
+	promptBack                             // revisit the previous hash
+)
+
+// promptActionForHash asks on w what to do with h until it gets an answer
+// from in, recording the decision. rest holds h and the hashes still to be
+// reviewed after it, for the approve-all-remaining answer A.
+func promptActionForHash(ctx context.Context, w io.Writer, h string, rest []string, idx, total, prProgressIndex, totalPRs int, in *bufio.Reader, g *gh.GhClient, propagate bool, approved, declined, prSkipped map[string]bool, hashPrMap gh.HashPrMap, prMap map[string][]string) promptResult {
 	for {
-		fmt.Print(colorize(cOrange, fmt.Sprintf("pr %d/%d hash: %d/%d approve this hash? (y/n/s/q) ", prProgressIndex, totalPRs, idx+1, total)))
+		fmt.Fprint(w, colorize(cOrange, fmt.Sprintf("pr %d/%d hash: %d/%d approve this hash? (y/n/s/g/o/b/A/q) ", prProgressIndex, totalPRs, idx+1, total)))
 		input, _ := in.ReadString('\n')
-		input = strings.TrimSpace(strings.ToLower(input))
-		switch input {
+		input = strings.TrimSpace(input)
+		// capital A approves everything left; lower-case a is y
+		if input == "A" {
+			undecided := undecidedHashes(rest, approved, declined, prSkipped, hashPrMap)
+			if confirm(w, in, fmt.Sprintf("Approve this and all %d remaining undecided hash(es)? (y/N) ", len(undecided))) {
+				for _, uh := range undecided {
+					approved[uh] = true
+					if propagate {
+						ApproveLinkedHashes(w, uh, approved, declined, hashPrMap, prMap)
+					}
+				}
+				return promptApprovedRest
+			}
+			continue
+		}
+		switch strings.ToLower(input) {
 		case "y", "a":
 			approved[h] = true
 			if propagate {
-				ApproveLinkedHashes(h, approved, declined, hashPrMap, prMap, false)
+				ApproveLinkedHashes(w, h, approved, declined, hashPrMap, prMap)
 			}
-			return
+			return promptDecided
 		case "n", "d":
 			declined[h] = true
-			DeclineLinkedHashes(h, declined, prSkipped, hashPrMap, prMap, false)
-			return
+			DeclineLinkedHashes(w, h, declined, prSkipped, hashPrMap, prMap)
+			return promptDecided
 		case "q":
-			fmt.Println("Quitting manual approval early.")
-			os.Exit(0)
+			fmt.Fprintln(w, "Quitting manual approval early.")
+			return promptQuit
+		case "b":
+			return promptBack
 		case "s":
-			showPrComments(h, hashPrMap, g)
+			showPrComments(ctx, w, h, hashPrMap, g)
+		case "g":
+			showFullDiff(ctx, w, h, hashPrMap, g)
+		case "o":
+			openFirstPr(w, h, hashPrMap)
 		default:
-			fmt.Println("Please enter y (approve), n (decline), s (show comment) or q (quit)")
+			fmt.Fprintln(w, "Please enter y (approve), n (decline), s (show comment), g (show full diff), o (open PR), b (back to the previous hash), A (approve all remaining) or q (quit)")
+		}
+	}
+}
+
+// undecidedHashes returns the hashes in list that are neither approved nor
+// declined and don't belong to a skipped PR.
+func undecidedHashes(list []string, approved, declined, prSkipped map[string]bool, hashPrMap gh.HashPrMap) []string {
+	var out []string
+	for _, h := range list {
+		if !approved[h] && !declined[h] && !isHashSkipped(h, hashPrMap, prSkipped) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// confirm asks question on w and reports whether the answer read from in
+// was yes.
+func confirm(w io.Writer, in *bufio.Reader, question string) bool {
+	fmt.Fprint(w, colorize(cOrange, question))
+	answer, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// openFirstPr opens the first PR linked to h in the browser, printing its URL
+// to w instead when no browser can be launched (e.g. over SSH).
+func openFirstPr(w io.Writer, h string, hashPrMap gh.HashPrMap) {
+	prs := hashPrMap[h]
+	if len(prs) == 0 {
+		fmt.Fprintln(w, colorize(cYellow, "No PR to open for this hash."))
+		return
+	}
+	url := prs[0].GetHTMLURL()
+	if err := browser.Open(url); err != nil {
+		if !errors.Is(err, browser.ErrNoBrowser) {
+			fmt.Fprintln(w, colorize(cRed, fmt.Sprintf("Could not open a browser: %v", err)))
 		}
+		fmt.Fprintln(w, "Open in your browser: "+colorize(cCyan, url))
+		return
+	}
+	fmt.Fprintln(w, "Opened "+colorize(cCyan, url))
+}
+
+// showFullDiff prints the whole diff of the first PR linked to h to w, for
+// context the hunk alone doesn't give.
+func showFullDiff(ctx context.Context, w io.Writer, h string, hashPrMap gh.HashPrMap, g *gh.GhClient) {
+	prs := hashPrMap[h]
+	if len(prs) == 0 {
+		fmt.Fprintln(w, colorize(cYellow, "No PR to show the diff of for this hash."))
+		return
+	}
+	diff, err := g.GetRawDiff(ctx, prs[0])
+	if err != nil {
+		fmt.Fprintln(w, colorize(cRed, err.Error()))
+		return
+	}
+	fmt.Fprintln(w, colorize(cGreen, "Full diff of "+prs[0].GetHTMLURL()+":"))
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		fmt.Fprintln(w, colorizeDiffLine(line))
 	}
 }
 
-func showPrComments(h string, hashPrMap gh.HashPrMap, g *gh.GhClient) {
+// colorizeDiffLine colors a unified diff line: file headers yellow, hunk
+// headers cyan, additions green and removals red.
+func colorizeDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "diff --git "), strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+		return colorize(cYellow, line)
+	case strings.HasPrefix(line, "@@"):
+		return colorize(cCyan, line)
+	case strings.HasPrefix(line, "+"):
+		return colorize(cGreen, line)
+	case strings.HasPrefix(line, "-"):
+		return colorize(cRed, line)
+	}
+	return line
+}
+
+func showPrComments(ctx context.Context, w io.Writer, h string, hashPrMap gh.HashPrMap, g *gh.GhClient) {
 	var comment string
 	for _, pr := range hashPrMap[h] {
-		c, err := g.GetPrComment(pr)
+		c, err := g.GetPrCommentOrDiscussion(ctx, pr)
 		if err != nil {
-			fmt.Println(colorize(cRed, fmt.Sprintf("Error fetching comment for PR %s: %v", pr.GetHTMLURL(), err)))
+			fmt.Fprintln(w, colorize(cRed, fmt.Sprintf("Error fetching comment for PR %s: %v", pr.GetHTMLURL(), err)))
 			continue
 		}
 		if c != "" {
@@ -327,74 +988,305 @@ func showPrComments(h string, hashPrMap gh.HashPrMap, g *gh.GhClient) {
 		}
 	}
 	if comment != "" {
-		fmt.Println(colorize(cGreen, "Review comment:"))
-		fmt.Println(comment)
+		fmt.Fprintln(w, colorize(cGreen, "Review comment:"))
+		fmt.Fprintln(w, comment)
 	} else {
-		fmt.Println(colorize(cYellow, "No review comment found for this hash."))
+		fmt.Fprintln(w, colorize(cYellow, "No review comment found for this hash."))
 	}
 }
 
 // ProcessApprovals walks prMap and approves PRs where all hashes are approved.
+// With approveOpts.SubmitDeclines, skipped PRs whose hashes were all declined
+// get a REQUEST_CHANGES review instead.
 // It returns a slice of log lines (already colorized) so callers can display
 // them however they like (print to stdout for CLI, show in popup for GUI).
-func ProcessApprovals(prMap map[string][]string, approved, declined, prSkipped map[string]bool, hashPrMap gh.HashPrMap, g *gh.GhClient, dryRun bool, reviewBody string) []string {
-	var logs []string
+// It never prints itself; anything g reports while approving goes to the
+// writer set with gh.WithOutput.
+func ProcessApprovals(ctx context.Context, prMap map[string][]string, approved, declined, prSkipped map[string]bool, hashPrMap gh.HashPrMap, g *gh.GhClient, approveOpts gh.ApproveOptions) []string {
+	return processApprovals(ctx, prMap, approved, declined, prSkipped, hashPrMap, g, approveOpts, nil).logs
+}
+
+// ProgressFunc is called by ProcessApprovalsWithProgress before each PR is
+// processed, with its 1-based position among total PRs.
+type ProgressFunc func(n, total int, prKey string)
+
+// ProcessApprovalsWithProgress is ProcessApprovals reporting each PR to
+// progress as it goes, so a caller running it in the background can show
+// how far along it is. The maps must not be modified until it returns.
+// Besides the log lines it returns what was done to each approved PR, the
+// PRs left unapproved because they changed since they were loaded, and an
+// *ApprovalError if any review failed.
+func ProcessApprovalsWithProgress(ctx context.Context, prMap map[string][]string, approved, declined, prSkipped map[string]bool, hashPrMap gh.HashPrMap, g *gh.GhClient, approveOpts gh.ApproveOptions, progress ProgressFunc) ([]string, []gh.ApproveResult, []string, error) {
+	sum := processApprovals(ctx, prMap, approved, declined, prSkipped, hashPrMap, g, approveOpts, progress)
+	return sum.logs, sum.results, sum.changed, sum.err()
+}
+
+// approvalSummary is the outcome of processApprovals, by PR URL.
+type approvalSummary struct {
+	logs     []string
+	approved []string // approved, or would be in a dry run
+	declined []string // changes requested, or would be in a dry run
+	skipped  []string // declined or not fully approved
+	notGreen []string // skipped for checks that have not passed, with their state
+	failed   []string
+	changed  []string           // skipped as their head moved since they were loaded
+	results  []gh.ApproveResult // one per PR actually approved
+
+	// reasons says why each PR in skipped was skipped.
+	reasons map[string]string
+	// errors holds the error each PR in failed failed with.
+	errors map[string]string
+}
+
+// skip records prKey as skipped for reason.
+func (s *approvalSummary) skip(prKey, reason string) {
+	s.skipped = append(s.skipped, prKey)
+	if s.reasons == nil {
+		s.reasons = map[string]string{}
+	}
+	s.reasons[prKey] = reason
+}
+
+// fail records that action ("approve", "request changes on") on prKey
+// failed with err.
+func (s *approvalSummary) fail(prKey, action string, err error) {
+	s.failed = append(s.failed, prKey)
+	s.logs = append(s.logs, colorize(cRed, fmt.Sprintf("Failed to %s PR %s: %v", action, prKey, err)))
+	if s.errors == nil {
+		s.errors = map[string]string{}
+	}
+	s.errors[prKey] = err.Error()
+}
+
+// ApprovalError reports the PRs whose review failed to submit. Succeeded
+// counts the approvals that went through, so callers can tell a partial
+// failure from a total one.
+type ApprovalError struct {
+	Failed    []string
+	Succeeded int
+}
+
+func (e *ApprovalError) Error() string {
+	return fmt.Sprintf("%d of %d approvals failed", len(e.Failed), len(e.Failed)+e.Succeeded)
+}
+
+// Partial reports whether some PRs were approved despite the failures.
+func (e *ApprovalError) Partial() bool {
+	return e.Succeeded > 0
+}
+
+// err returns an *ApprovalError if any review in s failed, nil otherwise.
+func (s approvalSummary) err() error {
+	if len(s.failed) == 0 {
+		return nil
+	}
+	return &ApprovalError{Failed: s.failed, Succeeded: len(s.approved)}
+}
+
+// describeApproval summarizes what ApprovePr did, e.g.
+// "review #12345, auto-merge enabled".
+func describeApproval(res gh.ApproveResult) string {
+	desc := fmt.Sprintf("review #%d", res.ReviewID)
+	switch {
+	case res.AutoMerge:
+		desc += ", auto-merge enabled"
+	case res.Merged:
+		desc += ", merged"
+	}
+	if res.Read {
+		desc += ", notification marked read"
+	}
+	return desc
+}
+
+// summarizeApprovals totals results, e.g. "Approved 3 PR(s): 2 with
+// auto-merge, 1 merged", or "" if nothing was approved.
+func summarizeApprovals(results []gh.ApproveResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	var autoMerge, merged int
+	for _, res := range results {
+		if res.AutoMerge {
+			autoMerge++
+		}
+		if res.Merged {
+			merged++
+		}
+	}
+	return fmt.Sprintf("Approved %d PR(s): %d with auto-merge, %d merged", len(results), autoMerge, merged)
+}
+
+func processApprovals(ctx context.Context, prMap map[string][]string, approved, declined, prSkipped map[string]bool, hashPrMap gh.HashPrMap, g *gh.GhClient, approveOpts gh.ApproveOptions, progress ProgressFunc) approvalSummary {
+	var sum approvalSummary
 	// Sort keys for deterministic output.
 	var prKeys []string
 	for k := range prMap {
 		prKeys = append(prKeys, k)
 	}
 	sort.Strings(prKeys)
-	for _, prKey := range prKeys {
+	prs := hashPrMap.PRsByURL()
+	for i, prKey := range prKeys {
 		phashes := prMap[prKey]
-		if len(phashes) == 0 || prSkipped[prKey] {
-			if prSkipped[prKey] {
-				logs = append(logs, colorize(cYellow, fmt.Sprintf("Not approving PR %s (skipped due to a declined hash)", prKey)))
+		if len(phashes) == 0 {
+			continue
+		}
+		if progress != nil {
+			progress(i+1, len(prKeys), prKey)
+		}
+		if prSkipped[prKey] {
+			if approveOpts.SubmitDeclines && allHashesDeclined(phashes, declined) {
+				requestChanges(ctx, &sum, prKey, prs, g, approveOpts)
+				continue
 			}
+			sum.skip(prKey, "a declined hash")
+			sum.logs = append(sum.logs, colorize(cYellow, fmt.Sprintf("Not approving PR %s (skipped due to a declined hash)", prKey)))
 			continue
 		}
-		if !allHashesApproved(phashes, approved, declined) {
+		if !ShouldApprovePR(prKey, phashes, approved, declined, prSkipped) {
+			sum.skip(prKey, "not every hash approved")
+			continue
+		}
+		pr, err := queuedPR(prs, prKey)
+		if err != nil {
+			sum.fail(prKey, "approve", err)
 			continue
 		}
-		pr := findPrByURL(prKey, hashPrMap)
-		if pr == nil {
-			logs = append(logs, colorize(cRed, fmt.Sprintf("Could not find PR object for %s to approve", prKey)))
+		if approveOpts.RequireGreen && !checksGreen(ctx, &sum, prKey, pr, g) {
+			sum.skip(prKey, "checks not green")
 			continue
 		}
-		if dryRun {
-			logs = append(logs, colorize(cYellow, fmt.Sprintf("[dry-run] Would approve PR %s", prKey)))
-		} else if err := g.ApprovePr(pr, reviewBody); err != nil {
-			logs = append(logs, colorize(cRed, fmt.Sprintf("Failed to approve PR %s: %v", prKey, err)))
+		if alreadyApproved(ctx, &sum, prKey, pr, g, approveOpts) {
+			sum.skip(prKey, "already approved at its head commit")
+		} else if res, err := g.ApprovePr(ctx, pr, approveOpts); errors.As(err, new(*gh.HeadChangedError)) {
+			sum.skip(prKey, "changed since it was loaded")
+			sum.changed = append(sum.changed, prKey)
+			sum.logs = append(sum.logs, colorize(cYellow, fmt.Sprintf("Not approving PR %s (changed since it was loaded; re-review it or use --force)", prKey)))
+		} else if err != nil {
+			sum.fail(prKey, "approve", err)
 		} else {
-			logs = append(logs, colorize(cGreen, fmt.Sprintf("Approved PR %s", prKey)))
+			sum.approved = append(sum.approved, prKey)
+			if !approveOpts.DryRun {
+				sum.results = append(sum.results, res)
+			}
+			sum.logs = append(sum.logs, approvedLine(prKey, res, approveOpts))
+		}
+	}
+	if line := summarizeApprovals(sum.results); line != "" {
+		sum.logs = append(sum.logs, colorize(cGreen, line))
+	}
+	if len(sum.notGreen) > 0 {
+		sum.logs = append(sum.logs, colorize(cYellow, "Skipped (checks not green):"))
+		for _, line := range sum.notGreen {
+			sum.logs = append(sum.logs, colorize(cYellow, "  "+line))
+		}
+	}
+	return sum
+}
+
+// approvedLine is the log line for prKey once ApprovePr returned res, which
+// in a dry run names what it would have done instead.
+func approvedLine(prKey string, res gh.ApproveResult, approveOpts gh.ApproveOptions) string {
+	if approveOpts.DryRun {
+		return colorize(cYellow, approveOpts.DryRunLine(prKey))
+	}
+	return colorize(cGreen, fmt.Sprintf("Approved PR %s (%s)", prKey, describeApproval(res)))
+}
+
+// checksGreen reports whether pr's checks have all passed, recording it in
+// sum.notGreen otherwise. A failed lookup counts as not green.
+func checksGreen(ctx context.Context, sum *approvalSummary, prKey string, pr *github.PullRequest, g *gh.GhClient) bool {
+	state, err := g.CombinedStatus(ctx, pr)
+	if err != nil {
+		sum.notGreen = append(sum.notGreen, fmt.Sprintf("%s (%v)", prKey, err))
+		return false
+	}
+	if state != gh.CheckSuccess {
+		sum.notGreen = append(sum.notGreen, fmt.Sprintf("%s (%s)", prKey, state))
+		return false
+	}
+	return true
+}
+
+// alreadyApproved reports whether the user already approved pr at its head
+// commit, logging the skip to sum. It is always false with approveOpts.Force.
+// A failed lookup is logged and treated as not approved.
+func alreadyApproved(ctx context.Context, sum *approvalSummary, prKey string, pr *github.PullRequest, g *gh.GhClient, approveOpts gh.ApproveOptions) bool {
+	if approveOpts.Force {
+		return false
+	}
+	ok, err := g.HasApproved(ctx, pr)
+	if err != nil {
+		sum.logs = append(sum.logs, colorize(cYellow, fmt.Sprintf("warning: could not check existing reviews on PR %s: %v", prKey, err)))
+		return false
+	}
+	if ok {
+		sum.logs = append(sum.logs, colorize(cYellow, fmt.Sprintf("Not approving PR %s (already approved at its head commit; use --force to approve again)", prKey)))
+	}
+	return ok
+}
+
+// requestChanges submits a REQUEST_CHANGES review for prKey and records the
+// outcome in sum, with approveOpts.DeclineComment as the review's body.
+func requestChanges(ctx context.Context, sum *approvalSummary, prKey string, prs map[string]*github.PullRequest, g *gh.GhClient, approveOpts gh.ApproveOptions) {
+	pr, err := queuedPR(prs, prKey)
+	switch {
+	case err != nil:
+		sum.fail(prKey, "request changes on", err)
+	case approveOpts.DryRun:
+		sum.declined = append(sum.declined, prKey)
+		sum.logs = append(sum.logs, colorize(cYellow, fmt.Sprintf("[dry-run] Would request changes on PR %s", prKey)))
+	default:
+		if err := g.RequestChanges(ctx, pr, approveOpts.DeclineComment); err != nil {
+			sum.fail(prKey, "request changes on", err)
+			return
 		}
+		sum.declined = append(sum.declined, prKey)
+		sum.logs = append(sum.logs, colorize(cOrange, fmt.Sprintf("Requested changes on PR %s", prKey)))
 	}
-	return logs
 }
 
-func allHashesApproved(phashes []string, approved, declined map[string]bool) bool {
+func allHashesDeclined(phashes []string, declined map[string]bool) bool {
 	for _, ph := range phashes {
-		if declined[ph] || !approved[ph] {
+		if !declined[ph] {
 			return false
 		}
 	}
-	return len(approved) > 0
+	return len(phashes) > 0
 }
 
-func findPrByURL(url string, hashPrMap gh.HashPrMap) *github.PullRequest {
-	for _, prs := range hashPrMap {
-		for _, pr := range prs {
-			if pr.GetHTMLURL() == url {
-				return pr
-			}
+// ShouldApprovePR reports whether the PR prKey, made of hashes, is ready to
+// be approved: it is not in skipped and every one of its hashes is approved
+// and none declined. A PR without hashes is never approved. It is the single
+// rule behind both the GUI's staged list and what a commit approves; pass a
+// nil skipped map to ask whether the hashes alone would stage the PR.
+func ShouldApprovePR(prKey string, hashes []string, approved, declined, skipped map[string]bool) bool {
+	if skipped[prKey] || len(hashes) == 0 {
+		return false
+	}
+	for _, h := range hashes {
+		if declined[h] || !approved[h] {
+			return false
 		}
 	}
-	return nil
+	return true
+}
+
+// queuedPR returns the PR object for prKey from prs, as built by
+// gh.HashPrMap.PRsByURL. A missing one means the PR and hash maps of the
+// queue are out of sync.
+func queuedPR(prs map[string]*github.PullRequest, prKey string) (*github.PullRequest, error) {
+	pr, ok := prs[prKey]
+	if !ok || pr == nil {
+		return nil, fmt.Errorf("PR %s is not in the review queue", prKey)
+	}
+	return pr, nil
 }
 
-// ApproveLinkedHashes auto-approves hashes linked in the same PR(s) as h.
-// When quiet is true, no output is printed.
-func ApproveLinkedHashes(h string, approved, declined map[string]bool, hashPrMap gh.HashPrMap, prMap map[string][]string, quiet bool) {
+// ApproveLinkedHashes auto-approves hashes linked in the same PR(s) as h,
+// reporting each to w. The GUI passes io.Discard since anything written to
+// stdout corrupts the Bubble Tea screen.
+func ApproveLinkedHashes(w io.Writer, h string, approved, declined map[string]bool, hashPrMap gh.HashPrMap, prMap map[string][]string) {
 	prs, ok := hashPrMap[h]
 	if !ok {
 		return
@@ -410,16 +1302,14 @@ func ApproveLinkedHashes(h string, approved, declined map[string]bool, hashPrMap
 				continue
 			}
 			approved[lh] = true
-			if !quiet {
-				fmt.Println(colorize(cYellow, fmt.Sprintf("Auto-approved linked hash %s (from PR %s)", lh, prKey)))
-			}
+			fmt.Fprintln(w, colorize(cYellow, fmt.Sprintf("Auto-approved linked hash %s (from PR %s)", lh, prKey)))
 		}
 	}
 }
 
-// DeclineLinkedHashes marks PRs containing h as skipped and declines linked hashes.
-// When quiet is true, no output is printed.
-func DeclineLinkedHashes(h string, declined, prSkipped map[string]bool, hashPrMap gh.HashPrMap, prMap map[string][]string, quiet bool) {
+// DeclineLinkedHashes marks PRs containing h as skipped and declines linked
+// hashes, reporting each to w as for ApproveLinkedHashes.
+func DeclineLinkedHashes(w io.Writer, h string, declined, prSkipped map[string]bool, hashPrMap gh.HashPrMap, prMap map[string][]string) {
 	prs, ok := hashPrMap[h]
 	if !ok {
 		return
@@ -428,9 +1318,7 @@ func DeclineLinkedHashes(h string, declined, prSkipped map[string]bool, hashPrMa
 		prKey := pr.GetHTMLURL()
 		if !prSkipped[prKey] {
 			prSkipped[prKey] = true
-			if !quiet {
-				fmt.Println(colorize(cYellow, fmt.Sprintf("Skipping PR %s because hash %s was declined", prKey, h)))
-			}
+			fmt.Fprintln(w, colorize(cYellow, fmt.Sprintf("Skipping PR %s because hash %s was declined", prKey, h)))
 		}
 		linked, ok := prMap[prKey]
 		if !ok {
@@ -441,9 +1329,7 @@ func DeclineLinkedHashes(h string, declined, prSkipped map[string]bool, hashPrMa
 				continue
 			}
 			declined[lh] = true
-			if !quiet {
-				fmt.Println(colorize(cYellow, fmt.Sprintf("Marked linked hash %s as declined due to PR %s", lh, prKey)))
-			}
+			fmt.Fprintln(w, colorize(cYellow, fmt.Sprintf("Marked linked hash %s as declined due to PR %s", lh, prKey)))
 		}
 	}
 }
@@ -451,20 +1337,28 @@ func DeclineLinkedHashes(h string, declined, prSkipped map[string]bool, hashPrMa
 // PrepareGUI fetches data and, if user is empty, returns the list of available
 // usernames so a selection panel can be shown. When user is non-empty it behaves
 // like PrepareManualApproval and pre-filters hashes for that user.
-func PrepareGUI(user string) (hashes []string, availableUsers []string, userHashPrMap gh.GhPrHashMap, changeMap gh.HashChangeMap, hashPrMap gh.HashPrMap, prMap map[string][]string, verifiedMap gh.PrVerifiedMap, hashFileMap gh.HashFileMap, rawChangeMap gh.HashRawChangeMap, client *gh.GhClient, err error) {
-	client = gh.NewGhClient()
-	userHashPrMap, changeMap, hashPrMap, prMap, verifiedMap, hashFileMap, rawChangeMap, err = client.GetPrReviewRequested()
+func PrepareGUI(ctx context.Context, user string, fetch gh.FetchOptions, opts ...gh.Option) (hashes []string, availableUsers []string, res *gh.FetchResult, client *gh.GhClient, err error) {
+	client, err = gh.NewGhClient(opts...)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	// without a user the selection panel needs every author
+	if user != "" {
+		res, err = client.GetPrReviewRequestedForUser(ctx, splitUsers(user), fetch)
+	} else {
+		res, err = client.GetPrReviewRequested(ctx, fetch)
+	}
 	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("error fetching PR review requests: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("error fetching PR review requests: %w", err)
 	}
 	// build sorted list of available users
-	for u := range userHashPrMap {
+	for u := range res.UserHashPrMap {
 		availableUsers = append(availableUsers, u)
 	}
 	sort.Strings(availableUsers)
 
 	if user != "" {
-		hashes = collectHashesForUsers(user, userHashPrMap)
+		hashes = collectHashesForUsers(user, res.UserHashPrMap)
 	}
 	return
 }
@@ -476,12 +1370,58 @@ func CollectHashesForUsers(user string, userHashPrMap gh.GhPrHashMap) []string {
 }
 
 // PrepareManualApproval fetches data required for manual approval (used by both CLI and GUI).
-func PrepareManualApproval(user string) ([]string, gh.HashChangeMap, gh.HashPrMap, map[string][]string, gh.PrVerifiedMap, *gh.GhClient, error) {
-	g := gh.NewGhClient()
-	userHashPrMap, changeMap, hashPrMap, prMap, verifiedMap, _, _, err := g.GetPrReviewRequested()
+func PrepareManualApproval(ctx context.Context, user string, fetch gh.FetchOptions, opts ...gh.Option) ([]string, *gh.FetchResult, *gh.GhClient, error) {
+	g, err := gh.NewGhClient(opts...)
 	if err != nil {
-		return nil, nil, nil, nil, nil, nil, fmt.Errorf("error fetching PR review requests: %w", err)
+		return nil, nil, nil, err
 	}
-	hashes := collectHashesForUsers(user, userHashPrMap)
-	return hashes, changeMap, hashPrMap, prMap, verifiedMap, g, nil
+	res, err := g.GetPrReviewRequestedForUser(ctx, splitUsers(user), fetch)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error fetching PR review requests: %w", err)
+	}
+	hashes := collectHashesForUsers(user, res.UserHashPrMap)
+	return hashes, res, g, nil
+}
+
+// printFetchSummary logs a warning for each PR that could not be loaded into
+// the queue and how many were left out by filters.
+func printFetchSummary(res *gh.FetchResult) {
+	if warning := res.PartialWarning(); warning != "" {
+		slog.Warn(warning)
+	}
+	for _, line := range res.FailureLines() {
+		slog.Warn(line)
+	}
+	if summary := res.FilterSummary(); summary != "" {
+		slog.Info(summary)
+	}
+}
+
+// reportAuthenticatedUser logs who the token belongs to, so a token for the
+// wrong account is noticed before anything is approved.
+func reportAuthenticatedUser(ctx context.Context, g *gh.GhClient) {
+	login, err := g.CurrentUser(ctx)
+	if err != nil {
+		slog.Warn(err.Error())
+		return
+	}
+	slog.Info("Authenticated as " + login)
+}
+
+// reportRateLimit logs the remaining core API budget, warning when it is
+// close to being exhausted.
+func reportRateLimit(ctx context.Context, g *gh.GhClient) {
+	rate, err := g.RateLimit(ctx)
+	if err != nil {
+		return
+	}
+	slog.Info(RateLimitSummary(rate))
+	if rate.Remaining < gh.LowRateLimitThreshold {
+		slog.Warn(fmt.Sprintf("only %d GitHub API requests left until %s; slow down or lower --concurrency", rate.Remaining, rate.Reset.Local().Format("15:04")))
+	}
+}
+
+// RateLimitSummary formats a rate limit as "rate limit: N/M remaining, resets at HH:MM".
+func RateLimitSummary(rate *github.Rate) string {
+	return fmt.Sprintf("rate limit: %d/%d remaining, resets at %s", rate.Remaining, rate.Limit, rate.Reset.Local().Format("15:04"))
 }
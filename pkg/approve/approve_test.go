@@ -0,0 +1,638 @@
+package approve
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/mallendem/gh-pr-review/pkg/gh"
+)
+
+// captureStdout returns everything fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	done := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- string(b)
+	}()
+	fn()
+	_ = w.Close()
+	return <-done
+}
+
+// testPR returns PR n of repository o/r.
+func testPR(n int) *github.PullRequest {
+	return &github.PullRequest{
+		Number:  github.Ptr(n),
+		HTMLURL: github.Ptr(fmt.Sprintf("https://github.com/o/r/pull/%d", n)),
+		Base:    &github.PullRequestBranch{Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}}},
+	}
+}
+
+// dryRunClient returns a client of a fake GitHub on which the user has
+// approved the PRs numbered approved and reviewed nothing else, failing t on
+// any request a dry run must not send.
+func dryRunClient(t *testing.T, approved ...int) *gh.GhClient {
+	t.Helper()
+	srv := httptest.NewServer(http.StripPrefix("/api/v3", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/user":
+			fmt.Fprint(w, `{"login":"me"}`)
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/reviews"):
+			var n int
+			_, _ = fmt.Sscanf(r.URL.Path, "/repos/o/r/pulls/%d/reviews", &n)
+			if slices.Contains(approved, n) {
+				fmt.Fprint(w, `[{"state":"APPROVED","user":{"login":"me"}}]`)
+				return
+			}
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("dry run sent %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})))
+	t.Cleanup(srv.Close)
+	g, err := gh.NewGhClientWithToken("token", gh.WithHTTPClient(srv.Client()), gh.WithBaseURL(srv.URL+"/api/v3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// testQueue returns two PRs sharing hash "a": PR 1 also contains "b" and
+// PR 2 also contains "c".
+func testQueue() (gh.HashPrMap, map[string][]string) {
+	pr1, pr2 := testPR(1), testPR(2)
+	hashPrMap := gh.HashPrMap{
+		"a": {pr1, pr2},
+		"b": {pr1},
+		"c": {pr2},
+	}
+	prMap := map[string][]string{
+		pr1.GetHTMLURL(): {"a", "b"},
+		pr2.GetHTMLURL(): {"a", "c"},
+	}
+	return hashPrMap, prMap
+}
+
+func TestApproveLinkedHashes(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	approved := map[string]bool{"a": true}
+	declined := map[string]bool{"c": true}
+
+	out := captureStdout(t, func() {
+		ApproveLinkedHashes(io.Discard, "a", approved, declined, hashPrMap, prMap)
+	})
+	if out != "" {
+		t.Errorf("ApproveLinkedHashes printed %q to stdout", out)
+	}
+	if !approved["b"] {
+		t.Error("linked hash b was not approved")
+	}
+	if approved["c"] {
+		t.Error("declined hash c was approved")
+	}
+
+	approved = map[string]bool{"a": true}
+	var buf bytes.Buffer
+	ApproveLinkedHashes(&buf, "a", approved, map[string]bool{}, hashPrMap, prMap)
+	if !strings.Contains(buf.String(), "Auto-approved linked hash") {
+		t.Errorf("ApproveLinkedHashes wrote %q, want progress lines", buf.String())
+	}
+}
+
+func TestDeclineLinkedHashes(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	declined := map[string]bool{"b": true}
+	prSkipped := map[string]bool{}
+
+	var buf bytes.Buffer
+	out := captureStdout(t, func() {
+		DeclineLinkedHashes(&buf, "b", declined, prSkipped, hashPrMap, prMap)
+	})
+	if out != "" {
+		t.Errorf("DeclineLinkedHashes printed %q to stdout", out)
+	}
+	if !strings.Contains(buf.String(), "Skipping PR https://github.com/o/r/pull/1") {
+		t.Errorf("DeclineLinkedHashes wrote %q, want the skipped PR", buf.String())
+	}
+	if !prSkipped["https://github.com/o/r/pull/1"] || prSkipped["https://github.com/o/r/pull/2"] {
+		t.Errorf("prSkipped = %v, want only PR 1", prSkipped)
+	}
+	if !declined["a"] || declined["c"] {
+		t.Errorf("declined = %v, want a and b only", declined)
+	}
+}
+
+func TestPromptActionForHash(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	tests := []struct {
+		name         string
+		input        string
+		want         promptResult
+		wantApproved []string
+		wantDeclined bool
+	}{
+		{"approve", "y\n", promptDecided, []string{"b"}, false},
+		{"lower-case a approves one", "a\n", promptDecided, []string{"b"}, false},
+		{"decline after invalid answer", "maybe\nn\n", promptDecided, nil, true},
+		{"quit", "q\n", promptQuit, nil, false},
+		{"approve all remaining", "A\nyes\n", promptApprovedRest, []string{"b", "c"}, false},
+		{"approve all remaining declined", "A\n\ny\n", promptDecided, []string{"b"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approved, declined, prSkipped := map[string]bool{}, map[string]bool{}, map[string]bool{}
+			var buf bytes.Buffer
+			in := bufio.NewReader(strings.NewReader(tt.input))
+			got := promptActionForHash(context.Background(), &buf, "b", []string{"b", "c"}, 0, 2, 1, 1, in, nil, false, approved, declined, prSkipped, hashPrMap, prMap)
+			if got != tt.want {
+				t.Errorf("promptActionForHash = %v, want %v", got, tt.want)
+			}
+			if gotApproved := trueKeys(approved); !slices.Equal(gotApproved, tt.wantApproved) {
+				t.Errorf("approved = %v, want %v", gotApproved, tt.wantApproved)
+			}
+			if declined["b"] != tt.wantDeclined {
+				t.Errorf("declined = %v", declined)
+			}
+			if !strings.Contains(buf.String(), "approve this hash?") {
+				t.Errorf("prompt not written to w: %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestReviewHashesBackUndoesDecline(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	res := &gh.FetchResult{HashPrMap: hashPrMap, PrMap: prMap}
+	// declining b also declines a through PR 1; going back from c must
+	// restore both before they are approved
+	in := bufio.NewReader(strings.NewReader("n\nb\ny\ny\ny\n"))
+	var buf bytes.Buffer
+	approved, declined, prSkipped, quit := reviewHashes(context.Background(), in, &buf, []string{"b", "a", "c"}, nil, res, nil, false)
+	if quit {
+		t.Fatal("reviewHashes reported quit")
+	}
+	if got := trueKeys(approved); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("approved = %v, want [a b c]", got)
+	}
+	if got := trueKeys(declined); len(got) != 0 {
+		t.Errorf("declined = %v, want none", got)
+	}
+	if got := trueKeys(prSkipped); len(got) != 0 {
+		t.Errorf("prSkipped = %v, want none", got)
+	}
+}
+
+func TestCollectHashesForUsersGroupsByAuthor(t *testing.T) {
+	userHashPrMap := gh.GhPrHashMap{
+		"bob":   {"a": nil, "z": nil},
+		"Alice": {"y": nil, "z": nil},
+		"carol": {"b": nil},
+	}
+	if got := collectHashesForUsers("bob,alice", userHashPrMap); !slices.Equal(got, []string{"y", "z", "a"}) {
+		t.Errorf("hashes = %v, want Alice's y and z, then bob's a", got)
+	}
+	authors := HashAuthors("bob, alice", userHashPrMap)
+	if want := map[string]string{"y": "Alice", "z": "Alice", "a": "bob"}; !maps.Equal(authors, want) {
+		t.Errorf("authors = %v, want %v", authors, want)
+	}
+
+	hashes := []string{"a", "z", "x", "y"}
+	GroupHashesByAuthor(hashes, authors)
+	if !slices.Equal(hashes, []string{"z", "y", "a", "x"}) {
+		t.Errorf("grouped = %v, want Alice's in their order, then bob's, then x without an author", hashes)
+	}
+}
+
+func TestReviewHashesAuthorHeaders(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	res := &gh.FetchResult{HashPrMap: hashPrMap, PrMap: prMap}
+	authors := map[string]string{"b": "alice", "c": "bob"}
+	in := bufio.NewReader(strings.NewReader("n\nn\n"))
+	var buf bytes.Buffer
+	if _, _, _, quit := reviewHashes(context.Background(), in, &buf, []string{"b", "c"}, authors, res, nil, false); quit {
+		t.Fatal("reviewHashes reported quit")
+	}
+	out := buf.String()
+	alice, bob := strings.Index(out, "== alice: 1 hash(es) =="), strings.Index(out, "== bob: 1 hash(es) ==")
+	if alice < 0 || bob < alice {
+		t.Errorf("want alice's header before bob's:\n%s", out)
+	}
+	// PRs are numbered in review order: b's PR 1, then c's PR 2
+	if !strings.Contains(out, "pr 1/2 hash: 1/2") || !strings.Contains(out, "pr 2/2 hash: 2/2") {
+		t.Errorf("prompts do not number PRs in review order:\n%s", out)
+	}
+}
+
+func TestReviewHashesBackAtFirstHash(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	res := &gh.FetchResult{HashPrMap: hashPrMap, PrMap: prMap}
+	in := bufio.NewReader(strings.NewReader("b\nq\n"))
+	var buf bytes.Buffer
+	if _, _, _, quit := reviewHashes(context.Background(), in, &buf, []string{"b"}, nil, res, nil, false); !quit {
+		t.Error("reviewHashes did not report quit")
+	}
+	if !strings.Contains(buf.String(), "Already at the first hash.") {
+		t.Errorf("output = %q, want a note about the first hash", buf.String())
+	}
+}
+
+// trueKeys returns the sorted keys of m that are set.
+func trueKeys(m map[string]bool) []string {
+	var keys []string
+	for k, v := range m {
+		if v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestUndecidedHashes(t *testing.T) {
+	hashPrMap, _ := testQueue()
+	got := undecidedHashes([]string{"a", "b", "c"}, map[string]bool{"a": true}, map[string]bool{}, map[string]bool{"https://github.com/o/r/pull/2": true}, hashPrMap)
+	if !slices.Equal(got, []string{"b"}) {
+		t.Errorf("undecidedHashes = %v, want [b]", got)
+	}
+}
+
+func TestProcessApprovalsDoesNotPrint(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	approved := map[string]bool{"a": true, "b": true}
+	declined := map[string]bool{}
+	prSkipped := map[string]bool{"https://github.com/o/r/pull/2": true}
+
+	var logs []string
+	out := captureStdout(t, func() {
+		logs = ProcessApprovals(context.Background(), prMap, approved, declined, prSkipped, hashPrMap, dryRunClient(t), gh.ApproveOptions{DryRun: true})
+	})
+	if out != "" {
+		t.Errorf("ProcessApprovals printed %q", out)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("got %d log lines, want 2: %q", len(logs), logs)
+	}
+	if !strings.Contains(logs[0], "Would approve PR https://github.com/o/r/pull/1; would enable auto-merge (squash)") {
+		t.Errorf("logs[0] = %q, want dry-run approval of PR 1 with its auto-merge", logs[0])
+	}
+	if !strings.Contains(logs[1], "Not approving PR https://github.com/o/r/pull/2") {
+		t.Errorf("logs[1] = %q, want PR 2 skipped", logs[1])
+	}
+}
+
+func TestProcessApprovalsSkipsPartiallyCovered(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	// "c" is not in the allowlist, so PR 2 must not be approved
+	approved := map[string]bool{"a": true, "b": true}
+
+	sum := processApprovals(context.Background(), prMap, approved, map[string]bool{}, map[string]bool{}, hashPrMap, dryRunClient(t), gh.ApproveOptions{DryRun: true}, nil)
+	if len(sum.approved) != 1 || sum.approved[0] != "https://github.com/o/r/pull/1" {
+		t.Errorf("approved = %v, want only PR 1", sum.approved)
+	}
+	if len(sum.skipped) != 1 || sum.skipped[0] != "https://github.com/o/r/pull/2" {
+		t.Errorf("skipped = %v, want only PR 2", sum.skipped)
+	}
+	if len(sum.failed) != 0 {
+		t.Errorf("failed = %v, want none", sum.failed)
+	}
+}
+
+func TestApproveHashes(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	pr3 := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/3")}
+	hashPrMap["d"] = []*github.PullRequest{pr3}
+	prMap[pr3.GetHTMLURL()] = []string{"d"}
+	res := &gh.FetchResult{HashPrMap: hashPrMap, PrMap: prMap}
+
+	// "a" touches PR 2 too, but its "c" is not supplied; PR 3 is untouched
+	var buf bytes.Buffer
+	if err := approveHashes(context.Background(), &buf, []string{"a", "b"}, res, dryRunClient(t), gh.ApproveOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Would approve PR https://github.com/o/r/pull/1",
+		"Skipped PR https://github.com/o/r/pull/2 (not every hash approved)",
+		"1 approved, 1 skipped, 0 failed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "pull/3") {
+		t.Errorf("output %q mentions PR 3, which no supplied hash touches", out)
+	}
+}
+
+func TestApproveUsersNeedsUsers(t *testing.T) {
+	err := ApproveUsers(context.Background(), io.Discard, nil, true, gh.PRSortRepo, false, gh.ViewHunks, gh.ApproveOptions{DryRun: true}, gh.FetchOptions{})
+	if err == nil {
+		t.Error("ApproveUsers without users returned nil, want an error rather than approving everyone")
+	}
+}
+
+func TestApproveForUsers(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	pr1, pr2 := hashPrMap["b"][0], hashPrMap["c"][0]
+	// alice wrote PR 1 and bob PR 2; they share hash "a"
+	res := &gh.FetchResult{
+		UserHashPrMap: gh.GhPrHashMap{
+			"Alice": {"a": {pr1}, "b": {pr1}},
+			"bob":   {"a": {pr2}, "c": {pr2}},
+		},
+		HashPrMap: hashPrMap,
+		PrMap:     prMap,
+	}
+
+	var buf bytes.Buffer
+	if err := approveForUsers(context.Background(), &buf, []string{"alice,alice"}, res, dryRunClient(t), gh.ApproveOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Would approve PR https://github.com/o/r/pull/1") {
+		t.Errorf("output %q does not approve alice's PR 1", out)
+	}
+	if strings.Contains(out, "pull/2") {
+		t.Errorf("output %q mentions bob's PR 2", out)
+	}
+	if n := strings.Count(out, "alice: 1 approved, 0 skipped, 0 failed"); n != 1 {
+		t.Errorf("output %q has %d summaries for alice, want 1", out, n)
+	}
+}
+
+func TestReadHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hashes.txt")
+	content := "# reviewed 2025-01-31\nabc123\n\n  def456  \n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readHashFile(path)
+	if err != nil {
+		t.Fatalf("readHashFile: %v", err)
+	}
+	if strings.Join(got, ",") != "abc123,def456" {
+		t.Errorf("readHashFile = %q, want [abc123 def456]", got)
+	}
+	if _, err := readHashFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("readHashFile on a missing file succeeded, want error")
+	}
+}
+
+func TestProcessApprovalsDryRunSkipsApproved(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	approved := map[string]bool{"a": true, "b": true, "c": true}
+	sum := processApprovals(context.Background(), prMap, approved, map[string]bool{}, map[string]bool{}, hashPrMap, dryRunClient(t, 2), gh.ApproveOptions{DryRun: true}, nil)
+	if !slices.Equal(sum.approved, []string{"https://github.com/o/r/pull/1"}) {
+		t.Errorf("approved %v, want only PR 1", sum.approved)
+	}
+	if got := sum.reasons["https://github.com/o/r/pull/2"]; got != "already approved at its head commit" {
+		t.Errorf("PR 2 skipped for %q, want already approved", got)
+	}
+	if len(sum.results) != 0 {
+		t.Errorf("dry run recorded results %v", sum.results)
+	}
+}
+
+func TestProcessApprovalsSubmitDeclines(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	declined := map[string]bool{"a": true, "b": true, "c": true}
+	prSkipped := map[string]bool{}
+	for _, h := range []string{"a", "b"} {
+		DeclineLinkedHashes(io.Discard, h, declined, prSkipped, hashPrMap, prMap)
+	}
+
+	sum := processApprovals(context.Background(), prMap, map[string]bool{}, declined, prSkipped, hashPrMap, dryRunClient(t), gh.ApproveOptions{DryRun: true}, nil)
+	if len(sum.declined) != 0 || len(sum.skipped) != 2 {
+		t.Errorf("without SubmitDeclines: declined %v, skipped %v; want none and both", sum.declined, sum.skipped)
+	}
+
+	sum = processApprovals(context.Background(), prMap, map[string]bool{}, declined, prSkipped, hashPrMap, dryRunClient(t), gh.ApproveOptions{DryRun: true, SubmitDeclines: true}, nil)
+	if len(sum.declined) != 2 || len(sum.skipped) != 0 {
+		t.Errorf("with SubmitDeclines: declined %v, skipped %v; want both and none", sum.declined, sum.skipped)
+	}
+}
+
+func TestProcessApprovalsWithProgress(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	approved := map[string]bool{"a": true, "b": true, "c": true}
+
+	var got []string
+	_, _, _, _ = ProcessApprovalsWithProgress(context.Background(), prMap, approved, map[string]bool{}, map[string]bool{}, hashPrMap, dryRunClient(t), gh.ApproveOptions{DryRun: true}, func(n, total int, prKey string) {
+		got = append(got, fmt.Sprintf("%d/%d %s", n, total, prKey))
+	})
+	want := []string{"1/2 https://github.com/o/r/pull/1", "2/2 https://github.com/o/r/pull/2"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("progress = %v, want %v", got, want)
+	}
+}
+
+func TestUserWorkloads(t *testing.T) {
+	pr := func(n int) *github.PullRequest {
+		return &github.PullRequest{HTMLURL: github.Ptr(fmt.Sprintf("https://github.com/o/r/pull/%d", n))}
+	}
+	m := gh.GhPrHashMap{
+		"bob":   {"a": {pr(1)}},
+		"alice": {"b": {pr(2)}},
+		"carol": {"c": {pr(3), pr(4)}, "d": {pr(3)}},
+	}
+
+	got := userWorkloads(m, SortByCount)
+	want := []userWorkload{{"carol", 2, 2}, {"alice", 1, 1}, {"bob", 1, 1}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("by count = %v, want %v", got, want)
+	}
+
+	got = userWorkloads(m, SortByName)
+	want = []userWorkload{{"alice", 1, 1}, {"bob", 1, 1}, {"carol", 2, 2}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("by name = %v, want %v", got, want)
+	}
+}
+
+func TestParseUserSort(t *testing.T) {
+	if s, err := ParseUserSort(""); err != nil || s != SortByCount {
+		t.Errorf("ParseUserSort(\"\") = %q, %v; want count", s, err)
+	}
+	if s, err := ParseUserSort("Name"); err != nil || s != SortByName {
+		t.Errorf("ParseUserSort(\"Name\") = %q, %v; want name", s, err)
+	}
+	if _, err := ParseUserSort("size"); err == nil {
+		t.Error("ParseUserSort(\"size\") succeeded, want error")
+	}
+}
+
+func TestSummarizeApprovals(t *testing.T) {
+	if got := summarizeApprovals(nil); got != "" {
+		t.Errorf("summarizeApprovals(nil) = %q, want empty", got)
+	}
+	results := []gh.ApproveResult{{ReviewID: 1, AutoMerge: true}, {ReviewID: 2, Merged: true}, {ReviewID: 3}}
+	if got, want := summarizeApprovals(results), "Approved 3 PR(s): 1 with auto-merge, 1 merged"; got != want {
+		t.Errorf("summarizeApprovals = %q, want %q", got, want)
+	}
+	if got, want := describeApproval(results[0]), "review #1, auto-merge enabled"; got != want {
+		t.Errorf("describeApproval = %q, want %q", got, want)
+	}
+}
+
+func TestShouldApprovePR(t *testing.T) {
+	const pr = "https://github.com/o/r/pull/1"
+	tests := []struct {
+		name     string
+		hashes   []string
+		approved map[string]bool
+		declined map[string]bool
+		skipped  map[string]bool
+		want     bool
+	}{
+		{"all approved", []string{"a", "b"}, map[string]bool{"a": true, "b": true}, nil, nil, true},
+		{"partially approved", []string{"a", "b"}, map[string]bool{"a": true}, nil, nil, false},
+		{"all declined", []string{"a", "b"}, nil, map[string]bool{"a": true, "b": true}, nil, false},
+		{"approved and declined", []string{"a", "b"}, map[string]bool{"a": true, "b": true}, map[string]bool{"b": true}, nil, false},
+		{"skipped", []string{"a"}, map[string]bool{"a": true}, nil, map[string]bool{pr: true}, false},
+		{"other PR skipped", []string{"a"}, map[string]bool{"a": true}, nil, map[string]bool{"https://github.com/o/r/pull/2": true}, true},
+		{"empty approvals", []string{"a"}, map[string]bool{}, nil, nil, false},
+		{"nil approvals", []string{"a"}, nil, nil, nil, false},
+		{"approvals for other hashes", []string{"a"}, map[string]bool{"b": true}, nil, nil, false},
+		{"no hashes", nil, map[string]bool{"a": true}, nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShouldApprovePR(pr, tt.hashes, tt.approved, tt.declined, tt.skipped); got != tt.want {
+				t.Errorf("ShouldApprovePR = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// An empty approved map must approve nothing, even for PRs with no declined
+// hashes; the GUI's staged list behaves the same way.
+func TestProcessApprovalsEmptyApproved(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+
+	sum := processApprovals(context.Background(), prMap, map[string]bool{}, map[string]bool{}, map[string]bool{}, hashPrMap, dryRunClient(t), gh.ApproveOptions{DryRun: true}, nil)
+	if len(sum.approved) != 0 {
+		t.Errorf("approved = %v, want none", sum.approved)
+	}
+	if len(sum.skipped) != 2 {
+		t.Errorf("skipped = %v, want both PRs", sum.skipped)
+	}
+}
+
+func TestColorizeDiffLine(t *testing.T) {
+	defer func(on bool) { colorEnabled = on }(colorEnabled)
+	colorEnabled = true
+	tests := map[string]string{
+		"diff --git a/a.go b/a.go": cYellow,
+		"--- a/a.go":               cYellow,
+		"+++ b/a.go":               cYellow,
+		"@@ -1 +1 @@":              cCyan,
+		"+added":                   cGreen,
+		"-removed":                 cRed,
+	}
+	for line, col := range tests {
+		if got := colorizeDiffLine(line); got != colorize(col, line) {
+			t.Errorf("colorizeDiffLine(%q) = %q", line, got)
+		}
+	}
+	if got := colorizeDiffLine(" context"); got != " context" {
+		t.Errorf("context line colored: %q", got)
+	}
+
+	// piped output stays plain
+	colorEnabled = false
+	if got := colorizeDiffLine("+added"); got != "+added" {
+		t.Errorf("colorizeDiffLine without color = %q, want the plain line", got)
+	}
+}
+
+func TestProcessApprovalsMissingPR(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	// a PR key without a PR object behind it in hashPrMap
+	prMap["https://github.com/o/r/pull/3"] = []string{"d"}
+	approved := map[string]bool{"a": true, "b": true, "c": true, "d": true}
+
+	sum := processApprovals(context.Background(), prMap, approved, map[string]bool{}, map[string]bool{}, hashPrMap, dryRunClient(t), gh.ApproveOptions{DryRun: true}, nil)
+	if !slices.Equal(sum.failed, []string{"https://github.com/o/r/pull/3"}) {
+		t.Errorf("failed = %v, want only PR 3", sum.failed)
+	}
+	if len(sum.approved) != 2 {
+		t.Errorf("approved = %v, want PRs 1 and 2", sum.approved)
+	}
+	if logs := strings.Join(sum.logs, "\n"); !strings.Contains(logs, "PR https://github.com/o/r/pull/3 is not in the review queue") {
+		t.Errorf("logs = %q, want an error naming PR 3", sum.logs)
+	}
+	var ae *ApprovalError
+	if err := sum.err(); !errors.As(err, &ae) || !ae.Partial() || err.Error() != "1 of 3 approvals failed" {
+		t.Errorf("err() = %v, want a partial *ApprovalError for 1 of 3", err)
+	}
+
+	// with nothing else approved the failure is total
+	sum = processApprovals(context.Background(), map[string][]string{"https://github.com/o/r/pull/3": {"d"}}, approved, map[string]bool{}, map[string]bool{}, hashPrMap, dryRunClient(t), gh.ApproveOptions{DryRun: true}, nil)
+	if err := sum.err(); !errors.As(err, &ae) || ae.Partial() {
+		t.Errorf("err() = %v, want a total *ApprovalError", err)
+	}
+}
+
+func TestSortHashesByReadiness(t *testing.T) {
+	pr := func(n int) *github.PullRequest {
+		return &github.PullRequest{HTMLURL: github.Ptr(fmt.Sprintf("https://github.com/o/r/pull/%d", n))}
+	}
+	hashPrMap := gh.HashPrMap{
+		"enough":    {pr(1)},
+		"two-away":  {pr(2)},
+		"one-away":  {pr(3)},
+		"conflicts": {pr(4)},
+		"unknown":   {pr(5)},
+		"no-rule":   {pr(6)},
+		// a hash shared with a PR one approval away ranks with it
+		"shared": {pr(1), pr(3)},
+	}
+	states := map[string]gh.ApprovalState{
+		"https://github.com/o/r/pull/1": {Approvals: 2, Required: 2, Mergeable: true},
+		"https://github.com/o/r/pull/2": {Approvals: 0, Required: 2, Mergeable: true},
+		"https://github.com/o/r/pull/3": {Approvals: 1, Required: 2, Mergeable: true},
+		"https://github.com/o/r/pull/4": {Approvals: 1, Required: 2},
+		"https://github.com/o/r/pull/6": {Approvals: 1, Mergeable: true},
+	}
+	hashes := []string{"enough", "unknown", "two-away", "no-rule", "conflicts", "one-away", "shared"}
+	SortHashesByReadiness(hashes, hashPrMap, states)
+	want := []string{"one-away", "shared", "conflicts", "two-away", "no-rule", "enough", "unknown"}
+	if !slices.Equal(hashes, want) {
+		t.Errorf("order = %v, want %v", hashes, want)
+	}
+}
+
+func TestParseQueueSort(t *testing.T) {
+	for in, want := range map[string]QueueSort{"": SortByHash, "hash": SortByHash, "Ready": SortByReady} {
+		if got, err := ParseQueueSort(in); err != nil || got != want {
+			t.Errorf("ParseQueueSort(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseQueueSort("oldest"); err == nil {
+		t.Error("ParseQueueSort(oldest) succeeded, want error")
+	}
+}
@@ -0,0 +1,174 @@
+package approve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/mallendem/gh-pr-review/pkg/gh"
+)
+
+// QueueDiff is how the review queue changed since a saved one, by PR URL.
+type QueueDiff struct {
+	// Since is when the queue compared against was saved.
+	Since time.Time `json:"since"`
+	// Added PRs were not in the saved queue; Removed ones are no longer in
+	// the queue, e.g. reviewed, merged or closed. PRs that failed to load
+	// now are not, and Removed stays empty when the queue now is partial,
+	// since missing PRs may just not have loaded.
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	// Updated PRs are in both queues with different changes, e.g. after new
+	// commits were pushed.
+	Updated []string `json:"updated"`
+	// NewlyGreen PRs are in both queues and their checks have passed since,
+	// when the saved queue recorded their state.
+	NewlyGreen []string `json:"newly_green"`
+}
+
+// diffQueues compares the queue now, whose PRs have checks, to the saved one.
+// A partial queue now (see FetchResult.Unloaded) reports no removals.
+func diffQueues(saved *gh.SavedQueue, now *gh.FetchResult, checks map[string]gh.CheckState) *QueueDiff {
+	// empty rather than null in JSON, like ManualReport
+	d := &QueueDiff{Since: saved.Saved, Added: []string{}, Removed: []string{}, Updated: []string{}, NewlyGreen: []string{}}
+	old := saved.Result.PrMap
+	for prKey, hashes := range now.PrMap {
+		oldHashes, ok := old[prKey]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, prKey)
+			continue
+		case !sameHashes(oldHashes, hashes):
+			d.Updated = append(d.Updated, prKey)
+		}
+		if was, ok := saved.Checks[prKey]; ok && was != gh.CheckSuccess && checks[prKey] == gh.CheckSuccess {
+			d.NewlyGreen = append(d.NewlyGreen, prKey)
+		}
+	}
+	for prKey := range old {
+		if _, ok := now.PrMap[prKey]; !ok && now.Unloaded == 0 && !failedToLoad(now, prKey) {
+			d.Removed = append(d.Removed, prKey)
+		}
+	}
+	for _, list := range [][]string{d.Added, d.Removed, d.Updated, d.NewlyGreen} {
+		sort.Strings(list)
+	}
+	return d
+}
+
+// failedToLoad reports whether the PR at URL prKey is one of res.Failures,
+// which are keyed "owner/repo#number".
+func failedToLoad(res *gh.FetchResult, prKey string) bool {
+	ref, err := gh.ParsePRRef(prKey)
+	if err != nil {
+		return false
+	}
+	_, ok := res.Failures[ref.String()]
+	return ok
+}
+
+// sameHashes reports whether a and b hold the same hashes in any order.
+func sameHashes(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// Write writes the diff to w in format.
+func (d *QueueDiff) Write(w io.Writer, format OutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Since %s: %d new, %d gone, %d updated, %d newly green\n", d.Since.Local().Format("2006-01-02 15:04"), len(d.Added), len(d.Removed), len(d.Updated), len(d.NewlyGreen))
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "  %s:\n", title)
+		for _, it := range items {
+			fmt.Fprintf(&b, "    %s\n", it)
+		}
+	}
+	writeList("New", d.Added)
+	writeList("Gone", d.Removed)
+	writeList("Updated", d.Updated)
+	writeList("Newly green", d.NewlyGreen)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// DiffSince fetches the review queue and writes to w in format how it
+// changed since a saved one. ref is either a file saved under historyDir or
+// by the queue snapshot, or a time as taken by --since (e.g. 24h or
+// 2006-01-02) to compare against the newest queue saved under historyDir by
+// then. The queue fetched is saved under historyDir first, unless it is
+// partial, so a run without anything to compare against still starts the
+// history.
+func DiffSince(ctx context.Context, w io.Writer, ref, historyDir string, format OutputFormat, fetch gh.FetchOptions, opts ...gh.Option) error {
+	path, at, err := parseDiffRef(ref)
+	if err != nil {
+		return err
+	}
+	g, err := gh.NewGhClient(append(opts[:len(opts):len(opts)], gh.WithFreshQueue())...)
+	if err != nil {
+		return err
+	}
+	reportAuthenticatedUser(ctx, g)
+	res, err := g.GetPrReviewRequested(ctx, fetch)
+	if err != nil {
+		return fmt.Errorf("error fetching PR review requests: %w", err)
+	}
+	printFetchSummary(res)
+	var prs []*github.PullRequest
+	for _, pr := range res.HashPrMap.PRsByURL() {
+		prs = append(prs, pr)
+	}
+	checks := g.CombinedStatuses(ctx, prs)
+	reportRateLimit(ctx, g)
+	// a partial queue would make later runs report the PRs it lacks as new
+	if res.Unloaded == 0 {
+		if _, err := g.SaveQueueHistory(ctx, historyDir, fetch, res, checks); err != nil {
+			// the diff is still worth showing; later runs just have less to compare with
+			slog.Warn("failed to save the review queue", "err", err)
+		}
+	}
+
+	var saved *gh.SavedQueue
+	if path != "" {
+		saved, err = gh.ReadSavedQueue(path)
+	} else if saved, err = g.LoadQueueHistory(ctx, historyDir, fetch, at); err != nil {
+		err = fmt.Errorf("%w; the queue was saved now to compare later runs with", err)
+	}
+	if err != nil {
+		return err
+	}
+	return diffQueues(saved, res, checks).Write(w, format)
+}
+
+// parseDiffRef splits a DiffSince ref into a saved queue file or a time.
+func parseDiffRef(ref string) (path string, at time.Time, err error) {
+	if _, err := os.Stat(ref); err == nil {
+		return ref, time.Time{}, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", time.Time{}, err
+	}
+	at, err = gh.ParseSince(ref)
+	if err != nil || at.IsZero() {
+		return "", time.Time{}, fmt.Errorf("%q is neither a saved review queue nor a time like 24h or 2006-01-02", ref)
+	}
+	return "", at, nil
+}
@@ -0,0 +1,102 @@
+package approve
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mallendem/gh-pr-review/pkg/gh"
+)
+
+func TestDiffQueues(t *testing.T) {
+	pr := func(n string) string { return "https://github.com/o/r/pull/" + n }
+	saved := &gh.SavedQueue{
+		Saved: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Result: &gh.FetchResult{PrMap: gh.PrHashMap{
+			pr("1"): {"a", "b"},
+			pr("2"): {"c"},
+			pr("3"): {"d"},
+		}},
+		Checks: map[string]gh.CheckState{pr("1"): gh.CheckPending, pr("2"): gh.CheckFailure},
+	}
+	now := &gh.FetchResult{PrMap: gh.PrHashMap{
+		pr("1"): {"b", "a"},
+		pr("2"): {"c", "e"},
+		pr("4"): {"f"},
+	}}
+	checks := map[string]gh.CheckState{pr("1"): gh.CheckSuccess, pr("2"): gh.CheckPending, pr("4"): gh.CheckSuccess}
+
+	got := diffQueues(saved, now, checks)
+	want := &QueueDiff{
+		Since:      saved.Saved,
+		Added:      []string{pr("4")},
+		Removed:    []string{pr("3")},
+		Updated:    []string{pr("2")},
+		NewlyGreen: []string{pr("1")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diff = %+v, want %+v", got, want)
+	}
+
+	var text bytes.Buffer
+	if err := got.Write(&text, OutputText); err != nil {
+		t.Fatalf("Write text: %v", err)
+	}
+	for _, s := range []string{
+		": 1 new, 1 gone, 1 updated, 1 newly green",
+		"  Gone:\n    " + pr("3"),
+		"  Newly green:\n    " + pr("1"),
+	} {
+		if !strings.Contains(text.String(), s) {
+			t.Errorf("text diff missing %q:\n%s", s, text.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := got.Write(&js, OutputJSON); err != nil {
+		t.Fatalf("Write json: %v", err)
+	}
+	var decoded QueueDiff
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatalf("decoding JSON diff: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, want) {
+		t.Errorf("JSON diff = %+v, want %+v", decoded, want)
+	}
+
+	// a PR that failed to load is not gone
+	saved.Result.PrMap[pr("5")] = []string{"g"}
+	now.Failures = map[string]error{"o/r#5": errors.New("boom")}
+	if got := diffQueues(saved, now, checks); !reflect.DeepEqual(got.Removed, []string{pr("3")}) {
+		t.Errorf("with a failed PR removed %v, want only %s", got.Removed, pr("3"))
+	}
+
+	// PRs missing from a partial queue may just not have loaded
+	now.Unloaded = 1
+	if got := diffQueues(saved, now, checks); len(got.Removed) != 0 {
+		t.Errorf("partial queue removed %v, want none", got.Removed)
+	}
+}
+
+func TestParseDiffRef(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "queue.json")
+	if err := os.WriteFile(f, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if path, at, err := parseDiffRef(f); err != nil || path != f || !at.IsZero() {
+		t.Errorf("parseDiffRef(file) = %q, %v, %v; want the file", path, at, err)
+	}
+	path, at, err := parseDiffRef("24h")
+	if err != nil || path != "" || time.Since(at) < 23*time.Hour {
+		t.Errorf("parseDiffRef(24h) = %q, %v, %v; want a day ago", path, at, err)
+	}
+	if _, _, err := parseDiffRef("yesterday-ish"); err == nil {
+		t.Error("parseDiffRef(yesterday-ish) succeeded, want error")
+	}
+}
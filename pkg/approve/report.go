@@ -0,0 +1,219 @@
+package approve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// OutputFormat selects how a ManualReport is written.
+type OutputFormat string
+
+const (
+	// OutputText writes the report for reading in a terminal.
+	OutputText OutputFormat = "text"
+	// OutputJSON writes the report as indented JSON, e.g. to diff runs.
+	OutputJSON OutputFormat = "json"
+)
+
+// ParseOutputFormat validates an --output value; empty selects OutputText.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch f := OutputFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return OutputText, nil
+	case OutputText, OutputJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid output %q: want text or json", s)
+	}
+}
+
+// ManualReport summarizes the outcome of a manual review run. In a dry run
+// it previews what a real run with the same answers would do.
+type ManualReport struct {
+	DryRun bool       `json:"dry_run"`
+	Hashes HashCounts `json:"hashes"`
+	// Approved lists the PRs approved, or that would be in a dry run.
+	Approved []string `json:"approved"`
+	// ChangesRequested lists the PRs that got, or would get, a
+	// REQUEST_CHANGES review.
+	ChangesRequested []string    `json:"changes_requested"`
+	Skipped          []SkippedPR `json:"skipped"`
+	Failed           []string    `json:"failed"`
+
+	// kept for the session summary (see WriteSummaryFile)
+	reviewer       string
+	hashDecisions  map[string][]string // by decision: approved, declined, skipped
+	failureReasons map[string]string   // by PR in Failed
+}
+
+// HashCounts counts the reviewed hashes by decision; Skipped are those left
+// undecided, e.g. because a PR they belong to was declined.
+type HashCounts struct {
+	Approved int `json:"approved"`
+	Declined int `json:"declined"`
+	Skipped  int `json:"skipped"`
+}
+
+// SkippedPR is a PR left unapproved and why.
+type SkippedPR struct {
+	PR     string `json:"pr"`
+	Reason string `json:"reason"`
+}
+
+func newManualReport(reviewer string, dryRun bool, hashes []string, approved, declined map[string]bool, sum approvalSummary) *ManualReport {
+	r := &ManualReport{
+		DryRun: dryRun,
+		// empty rather than null in JSON, so reports diff cleanly
+		Approved:         append([]string{}, sum.approved...),
+		ChangesRequested: append([]string{}, sum.declined...),
+		Skipped:          []SkippedPR{},
+		Failed:           append([]string{}, sum.failed...),
+		reviewer:         reviewer,
+		hashDecisions:    map[string][]string{},
+		failureReasons:   sum.errors,
+	}
+	for _, h := range hashes {
+		switch {
+		case approved[h]:
+			r.Hashes.Approved++
+			r.hashDecisions["approved"] = append(r.hashDecisions["approved"], h)
+		case declined[h]:
+			r.Hashes.Declined++
+			r.hashDecisions["declined"] = append(r.hashDecisions["declined"], h)
+		default:
+			r.Hashes.Skipped++
+			r.hashDecisions["skipped"] = append(r.hashDecisions["skipped"], h)
+		}
+	}
+	for _, prKey := range sum.skipped {
+		r.Skipped = append(r.Skipped, SkippedPR{PR: prKey, Reason: sum.reasons[prKey]})
+	}
+	return r
+}
+
+// Write writes the report to w in format.
+func (r *ManualReport) Write(w io.Writer, format OutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	verb := "approved"
+	if r.DryRun {
+		verb = "would be approved"
+	}
+	var b strings.Builder
+	b.WriteString("Summary")
+	if r.DryRun {
+		b.WriteString(" (dry run)")
+	}
+	b.WriteString(":\n")
+	fmt.Fprintf(&b, "  Hashes: %d approved, %d declined, %d skipped\n", r.Hashes.Approved, r.Hashes.Declined, r.Hashes.Skipped)
+	fmt.Fprintf(&b, "  PRs: %d %s, %d with changes requested, %d skipped, %d failed\n", len(r.Approved), verb, len(r.ChangesRequested), len(r.Skipped), len(r.Failed))
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "  %s:\n", title)
+		for _, it := range items {
+			fmt.Fprintf(&b, "    %s\n", it)
+		}
+	}
+	writeList(strings.ToUpper(verb[:1])+verb[1:], r.Approved)
+	writeList("Changes requested", r.ChangesRequested)
+	var skipped []string
+	for _, s := range r.Skipped {
+		skipped = append(skipped, fmt.Sprintf("%s (%s)", s.PR, s.Reason))
+	}
+	writeList("Skipped", skipped)
+	writeList("Failed", r.Failed)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// SummaryVersion is the SessionSummary schema version, raised only by
+// changes that break readers of older summaries.
+const SummaryVersion = 1
+
+// SessionSummary is the audit record of a manual review run, written by
+// WriteSummaryFile: who decided what on which hashes and PRs, and when.
+type SessionSummary struct {
+	Version int `json:"version"`
+	// Reviewer is the authenticated user, empty if it couldn't be looked up.
+	Reviewer string    `json:"reviewer"`
+	Finished time.Time `json:"finished"`
+	DryRun   bool      `json:"dry_run"`
+
+	ApprovedHashes []string `json:"approved_hashes"`
+	DeclinedHashes []string `json:"declined_hashes"`
+	// SkippedHashes were left undecided.
+	SkippedHashes []string `json:"skipped_hashes"`
+
+	// ApprovedPRs were approved, or would be in a dry run.
+	ApprovedPRs         []string    `json:"approved_prs"`
+	ChangesRequestedPRs []string    `json:"changes_requested_prs"`
+	SkippedPRs          []SkippedPR `json:"skipped_prs"`
+	// Errors lists the PRs whose review failed to submit.
+	Errors []PRError `json:"errors"`
+}
+
+// PRError is a PR whose review failed and the error it failed with.
+type PRError struct {
+	PR    string `json:"pr"`
+	Error string `json:"error"`
+}
+
+// Summary returns the audit record of the run r reports, finished at
+// finished. Lists are empty rather than null and sorted, so summaries are
+// stable.
+func (r *ManualReport) Summary(finished time.Time) SessionSummary {
+	sorted := func(items []string) []string {
+		items = append([]string{}, items...)
+		slices.Sort(items)
+		return items
+	}
+	s := SessionSummary{
+		Version:             SummaryVersion,
+		Reviewer:            r.reviewer,
+		Finished:            finished.UTC(),
+		DryRun:              r.DryRun,
+		ApprovedHashes:      sorted(r.hashDecisions["approved"]),
+		DeclinedHashes:      sorted(r.hashDecisions["declined"]),
+		SkippedHashes:       sorted(r.hashDecisions["skipped"]),
+		ApprovedPRs:         sorted(r.Approved),
+		ChangesRequestedPRs: sorted(r.ChangesRequested),
+		SkippedPRs:          append([]SkippedPR{}, r.Skipped...),
+		Errors:              []PRError{},
+	}
+	slices.SortFunc(s.SkippedPRs, func(a, b SkippedPR) int { return strings.Compare(a.PR, b.PR) })
+	for _, pr := range sorted(r.Failed) {
+		s.Errors = append(s.Errors, PRError{PR: pr, Error: r.failureReasons[pr]})
+	}
+	return s
+}
+
+// WriteSummaryFile writes r's Summary as indented JSON to path, through a
+// temp file renamed into place so a reader never sees a partial summary.
+func (r *ManualReport) WriteSummaryFile(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".summary-*")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	err = errors.Join(enc.Encode(r.Summary(time.Now())), f.Close())
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package approve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mallendem/gh-pr-review/pkg/gh"
+)
+
+func TestManualReport(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	approved := map[string]bool{"a": true, "b": true}
+	declined := map[string]bool{"c": true}
+	prSkipped := map[string]bool{"https://github.com/o/r/pull/2": true}
+	sum := processApprovals(context.Background(), prMap, approved, declined, prSkipped, hashPrMap, dryRunClient(t), gh.ApproveOptions{DryRun: true}, nil)
+
+	got := newManualReport("me", true, []string{"a", "b", "c", "d"}, approved, declined, sum)
+	want := &ManualReport{
+		DryRun:           true,
+		Hashes:           HashCounts{Approved: 2, Declined: 1, Skipped: 1},
+		Approved:         []string{"https://github.com/o/r/pull/1"},
+		ChangesRequested: []string{},
+		Skipped:          []SkippedPR{{PR: "https://github.com/o/r/pull/2", Reason: "a declined hash"}},
+		Failed:           []string{},
+		reviewer:         "me",
+		hashDecisions:    map[string][]string{"approved": {"a", "b"}, "declined": {"c"}, "skipped": {"d"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("report = %+v, want %+v", got, want)
+	}
+
+	var text bytes.Buffer
+	if err := got.Write(&text, OutputText); err != nil {
+		t.Fatalf("Write text: %v", err)
+	}
+	for _, s := range []string{
+		"Summary (dry run):",
+		"Hashes: 2 approved, 1 declined, 1 skipped",
+		"PRs: 1 would be approved, 0 with changes requested, 1 skipped, 0 failed",
+		"https://github.com/o/r/pull/2 (a declined hash)",
+	} {
+		if !strings.Contains(text.String(), s) {
+			t.Errorf("text report missing %q:\n%s", s, text.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := got.Write(&js, OutputJSON); err != nil {
+		t.Fatalf("Write json: %v", err)
+	}
+	var decoded ManualReport
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatalf("decoding JSON report: %v", err)
+	}
+	// only the summary keeps the reviewer and the hashes
+	exported := *want
+	exported.reviewer, exported.hashDecisions = "", nil
+	if !reflect.DeepEqual(decoded, exported) {
+		t.Errorf("JSON report = %+v, want %+v", decoded, exported)
+	}
+}
+
+func TestWriteSummaryFile(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	approved := map[string]bool{"a": true, "b": true, "c": true}
+	sum := processApprovals(context.Background(), prMap, approved, map[string]bool{}, map[string]bool{}, hashPrMap, dryRunClient(t), gh.ApproveOptions{DryRun: true}, nil)
+	sum.fail("https://github.com/o/r/pull/3", "approve", errors.New("boom"))
+	r := newManualReport("me", true, []string{"c", "a", "b", "d"}, approved, map[string]bool{}, sum)
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := r.WriteSummaryFile(path); err != nil {
+		t.Fatalf("WriteSummaryFile: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got SessionSummary
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decoding summary: %v\n%s", err, data)
+	}
+	if got.Finished.IsZero() {
+		t.Error("summary has no finish time")
+	}
+	got.Finished = time.Time{}
+	want := SessionSummary{
+		Version:             SummaryVersion,
+		Reviewer:            "me",
+		DryRun:              true,
+		ApprovedHashes:      []string{"a", "b", "c"},
+		DeclinedHashes:      []string{},
+		SkippedHashes:       []string{"d"},
+		ApprovedPRs:         []string{"https://github.com/o/r/pull/1", "https://github.com/o/r/pull/2"},
+		ChangesRequestedPRs: []string{},
+		SkippedPRs:          []SkippedPR{},
+		Errors:              []PRError{{PR: "https://github.com/o/r/pull/3", Error: "boom"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summary = %+v, want %+v", got, want)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("left %d files behind, want only the summary", len(entries))
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for in, want := range map[string]OutputFormat{"": OutputText, "text": OutputText, " JSON ": OutputJSON} {
+		if got, err := ParseOutputFormat(in); err != nil || got != want {
+			t.Errorf("ParseOutputFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseOutputFormat("yaml"); err == nil {
+		t.Error("ParseOutputFormat(yaml) succeeded, want error")
+	}
+}
@@ -0,0 +1,98 @@
+package approve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mallendem/gh-pr-review/pkg/gh"
+)
+
+// StagedPR is a PR that would be approved, as the GUI's staged list shows it.
+type StagedPR struct {
+	URL    string   `json:"url"`
+	Title  string   `json:"title"`
+	Author string   `json:"author"`
+	Hashes []string `json:"hashes"`
+}
+
+// stagedPRs returns the PRs of res that ShouldApprovePR would approve given
+// approved, ordered by URL. A nil approved stages only the PRs made of a
+// single hash, which are approvable at a glance; a PR whose hashes each
+// happen to be single-hash PRs elsewhere still needs a look.
+func stagedPRs(res *gh.FetchResult, approved map[string]bool) []StagedPR {
+	prs := res.HashPrMap.PRsByURL()
+	// empty rather than null in JSON, like ManualReport
+	staged := []StagedPR{}
+	for prKey, hashes := range res.PrMap {
+		if approved == nil && len(hashes) != 1 {
+			continue
+		}
+		if approved != nil && !ShouldApprovePR(prKey, hashes, approved, nil, nil) {
+			continue
+		}
+		s := StagedPR{URL: prKey, Hashes: append([]string(nil), hashes...)}
+		if pr := prs[prKey]; pr != nil {
+			s.Title, s.Author = pr.GetTitle(), pr.GetUser().GetLogin()
+		}
+		sort.Strings(s.Hashes)
+		staged = append(staged, s)
+	}
+	sort.Slice(staged, func(i, j int) bool { return staged[i].URL < staged[j].URL })
+	return staged
+}
+
+// writeStagedPRs writes staged to w in format.
+func writeStagedPRs(w io.Writer, staged []StagedPR, format OutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(staged)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d PR(s) would be approved\n", len(staged))
+	for _, s := range staged {
+		fmt.Fprintf(&b, "  %s  %s (%s)\n", s.URL, s.Title, s.Author)
+		fmt.Fprintf(&b, "    hashes: %s\n", strings.Join(s.Hashes, ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// PrintStagedPRs fetches the review queue of the comma-separated user, or of
+// every author if empty, and writes to w in format the PRs that would be
+// approved if the hashes listed in the file at hashesFile were, without
+// approving anything. With no hashesFile, the hashes of single-hash PRs are
+// taken as approved.
+func PrintStagedPRs(ctx context.Context, w io.Writer, user, hashesFile string, format OutputFormat, fetch gh.FetchOptions, opts ...gh.Option) error {
+	var approved map[string]bool
+	if hashesFile != "" {
+		hashes, err := readHashFile(hashesFile)
+		if err != nil {
+			return err
+		}
+		approved = map[string]bool{}
+		for _, h := range hashes {
+			approved[h] = true
+		}
+	}
+	g, err := gh.NewGhClient(opts...)
+	if err != nil {
+		return err
+	}
+	reportAuthenticatedUser(ctx, g)
+	var users []string
+	if user != "" {
+		users = splitUsers(user)
+	}
+	res, err := g.GetPrReviewRequestedForUser(ctx, users, fetch)
+	if err != nil {
+		return fmt.Errorf("error fetching PR review requests: %w", err)
+	}
+	printFetchSummary(res)
+	reportRateLimit(ctx, g)
+	return writeStagedPRs(w, stagedPRs(res, approved), format)
+}
@@ -0,0 +1,68 @@
+package approve
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/mallendem/gh-pr-review/pkg/gh"
+)
+
+func TestStagedPRs(t *testing.T) {
+	hashPrMap, prMap := testQueue()
+	pr3 := &github.PullRequest{
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/3"),
+		Title:   github.Ptr("Bump lib"),
+		User:    &github.User{Login: github.Ptr("alice")},
+	}
+	hashPrMap["d"] = []*github.PullRequest{pr3}
+	prMap[pr3.GetHTMLURL()] = []string{"d"}
+	res := &gh.FetchResult{HashPrMap: hashPrMap, PrMap: prMap}
+
+	urls := func(staged []StagedPR) string {
+		var out []string
+		for _, s := range staged {
+			out = append(out, s.URL)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := urls(stagedPRs(res, map[string]bool{"a": true, "b": true})); got != "https://github.com/o/r/pull/1" {
+		t.Errorf("staged with a and b = %s, want PR 1", got)
+	}
+	// without a hash set only the single-hash PR is staged
+	staged := stagedPRs(res, nil)
+	if got := urls(staged); got != "https://github.com/o/r/pull/3" {
+		t.Errorf("staged without hashes = %s, want PR 3", got)
+	}
+
+	// PR 4 is made of the hashes of single-hash PRs 5 and 6 combined, which
+	// doesn't make it approvable at a glance
+	pr4, pr5, pr6 := testPR(4), testPR(5), testPR(6)
+	multi := &gh.FetchResult{
+		HashPrMap: gh.HashPrMap{"e": {pr4, pr5}, "f": {pr4, pr6}},
+		PrMap: map[string][]string{
+			pr4.GetHTMLURL(): {"e", "f"},
+			pr5.GetHTMLURL(): {"e"},
+			pr6.GetHTMLURL(): {"f"},
+		},
+	}
+	if got := urls(stagedPRs(multi, nil)); got != "https://github.com/o/r/pull/5,https://github.com/o/r/pull/6" {
+		t.Errorf("staged without hashes = %s, want only PRs 5 and 6", got)
+	}
+
+	var b strings.Builder
+	if err := writeStagedPRs(&b, staged, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	want := "1 PR(s) would be approved\n  https://github.com/o/r/pull/3  Bump lib (alice)\n    hashes: d\n"
+	if b.String() != want {
+		t.Errorf("text output:\n%s\nwant:\n%s", b.String(), want)
+	}
+	b.Reset()
+	if err := writeStagedPRs(&b, stagedPRs(res, map[string]bool{}), OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(b.String()); got != "[]" {
+		t.Errorf("JSON output of nothing staged = %s, want []", got)
+	}
+}
@@ -0,0 +1,80 @@
+package approve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mallendem/gh-pr-review/pkg/gh"
+)
+
+// PrintHashPRs prints to w every hash in the review queue starting with
+// hash, e.g. the 6-character short shown by the GUI, with its changes and
+// the PRs (URL, title and author) containing it. It returns an error if no
+// hash matches.
+func PrintHashPRs(ctx context.Context, w io.Writer, hash string, fetch gh.FetchOptions, opts ...gh.Option) error {
+	prefix := strings.ToLower(strings.TrimSpace(hash))
+	if prefix == "" {
+		return errors.New("no hash given")
+	}
+	g, err := gh.NewGhClient(opts...)
+	if err != nil {
+		return err
+	}
+	reportAuthenticatedUser(ctx, g)
+	res, err := g.GetPrReviewRequested(ctx, fetch)
+	if err != nil {
+		return fmt.Errorf("error fetching PR review requests: %w", err)
+	}
+	printFetchSummary(res)
+	reportRateLimit(ctx, g)
+	matches := matchHashes(prefix, res.HashPrMap)
+	if len(matches) == 0 {
+		return fmt.Errorf("no match: no PR in the review queue has a hash starting with %q", hash)
+	}
+	printHashPRs(w, matches, res)
+	return nil
+}
+
+// matchHashes returns the hashes of hashPrMap starting with prefix in order,
+// or just prefix if it is a whole hash itself.
+func matchHashes(prefix string, hashPrMap gh.HashPrMap) []string {
+	if _, ok := hashPrMap[prefix]; ok {
+		return []string{prefix}
+	}
+	var matches []string
+	for _, h := range slices.Sorted(maps.Keys(hashPrMap)) {
+		if strings.HasPrefix(h, prefix) {
+			matches = append(matches, h)
+		}
+	}
+	return matches
+}
+
+// printHashPRs prints to w each of hashes with its changes and the PRs in
+// res containing it.
+func printHashPRs(w io.Writer, hashes []string, res *gh.FetchResult) {
+	if len(hashes) > 1 {
+		fmt.Fprintln(w, colorize(cYellow, fmt.Sprintf("%d hashes match; give more of the hash to pick one", len(hashes))))
+	}
+	for _, h := range hashes {
+		fmt.Fprintln(w, colorize(cYellow, "Hash: "+h))
+		if hunk, ok := res.ChangeMap[h]; ok {
+			fmt.Fprintln(w, colorize(cCyan, "  "+hunk.Header()))
+			for _, line := range hunk.Lines {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		} else {
+			fmt.Fprintln(w, "  No changes found for this hash.")
+		}
+		prs := res.HashPrMap[h]
+		fmt.Fprintf(w, "  PRs (%d):\n", len(prs))
+		for _, pr := range prs {
+			fmt.Fprintf(w, "    %s  %s (%s)\n", pr.GetHTMLURL(), pr.GetTitle(), pr.GetUser().GetLogin())
+		}
+	}
+}
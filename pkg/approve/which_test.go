@@ -0,0 +1,44 @@
+package approve
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+	"github.com/mallendem/gh-pr-review/pkg/gh"
+)
+
+func TestMatchHashes(t *testing.T) {
+	hashPrMap := gh.HashPrMap{"abc123": nil, "abc999": nil, "abc": nil, "def456": nil}
+	for prefix, want := range map[string][]string{
+		"abc1": {"abc123"},
+		"abc9": {"abc999"},
+		"abc":  {"abc"},
+		"ab":   {"abc", "abc123", "abc999"},
+		"fff":  nil,
+	} {
+		if got := matchHashes(prefix, hashPrMap); !slices.Equal(got, want) {
+			t.Errorf("matchHashes(%q) = %v, want %v", prefix, got, want)
+		}
+	}
+}
+
+func TestPrintHashPRs(t *testing.T) {
+	pr := &github.PullRequest{
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Title:   github.Ptr("Bump lib"),
+		User:    &github.User{Login: github.Ptr("alice")},
+	}
+	res := &gh.FetchResult{
+		HashPrMap: gh.HashPrMap{"abc123": {pr}},
+		ChangeMap: gh.HashChangeMap{"abc123": {File: "go.mod", Lines: []string{"+lib v2"}}},
+	}
+	var b strings.Builder
+	printHashPRs(&b, []string{"abc123"}, res)
+	for _, s := range []string{"Hash: abc123", "    +lib v2\n", "  PRs (1):\n    https://github.com/o/r/pull/1  Bump lib (alice)\n"} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("output missing %q:\n%s", s, b.String())
+		}
+	}
+}
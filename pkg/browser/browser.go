@@ -0,0 +1,54 @@
+// Package browser opens URLs in the user's default web browser.
+package browser
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// ErrNoBrowser is returned by Open when there is no way to launch a browser,
+// e.g. in an SSH session or on a headless machine. Callers should show the
+// URL instead.
+var ErrNoBrowser = errors.New("no browser available")
+
+// Open launches the default browser on url without waiting for it to exit.
+// $BROWSER, when set, is used in place of the platform's launcher.
+func Open(url string) error {
+	cmd, err := command(url)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// reap the launcher; it exits as soon as it has handed url over
+	go cmd.Wait()
+	return nil
+}
+
+// command returns the launcher for url on this platform.
+func command(url string) (*exec.Cmd, error) {
+	if b := os.Getenv("BROWSER"); b != "" {
+		return exec.Command(b, url), nil
+	}
+	// a launcher would open the browser on the remote machine, if at all
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		return nil, ErrNoBrowser
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url), nil
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url), nil
+	}
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return nil, ErrNoBrowser
+	}
+	path, err := exec.LookPath("xdg-open")
+	if err != nil {
+		return nil, ErrNoBrowser
+	}
+	return exec.Command(path, url), nil
+}
@@ -0,0 +1,26 @@
+package browser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOpenOverSSH(t *testing.T) {
+	t.Setenv("BROWSER", "")
+	t.Setenv("SSH_TTY", "/dev/pts/0")
+	if err := Open("https://github.com/o/r/pull/1"); !errors.Is(err, ErrNoBrowser) {
+		t.Errorf("Open over SSH: err = %v, want ErrNoBrowser", err)
+	}
+}
+
+func TestOpenUsesBrowserEnv(t *testing.T) {
+	t.Setenv("BROWSER", "my-browser")
+	t.Setenv("SSH_TTY", "/dev/pts/0")
+	cmd, err := command("https://github.com/o/r/pull/1")
+	if err != nil {
+		t.Fatalf("command: %v", err)
+	}
+	if len(cmd.Args) != 2 || cmd.Args[0] != "my-browser" || cmd.Args[1] != "https://github.com/o/r/pull/1" {
+		t.Errorf("args = %v, want [my-browser <url>]", cmd.Args)
+	}
+}
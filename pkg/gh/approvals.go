@@ -0,0 +1,158 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/go-github/v72/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// ApprovalState is how close a PR is to the approvals its base branch
+// requires.
+type ApprovalState struct {
+	Approvals int // distinct reviewers whose latest review approves
+	// Required is the approving review count the base branch protection
+	// requires; 0 when there is no such rule or it can't be read.
+	Required  int
+	Mergeable bool // no merge conflicts with the base branch
+}
+
+// Missing returns how many more approvals the PR needs, or 0 if it has
+// enough or no requirement is known.
+func (s ApprovalState) Missing() int {
+	return max(s.Required-s.Approvals, 0)
+}
+
+// String describes the state, e.g. "approvals 1/2" or "approvals 1" when no
+// requirement is known, with ", conflicts" if the PR can't be merged.
+func (s ApprovalState) String() string {
+	desc := fmt.Sprintf("approvals %d", s.Approvals)
+	if s.Required > 0 {
+		desc += fmt.Sprintf("/%d", s.Required)
+	}
+	if !s.Mergeable {
+		desc += ", conflicts"
+	}
+	return desc
+}
+
+const approvalStateQuery = `query ApprovalState($owner:String!, $repo:String!, $number:Int!) {
+  repository(owner:$owner, name:$repo) {
+    pullRequest(number:$number) {
+      mergeable
+      latestOpinionatedReviews(first:100) { nodes { state } }
+      baseRef { branchProtectionRule { requiredApprovingReviewCount } }
+    }
+  }
+}`
+
+// ApprovalState looks up how many approvals pr has against how many its base
+// branch requires, and whether it is free of merge conflicts. A merge state
+// GitHub hasn't computed yet counts as mergeable.
+func (g *GhClient) ApprovalState(ctx context.Context, pr *github.PullRequest) (ApprovalState, error) {
+	owner, repo, err := prRepo(pr)
+	if err != nil {
+		return ApprovalState{}, err
+	}
+	var data struct {
+		Repository struct {
+			PullRequest *struct {
+				Mergeable                string `json:"mergeable"`
+				LatestOpinionatedReviews struct {
+					Nodes []struct {
+						State string `json:"state"`
+					} `json:"nodes"`
+				} `json:"latestOpinionatedReviews"`
+				BaseRef *struct {
+					BranchProtectionRule *struct {
+						RequiredApprovingReviewCount int `json:"requiredApprovingReviewCount"`
+					} `json:"branchProtectionRule"`
+				} `json:"baseRef"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	vars := map[string]any{"owner": owner, "repo": repo, "number": pr.GetNumber()}
+	if err := g.graphQL(ctx, approvalStateQuery, vars, &data); err != nil {
+		return ApprovalState{}, fmt.Errorf("failed to get approval state of PR %s: %w", pr.GetHTMLURL(), err)
+	}
+	p := data.Repository.PullRequest
+	if p == nil {
+		return ApprovalState{}, fmt.Errorf("PR %s not found", pr.GetHTMLURL())
+	}
+	state := ApprovalState{Mergeable: p.Mergeable != "CONFLICTING"}
+	for _, r := range p.LatestOpinionatedReviews.Nodes {
+		if r.State == "APPROVED" {
+			state.Approvals++
+		}
+	}
+	if p.BaseRef != nil && p.BaseRef.BranchProtectionRule != nil {
+		state.Required = p.BaseRef.BranchProtectionRule.RequiredApprovingReviewCount
+	}
+	return state, nil
+}
+
+// ApprovalStates looks up ApprovalState for every PR in parallel, keyed by
+// HTML URL. PRs whose state can't be read are left out.
+func (g *GhClient) ApprovalStates(ctx context.Context, prs []*github.PullRequest) map[string]ApprovalState {
+	states := map[string]ApprovalState{}
+	var eg errgroup.Group
+	eg.SetLimit(g.concurrency)
+	for _, pr := range prs {
+		eg.Go(func() error {
+			state, err := g.ApprovalState(ctx, pr)
+			if err != nil {
+				slog.Debug("skipping approval state", "pr", pr.GetHTMLURL(), "err", err)
+				return nil
+			}
+			g.mu.Lock()
+			states[pr.GetHTMLURL()] = state
+			g.mu.Unlock()
+			return nil
+		})
+	}
+	eg.Wait()
+	return states
+}
+
+// graphQL runs query with vars and decodes its data into out.
+func (g *GhClient) graphQL(ctx context.Context, query string, vars map[string]any, out any) error {
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", g.graphqlURL(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	resp, err := g.doWithRetry(req)
+	if err != nil {
+		return fmt.Errorf("GraphQL request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("GraphQL returned status %d: %s", resp.StatusCode, string(body))
+	}
+	var gqlResp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &gqlResp); err != nil {
+		return fmt.Errorf("failed to decode GraphQL response: %w", err)
+	}
+	if len(gqlResp.Errors) > 0 {
+		return fmt.Errorf("GraphQL returned errors: %s", gqlResp.Errors[0].Message)
+	}
+	return json.Unmarshal(gqlResp.Data, out)
+}
@@ -0,0 +1,57 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestApprovalState(t *testing.T) {
+	responses := map[int]string{
+		1: `{"data":{"repository":{"pullRequest":{"mergeable":"MERGEABLE","latestOpinionatedReviews":{"nodes":[{"state":"APPROVED"},{"state":"CHANGES_REQUESTED"}]},"baseRef":{"branchProtectionRule":{"requiredApprovingReviewCount":2}}}}}}`,
+		2: `{"data":{"repository":{"pullRequest":{"mergeable":"CONFLICTING","latestOpinionatedReviews":{"nodes":[]},"baseRef":{"branchProtectionRule":null}}}}}`,
+		3: `{"data":{"repository":{"pullRequest":null}},"errors":[{"message":"Could not resolve to a PullRequest"}]}`,
+	}
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables struct {
+				Number int `json:"number"`
+			} `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprint(w, responses[req.Variables.Number])
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	newPR := func(n int) *github.PullRequest {
+		return &github.PullRequest{
+			Number:  github.Ptr(n),
+			HTMLURL: github.Ptr(fmt.Sprintf("https://github.com/o/r/pull/%d", n)),
+			Base:    &github.PullRequestBranch{Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}}},
+		}
+	}
+	states := g.ApprovalStates(context.Background(), []*github.PullRequest{newPR(1), newPR(2), newPR(3)})
+	want := map[string]ApprovalState{
+		"https://github.com/o/r/pull/1": {Approvals: 1, Required: 2, Mergeable: true},
+		"https://github.com/o/r/pull/2": {},
+	}
+	if len(states) != len(want) {
+		t.Fatalf("ApprovalStates = %+v, want %+v", states, want)
+	}
+	for pr, w := range want {
+		if states[pr] != w {
+			t.Errorf("state of %s = %+v, want %+v", pr, states[pr], w)
+		}
+	}
+	if got := states["https://github.com/o/r/pull/1"]; got.Missing() != 1 || got.String() != "approvals 1/2" {
+		t.Errorf("Missing() = %d, String() = %q", got.Missing(), got.String())
+	}
+	if got := states["https://github.com/o/r/pull/2"].String(); got != "approvals 0, conflicts" {
+		t.Errorf("String() = %q", got)
+	}
+}
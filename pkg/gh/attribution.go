@@ -0,0 +1,160 @@
+package gh
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// maxAttributedCommits bounds how many commits of a PR are fetched one by one
+// to find which introduced each hunk; longer PRs are left unattributed rather
+// than spending the rate limit on them.
+const maxAttributedCommits = 20
+
+// ShortSHA abbreviates a commit SHA to the usual 7 characters.
+func ShortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// listPrCommits lists the commits of a PR, oldest first.
+func (g *GhClient) listPrCommits(ctx context.Context, owner, repo string, number int) ([]*github.RepositoryCommit, error) {
+	var all []*github.RepositoryCommit
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		commits, resp, err := g.c.PullRequests.ListCommits(ctx, owner, repo, number, opt)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, commits...)
+		if resp.NextPage == 0 || resp.NextPage <= opt.Page {
+			return all, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// allCommitsVerified reports whether every commit is verified (signed).
+func allCommitsVerified(commits []*github.RepositoryCommit) bool {
+	for _, c := range commits {
+		if !c.GetCommit().GetVerification().GetVerified() {
+			return false
+		}
+	}
+	return len(commits) > 0
+}
+
+// attributeHunks maps each hunk of a PR to the SHA of the commit that
+// introduced it: the commit whose patch for the hunk's file contains most of
+// the hunk's changed lines, preferring newer commits on a tie. Binary changes
+// and renames, which have no such lines, go to the newest commit touching the
+// file. Hunks that can't be attributed are left out. complete is false if a
+// commit couldn't be fetched, so the result is not worth caching.
+func (g *GhClient) attributeHunks(ctx context.Context, owner, repo string, commits []*github.RepositoryCommit, hunks map[string]Hunk, raw map[string][]string) (attributed map[string]string, complete bool) {
+	attributed = make(map[string]string)
+	if len(commits) == 1 {
+		if sha := commits[0].GetSHA(); sha != "" {
+			for h := range hunks {
+				attributed[h] = sha
+			}
+		}
+		return attributed, true
+	}
+	if len(commits) == 0 || len(hunks) == 0 {
+		return attributed, true
+	}
+	if len(commits) > maxAttributedCommits {
+		slog.Debug("too many commits to attribute hunks", "repo", owner+"/"+repo, "commits", len(commits))
+		return attributed, true
+	}
+
+	complete = true
+	best := make(map[string]int)
+	for i := len(commits) - 1; i >= 0; i-- {
+		sha := commits[i].GetSHA()
+		if sha == "" {
+			continue
+		}
+		commit, _, err := g.c.Repositories.GetCommit(ctx, owner, repo, sha, nil)
+		if err != nil {
+			slog.Debug("failed to fetch commit for attribution", "repo", owner+"/"+repo, "sha", sha, "err", err)
+			complete = false
+			continue
+		}
+		patches := make(map[string][]string)
+		for _, f := range commit.Files {
+			patches[f.GetFilename()] = strings.Split(f.GetPatch(), "\n")
+		}
+		for h, hunk := range hunks {
+			patch, ok := patches[hunk.File]
+			if !ok {
+				continue
+			}
+			score := matchingLines(changedLines(raw[h]), patch)
+			if _, seen := attributed[h]; !seen || score > best[h] {
+				attributed[h], best[h] = sha, score
+			}
+		}
+	}
+	return attributed, complete
+}
+
+// prAttribution is attributeHunks for pr, cached by PR URL and head SHA: in
+// memory for --watch refreshes, and next to the cached diff for later runs.
+// A PR's commits and hunks are fixed by its head, so a hit is always current.
+func (g *GhClient) prAttribution(ctx context.Context, owner, repo string, pr *github.PullRequest, commits []*github.RepositoryCommit, hunks map[string]Hunk, raw map[string][]string) map[string]string {
+	prURL, headSHA := pr.GetHTMLURL(), pr.GetHead().GetSHA()
+	key := prURL + "@" + headSHA
+	g.mu.Lock()
+	attributed, ok := g.attributions[key]
+	g.mu.Unlock()
+	if ok {
+		return attributed
+	}
+	if attributed, ok = g.loadCachedAttribution(prURL, headSHA); !ok {
+		var complete bool
+		attributed, complete = g.attributeHunks(ctx, owner, repo, commits, hunks, raw)
+		if !complete || headSHA == "" {
+			return attributed
+		}
+		g.storeCachedAttribution(prURL, headSHA, attributed)
+	}
+
+	g.mu.Lock()
+	if g.attributions == nil {
+		g.attributions = map[string]map[string]string{}
+	}
+	g.attributions[key] = attributed
+	g.mu.Unlock()
+	return attributed
+}
+
+// changedLines returns the +/- lines of a raw hunk.
+func changedLines(raw []string) []string {
+	var out []string
+	for _, l := range raw {
+		if strings.HasPrefix(l, "+") || strings.HasPrefix(l, "-") {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// matchingLines counts the lines that also appear in patch.
+func matchingLines(lines, patch []string) int {
+	inPatch := make(map[string]bool, len(patch))
+	for _, l := range patch {
+		inPatch[l] = true
+	}
+	n := 0
+	for _, l := range lines {
+		if inPatch[l] {
+			n++
+		}
+	}
+	return n
+}
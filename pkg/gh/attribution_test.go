@@ -0,0 +1,124 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestAttributeHunks(t *testing.T) {
+	patches := map[string]map[string]string{
+		"c1": {"a.go": "@@ -1 +1 @@\n-old\n+new", "logo.png": ""},
+		"c2": {"a.go": "@@ -10 +10 @@\n-x\n+y"},
+		"c3": {"b.go": "@@ -1 +1 @@\n+other"},
+	}
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		sha := strings.TrimPrefix(r.URL.Path, "/repos/o/r/commits/")
+		files, ok := patches[sha]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		commit := github.RepositoryCommit{SHA: github.Ptr(sha)}
+		for name, patch := range files {
+			commit.Files = append(commit.Files, &github.CommitFile{Filename: github.Ptr(name), Patch: github.Ptr(patch)})
+		}
+		_ = json.NewEncoder(w).Encode(commit)
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	commits := []*github.RepositoryCommit{{SHA: github.Ptr("c1")}, {SHA: github.Ptr("c2")}, {SHA: github.Ptr("missing")}, {SHA: github.Ptr("c3")}}
+	hunks := map[string]Hunk{
+		"first":   {File: "a.go"},
+		"second":  {File: "a.go"},
+		"binary":  {File: "logo.png", Binary: true},
+		"unknown": {File: "c.go"},
+	}
+	raw := map[string][]string{
+		"first":  {" ctx", "-old", "+new"},
+		"second": {"-x", "+y"},
+	}
+	got, complete := g.attributeHunks(context.Background(), "o", "r", commits, hunks, raw)
+	if complete {
+		t.Error("attributeHunks reported complete despite a missing commit")
+	}
+	want := map[string]string{"first": "c1", "second": "c2", "binary": "c1"}
+	if len(got) != len(want) {
+		t.Fatalf("attributeHunks = %v, want %v", got, want)
+	}
+	for h, sha := range want {
+		if got[h] != sha {
+			t.Errorf("hunk %s attributed to %q, want %q", h, got[h], sha)
+		}
+	}
+}
+
+func TestAttributeHunksSingleCommit(t *testing.T) {
+	hunks := map[string]Hunk{"h": {File: "a.go"}}
+	// a single commit introduced everything, so no API call is needed
+	g := &GhClient{}
+	got, _ := g.attributeHunks(context.Background(), "o", "r", []*github.RepositoryCommit{{SHA: github.Ptr("abc")}}, hunks, nil)
+	if got["h"] != "abc" {
+		t.Errorf("attributeHunks = %v, want h attributed to abc", got)
+	}
+	if got, _ := g.attributeHunks(context.Background(), "o", "r", []*github.RepositoryCommit{{}}, hunks, nil); len(got) != 0 {
+		t.Errorf("attributeHunks without a SHA = %v, want none", got)
+	}
+}
+
+func TestShortSHA(t *testing.T) {
+	if got := ShortSHA("0123456789abcdef"); got != "0123456" {
+		t.Errorf("ShortSHA = %q", got)
+	}
+	if got := ShortSHA("abc"); got != "abc" {
+		t.Errorf("ShortSHA(short) = %q", got)
+	}
+}
+
+func TestPrAttributionIsCached(t *testing.T) {
+	var calls int
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		sha := strings.TrimPrefix(r.URL.Path, "/repos/o/r/commits/")
+		commit := github.RepositoryCommit{SHA: github.Ptr(sha), Files: []*github.CommitFile{{Filename: github.Ptr("a.go"), Patch: github.Ptr("+" + sha)}}}
+		_ = json.NewEncoder(w).Encode(commit)
+	})
+	defer srv.Close()
+	dir := t.TempDir()
+	pr := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/1"), Head: &github.PullRequestBranch{SHA: github.Ptr("head")}}
+	commits := []*github.RepositoryCommit{{SHA: github.Ptr("c1")}, {SHA: github.Ptr("c2")}}
+	hunks := map[string]Hunk{"h": {File: "a.go"}}
+	raw := map[string][]string{"h": {"+c1"}}
+
+	g := newTestClient(t, srv)
+	WithDiffCache(dir)(g)
+	if got := g.prAttribution(context.Background(), "o", "r", pr, commits, hunks, raw); got["h"] != "c1" {
+		t.Fatalf("prAttribution = %v, want h attributed to c1", got)
+	}
+	if calls != 2 {
+		t.Fatalf("first attribution made %d commit requests, want 2", calls)
+	}
+
+	// a refresh reuses the attribution in memory, a new run the one on disk
+	g.prAttribution(context.Background(), "o", "r", pr, commits, hunks, raw)
+	fresh := newTestClient(t, srv)
+	WithDiffCache(dir)(fresh)
+	if got := fresh.prAttribution(context.Background(), "o", "r", pr, commits, hunks, raw); got["h"] != "c1" {
+		t.Errorf("cached prAttribution = %v, want h attributed to c1", got)
+	}
+	if calls != 2 {
+		t.Errorf("cached attribution made %d more commit requests", calls-2)
+	}
+
+	// a new head is a miss
+	pr.Head.SHA = github.Ptr("newhead")
+	g.prAttribution(context.Background(), "o", "r", pr, commits, hunks, raw)
+	if calls != 4 {
+		t.Errorf("attribution for a new head made %d commit requests, want 2", calls-2)
+	}
+}
@@ -3,6 +3,7 @@ package gh
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,38 +11,54 @@ import (
 	"github.com/google/go-github/v72/github"
 )
 
-// tryUpdateBranch tries to rebase the branch for the given PR.
-func (g *GhClient) tryUpdateBranch(owner, repo string, number int) error {
+// tryUpdateBranch tries to rebase the branch for the given PR. Given the
+// head SHA the PR was reviewed at, GitHub only updates the branch if its head
+// is still there, and the head SHA after the update is returned; otherwise
+// it returns "".
+func (g *GhClient) tryUpdateBranch(ctx context.Context, owner, repo string, number int, headSHA string) (string, error) {
+	opts := &github.PullRequestBranchUpdateOptions{}
+	if headSHA != "" {
+		opts.ExpectedHeadSHA = &headSHA
+	}
 	_, _, err := g.c.PullRequests.UpdateBranch(
-		context.Background(),
+		ctx,
 		owner,
 		repo,
 		number,
-		&github.PullRequestBranchUpdateOptions{})
+		opts)
+	// GitHub updates the branch in the background and answers 202 Accepted,
+	// which go-github reports as an *AcceptedError
+	if err != nil && !errors.As(err, new(*github.AcceptedError)) {
+		return "", fmt.Errorf("failed to update branch for PR #%d in %s/%s: %w", number, owner, repo, err)
+	}
+	if headSHA == "" {
+		return "", nil
+	}
+	pr, err := g.GetPR(ctx, owner, repo, number)
 	if err != nil {
-		return fmt.Errorf("failed to update branch for PR #%d in %s/%s: %w", number, owner, repo, err)
+		return "", err
 	}
-	return nil
+	return pr.GetHead().GetSHA(), nil
 }
 
 // isBranchBehind checks whether headRef is behind baseRef using the GitHub compare API.
 // It returns true if the head is behind the base (i.e., the branch is out-of-date and
 // should be updated/rebased).
-func (g *GhClient) isBranchBehind(owner, repo, baseRef, headRef string) (bool, error) {
+func (g *GhClient) isBranchBehind(ctx context.Context, owner, repo, baseRef, headRef string) (bool, error) {
 	if owner == "" || repo == "" || baseRef == "" || headRef == "" {
 		return false, fmt.Errorf("invalid parameters to isBranchBehind")
 	}
 
 	// Use the REST compare endpoint to avoid dependency on go-github method signatures.
-	compareURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/compare/%s...%s", owner, repo, baseRef, headRef)
-	req, err := http.NewRequest("GET", compareURL, nil)
+	compareURL := g.restURL("repos/%s/%s/compare/%s...%s", owner, repo, baseRef, headRef)
+	req, err := http.NewRequestWithContext(ctx, "GET", compareURL, nil)
 	if err != nil {
 		return false, fmt.Errorf("failed to build compare request: %w", err)
 	}
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("Authorization", "Bearer "+g.token)
 
-	resp, err := g.c.Client().Do(req)
+	resp, err := g.doWithRetry(req)
 	if err != nil {
 		return false, fmt.Errorf("compare request failed for %s/%s %s...%s: %w", owner, repo, baseRef, headRef, err)
 	}
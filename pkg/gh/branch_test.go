@@ -0,0 +1,44 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestIsBranchBehind(t *testing.T) {
+	status := ""
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/o/r/compare/main...feature" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"status":%q}`, status)
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	for _, tt := range []struct {
+		status string
+		want   bool
+	}{
+		{"behind", true},
+		{"diverged", false},
+		{"ahead", false},
+		{"identical", false},
+	} {
+		status = tt.status
+		got, err := g.isBranchBehind(context.Background(), "o", "r", "main", "feature")
+		if err != nil {
+			t.Fatalf("isBranchBehind(%s): %v", tt.status, err)
+		}
+		if got != tt.want {
+			t.Errorf("isBranchBehind(%s) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+
+	if _, err := g.isBranchBehind(context.Background(), "o", "r", "main", "missing"); err == nil {
+		t.Error("isBranchBehind on a 404 succeeded, want error")
+	}
+}
@@ -0,0 +1,218 @@
+package gh
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DiffCacheMaxAge is how long a cached diff may go unused before it is evicted.
+const DiffCacheMaxAge = 7 * 24 * time.Hour
+
+// DefaultDiffCacheDir returns the per-user directory diffs are cached in.
+func DefaultDiffCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-pr-review", "diffs"), nil
+}
+
+// WithDiffCache caches raw PR diffs under dir, keyed by PR URL and head SHA,
+// so re-running against an unchanged queue doesn't re-download every diff.
+// An empty dir disables the cache, which is the default.
+func WithDiffCache(dir string) Option {
+	return func(g *GhClient) {
+		g.cacheDir = dir
+	}
+}
+
+// diffCachePath returns the cache file for a PR at a given head commit. Both
+// parts go into the key so a force-push or new commit is always a miss.
+func (g *GhClient) diffCachePath(prURL, headSHA string) string {
+	sum := sha256.Sum256([]byte(prURL + "\n" + headSHA))
+	return filepath.Join(g.cacheDir, hex.EncodeToString(sum[:])+".diff")
+}
+
+// attributionCachePath returns the file the hunk attribution of a PR at a
+// given head commit is cached in, next to its diff.
+func (g *GhClient) attributionCachePath(prURL, headSHA string) string {
+	return strings.TrimSuffix(g.diffCachePath(prURL, headSHA), ".diff") + ".attr"
+}
+
+// loadCachedAttribution reads a cached hunk attribution, or returns ok=false
+// on a miss. Like openCachedDiff, a hit refreshes the file's mtime.
+func (g *GhClient) loadCachedAttribution(prURL, headSHA string) (map[string]string, bool) {
+	if g.cacheDir == "" || headSHA == "" {
+		return nil, false
+	}
+	p := g.attributionCachePath(prURL, headSHA)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+	var attributed map[string]string
+	if err := json.Unmarshal(data, &attributed); err != nil || attributed == nil {
+		slog.Debug("ignoring corrupt attribution cache", "path", p, "err", err)
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+	return attributed, true
+}
+
+// storeCachedAttribution caches a hunk attribution for later runs. Failures
+// only cost a refetch, so they are logged rather than returned.
+func (g *GhClient) storeCachedAttribution(prURL, headSHA string, attributed map[string]string) {
+	if g.cacheDir == "" || headSHA == "" {
+		return
+	}
+	data, err := json.Marshal(attributed)
+	if err == nil {
+		err = os.MkdirAll(g.cacheDir, 0o700)
+	}
+	var f *os.File
+	if err == nil {
+		f, err = os.CreateTemp(g.cacheDir, "tmp-*")
+	}
+	if err == nil {
+		_, err = f.Write(data)
+		err = errors.Join(err, f.Close())
+		if err == nil {
+			err = os.Rename(f.Name(), g.attributionCachePath(prURL, headSHA))
+		}
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}
+	if err != nil {
+		slog.Debug("failed to write attribution cache", "err", err)
+	}
+}
+
+// openCachedDiff opens the cached diff, or returns ok=false on a miss. A hit
+// refreshes the file's mtime so diffs still in the queue are not evicted.
+func (g *GhClient) openCachedDiff(prURL, headSHA string) (*os.File, bool) {
+	if g.cacheDir == "" || headSHA == "" {
+		return nil, false
+	}
+	p := g.diffCachePath(prURL, headSHA)
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+	return f, true
+}
+
+// diffCacheWriter stores a diff for later runs while it is being downloaded.
+// It writes to a temp file that commit renames into place, so concurrent runs
+// never read a partial diff. Failures only cost a refetch, so they are logged
+// rather than returned, and never fail the download itself.
+type diffCacheWriter struct {
+	f    *os.File
+	path string
+	err  error
+}
+
+// newDiffCacheWriter starts caching the diff of prURL at headSHA. It returns
+// nil if the cache is disabled or can't be written.
+func (g *GhClient) newDiffCacheWriter(prURL, headSHA string) *diffCacheWriter {
+	if g.cacheDir == "" || headSHA == "" {
+		return nil
+	}
+	if err := os.MkdirAll(g.cacheDir, 0o700); err != nil {
+		slog.Debug("failed to create diff cache", "dir", g.cacheDir, "err", err)
+		return nil
+	}
+	f, err := os.CreateTemp(g.cacheDir, "tmp-*")
+	if err != nil {
+		slog.Debug("failed to write diff cache", "err", err)
+		return nil
+	}
+	return &diffCacheWriter{f: f, path: g.diffCachePath(prURL, headSHA)}
+}
+
+// Write appends p to the cache file. It always reports success; the first
+// error is kept for commit.
+func (w *diffCacheWriter) Write(p []byte) (int, error) {
+	if w.err == nil {
+		_, w.err = w.f.Write(p)
+	}
+	return len(p), nil
+}
+
+// commit moves the complete diff into the cache.
+func (w *diffCacheWriter) commit() {
+	err := errors.Join(w.err, w.f.Close())
+	if err == nil {
+		err = os.Rename(w.f.Name(), w.path)
+	}
+	if err != nil {
+		_ = os.Remove(w.f.Name())
+		slog.Debug("failed to write diff cache", "err", err)
+	}
+}
+
+// abort discards a diff that was not read to the end.
+func (w *diffCacheWriter) abort() {
+	_ = w.f.Close()
+	_ = os.Remove(w.f.Name())
+}
+
+// cachingBody is a diff response body that is copied into the cache as it
+// is read, and only kept there once read to the end.
+type cachingBody struct {
+	body  io.ReadCloser
+	cache *diffCacheWriter
+	done  bool
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	_, _ = b.cache.Write(p[:n])
+	if err == io.EOF && !b.done {
+		b.done = true
+		b.cache.commit()
+	}
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	if !b.done {
+		b.done = true
+		b.cache.abort()
+	}
+	return b.body.Close()
+}
+
+// pruneDiffCache removes cached diffs and attributions that haven't been used within maxAge.
+func pruneDiffCache(dir string, maxAge time.Duration) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Debug("failed to read diff cache", "dir", dir, "err", err)
+		}
+		return
+	}
+	cutoff := time.Now().Add(-maxAge)
+	for _, e := range entries {
+		if e.IsDir() || !(strings.HasSuffix(e.Name(), ".diff") || strings.HasSuffix(e.Name(), ".attr") || strings.HasPrefix(e.Name(), "tmp-")) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		_ = os.Remove(filepath.Join(dir, e.Name()))
+	}
+}
@@ -0,0 +1,115 @@
+package gh
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestFetchDiffUsesCache(t *testing.T) {
+	fetches := 0
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_, _ = w.Write([]byte("diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-a\n+b\n"))
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.cacheDir = t.TempDir()
+
+	pr := func(sha string) *github.PullRequest {
+		return &github.PullRequest{
+			URL:     github.Ptr(apiURL(srv) + "/repos/o/r/pulls/1"),
+			HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+			Head:    &github.PullRequestBranch{SHA: github.Ptr(sha)},
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := g.fetchDiff(context.Background(), pr("abc")); err != nil {
+			t.Fatalf("fetchDiff: %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("same head SHA fetched %d times, want 1", fetches)
+	}
+	if _, err := g.fetchDiff(context.Background(), pr("def")); err != nil {
+		t.Fatalf("fetchDiff: %v", err)
+	}
+	if fetches != 2 {
+		t.Errorf("new head SHA did not refetch: %d fetches, want 2", fetches)
+	}
+}
+
+func TestOpenDiffCachesOnlyCompleteReads(t *testing.T) {
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-a\n+b\n"))
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.cacheDir = t.TempDir()
+	pr := &github.PullRequest{
+		URL:     github.Ptr(apiURL(srv) + "/repos/o/r/pulls/1"),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Head:    &github.PullRequestBranch{SHA: github.Ptr("abc")},
+	}
+	cached := func() bool {
+		_, err := os.Stat(g.diffCachePath(pr.GetHTMLURL(), "abc"))
+		return err == nil
+	}
+
+	rc, err := g.openDiff(context.Background(), pr)
+	if err != nil {
+		t.Fatalf("openDiff: %v", err)
+	}
+	_, _ = rc.Read(make([]byte, 4))
+	_ = rc.Close()
+	if cached() {
+		t.Fatal("partially read diff was cached")
+	}
+	if entries, _ := os.ReadDir(g.cacheDir); len(entries) != 0 {
+		t.Errorf("cache dir has %d leftover files, want none", len(entries))
+	}
+
+	if _, err := g.fetchDiff(context.Background(), pr); err != nil {
+		t.Fatalf("fetchDiff: %v", err)
+	}
+	if !cached() {
+		t.Error("fully read diff was not cached")
+	}
+}
+
+func TestPruneDiffCache(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.diff")
+	fresh := filepath.Join(dir, "fresh.diff")
+	oldAttr := filepath.Join(dir, "old.attr")
+	other := filepath.Join(dir, "notes.txt")
+	for _, p := range []string{old, oldAttr, fresh, other} {
+		if err := os.WriteFile(p, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := time.Now().Add(-2 * DiffCacheMaxAge)
+	for _, p := range []string{old, oldAttr, other} {
+		if err := os.Chtimes(p, stale, stale); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruneDiffCache(dir, DiffCacheMaxAge)
+
+	for _, p := range []string{old, oldAttr} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("stale %s was not evicted", filepath.Base(p))
+		}
+	}
+	for _, p := range []string{fresh, other} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s was removed: %v", filepath.Base(p), err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/go-github/v72/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// CheckState summarizes the CI status of a PR's head commit.
+type CheckState string
+
+const (
+	CheckSuccess CheckState = "success"
+	CheckPending CheckState = "pending"
+	CheckFailure CheckState = "failure"
+)
+
+// CombinedStatus reports the CI state of pr's head commit, combining legacy
+// commit statuses and check runs: any failed status or check makes it
+// CheckFailure, otherwise anything still running makes it CheckPending. A
+// commit with no statuses or checks at all counts as CheckSuccess. Results
+// are cached per PR and head commit for the life of the client.
+func (g *GhClient) CombinedStatus(ctx context.Context, pr *github.PullRequest) (CheckState, error) {
+	key := pr.GetHTMLURL() + "@" + pr.GetHead().GetSHA()
+	g.mu.Lock()
+	state, ok := g.checks[key]
+	g.mu.Unlock()
+	if ok {
+		return state, nil
+	}
+
+	base := pr.GetBase()
+	if base == nil || base.GetRepo() == nil || base.GetRepo().GetOwner() == nil {
+		return "", fmt.Errorf("unable to determine owner/repo for PR %s", pr.GetHTMLURL())
+	}
+	owner, repo, sha := base.GetRepo().GetOwner().GetLogin(), base.GetRepo().GetName(), pr.GetHead().GetSHA()
+
+	state, err := g.statusState(ctx, owner, repo, sha)
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit status for PR %s: %w", pr.GetHTMLURL(), err)
+	}
+	if state != CheckFailure {
+		runs, err := g.checkRunsState(ctx, owner, repo, sha)
+		if err != nil {
+			return "", fmt.Errorf("failed to list check runs for PR %s: %w", pr.GetHTMLURL(), err)
+		}
+		if runs != CheckSuccess {
+			state = runs
+		}
+	}
+
+	g.mu.Lock()
+	if g.checks == nil {
+		g.checks = map[string]CheckState{}
+	}
+	g.checks[key] = state
+	g.mu.Unlock()
+	return state, nil
+}
+
+// statusState maps the combined commit status of sha to a CheckState.
+func (g *GhClient) statusState(ctx context.Context, owner, repo, sha string) (CheckState, error) {
+	status, _, err := g.c.Repositories.GetCombinedStatus(ctx, owner, repo, sha, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return "", err
+	}
+	// GitHub reports "pending" for commits without any status; those are
+	// judged by their check runs alone.
+	if status.GetTotalCount() == 0 {
+		return CheckSuccess, nil
+	}
+	switch status.GetState() {
+	case "success":
+		return CheckSuccess, nil
+	case "pending":
+		return CheckPending, nil
+	default:
+		return CheckFailure, nil
+	}
+}
+
+// checkRunsState folds the check runs of sha into a CheckState.
+func (g *GhClient) checkRunsState(ctx context.Context, owner, repo, sha string) (CheckState, error) {
+	state := CheckSuccess
+	opt := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		res, resp, err := g.c.Checks.ListCheckRunsForRef(ctx, owner, repo, sha, opt)
+		if err != nil {
+			return "", err
+		}
+		for _, run := range res.CheckRuns {
+			if run.GetStatus() != "completed" {
+				state = CheckPending
+				continue
+			}
+			switch run.GetConclusion() {
+			case "success", "neutral", "skipped":
+			default:
+				return CheckFailure, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return state, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// CombinedStatuses looks up CombinedStatus for every PR in parallel, keyed by
+// HTML URL. PRs whose status can't be read are left out.
+func (g *GhClient) CombinedStatuses(ctx context.Context, prs []*github.PullRequest) map[string]CheckState {
+	states := map[string]CheckState{}
+	var eg errgroup.Group
+	eg.SetLimit(g.concurrency)
+	for _, pr := range prs {
+		eg.Go(func() error {
+			state, err := g.CombinedStatus(ctx, pr)
+			if err != nil {
+				slog.Debug("skipping check status", "pr", pr.GetHTMLURL(), "err", err)
+				return nil
+			}
+			g.mu.Lock()
+			states[pr.GetHTMLURL()] = state
+			g.mu.Unlock()
+			return nil
+		})
+	}
+	eg.Wait()
+	return states
+}
@@ -0,0 +1,89 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestCombinedStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses string
+		runs     string
+		want     CheckState
+	}{
+		{
+			name:     "all green",
+			statuses: `{"state":"success","total_count":1}`,
+			runs:     `{"total_count":2,"check_runs":[{"status":"completed","conclusion":"success"},{"status":"completed","conclusion":"skipped"}]}`,
+			want:     CheckSuccess,
+		},
+		{
+			name:     "no statuses, only checks",
+			statuses: `{"state":"pending","total_count":0}`,
+			runs:     `{"total_count":1,"check_runs":[{"status":"completed","conclusion":"success"}]}`,
+			want:     CheckSuccess,
+		},
+		{
+			name:     "check still running",
+			statuses: `{"state":"success","total_count":1}`,
+			runs:     `{"total_count":1,"check_runs":[{"status":"in_progress"}]}`,
+			want:     CheckPending,
+		},
+		{
+			name:     "failed check",
+			statuses: `{"state":"pending","total_count":1}`,
+			runs:     `{"total_count":2,"check_runs":[{"status":"queued"},{"status":"completed","conclusion":"failure"}]}`,
+			want:     CheckFailure,
+		},
+		{
+			name:     "failed status",
+			statuses: `{"state":"error","total_count":1}`,
+			want:     CheckFailure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				switch r.URL.Path {
+				case "/repos/o/r/commits/head/status":
+					fmt.Fprint(w, tt.statuses)
+				case "/repos/o/r/commits/head/check-runs":
+					fmt.Fprint(w, tt.runs)
+				default:
+					http.NotFound(w, r)
+				}
+			})
+			defer srv.Close()
+			g := newTestClient(t, srv)
+
+			pr := &github.PullRequest{
+				HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+				Base: &github.PullRequestBranch{
+					Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}},
+				},
+				Head: &github.PullRequestBranch{SHA: github.Ptr("head")},
+			}
+			got, err := g.CombinedStatus(context.Background(), pr)
+			if err != nil {
+				t.Fatalf("CombinedStatus: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("CombinedStatus = %s, want %s", got, tt.want)
+			}
+			callsAfterFirst := calls
+			if _, err := g.CombinedStatus(context.Background(), pr); err != nil || calls != callsAfterFirst {
+				t.Errorf("second lookup made %d more requests, want cached", calls-callsAfterFirst)
+			}
+			if states := g.CombinedStatuses(context.Background(), []*github.PullRequest{pr}); states[pr.GetHTMLURL()] != tt.want {
+				t.Errorf("CombinedStatuses = %v, want %s", states, tt.want)
+			}
+		})
+	}
+}
@@ -2,32 +2,254 @@ package gh
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/v72/github"
 	"golang.org/x/oauth2"
 )
 
 type GhClient struct {
-	c     *github.Client
-	token string
+	c           *github.Client
+	token       string
+	baseURL     string       // REST API base URL for GitHub Enterprise; empty means github.com
+	concurrency int          // max PRs fetched in parallel
+	maxRetries  int          // retries for rate-limited raw requests
+	httpClient  *http.Client // optional transport shared by all requests
+	cacheDir    string       // on-disk diff cache; empty disables it
+	out         io.Writer    // where ApprovePr reports progress and warnings; nil logs them via slog
+
+	// timeout bounds each request, reading the response included; zero
+	// means no deadline.
+	timeout time.Duration
+
+	// snapshotDir holds review queues saved for later runs, reused for
+	// snapshotTTL; empty disables them
+	snapshotDir string
+	snapshotTTL time.Duration
+
+	mu     sync.Mutex
+	login  string                // cached by CurrentUser
+	teams  []string              // cached by UserTeams
+	checks map[string]CheckState // cached by CombinedStatus, keyed by PR URL and head SHA
+	// attributions maps PR URL and head SHA to the hunk attribution found
+	// by prAttribution
+	attributions map[string]map[string]string
+	// reviews maps PR URLs to the approval ApprovePr created, for DismissReview
+	reviews map[string]int64
+	// threads maps PR URLs to the notification thread that requested the
+	// review, as found by GetPrReviewRequested
+	threads map[string]string
+
+	sleep func(context.Context, time.Duration) error // overridable for tests
+}
+
+// Option configures a GhClient at construction time.
+type Option func(*GhClient)
+
+// WithBaseURL points the client at a GitHub Enterprise Server instance, e.g.
+// "https://ghe.example.com" or "https://ghe.example.com/api/v3/". An empty
+// value keeps the default (github.com, or GITHUB_API_URL if set).
+func WithBaseURL(baseURL string) Option {
+	return func(g *GhClient) {
+		if baseURL != "" {
+			g.baseURL = baseURL
+		}
+	}
+}
+
+// WithConcurrency sets how many PRs are fetched in parallel. It must be at
+// least 1; the default is CONCURRENCY_LIMIT.
+func WithConcurrency(n int) Option {
+	return func(g *GhClient) {
+		g.concurrency = n
+	}
+}
+
+// WithMaxRetries sets how many times a rate-limited request is retried before
+// giving up. Zero disables retries; the default is DefaultMaxRetries.
+func WithMaxRetries(n int) Option {
+	return func(g *GhClient) {
+		g.maxRetries = n
+	}
 }
 
-func NewGhClient() *GhClient {
-	ghToken := ""
-	if ghToken = os.Getenv("GITHUB_TOKEN"); ghToken == "" {
-		panic("GITHUB_TOKEN environment variable is not set")
+// DefaultTimeout bounds each request to GitHub, so a hung connection fails
+// the request instead of stalling the tool.
+const DefaultTimeout = 30 * time.Second
+
+// WithTimeout sets how long a single request to GitHub, including reading its
+// response, may take. Zero disables the deadline; the default is
+// DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(g *GhClient) {
+		g.timeout = d
+	}
+}
+
+// WithHTTPClient makes the client send every request, both go-github calls and
+// raw diff/compare/GraphQL requests, through hc's transport. This is mostly
+// useful for tests stubbing GitHub with an httptest.Server.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(g *GhClient) {
+		g.httpClient = hc
+	}
+}
+
+// WithOutput sends the progress and warning lines ApprovePr reports to w
+// instead of the slog logger, e.g. so a TUI can show them without corrupting
+// its screen.
+func WithOutput(w io.Writer) Option {
+	return func(g *GhClient) {
+		g.out = w
 	}
+}
 
+// report logs a progress or warning line from ApprovePr: to the WithOutput
+// writer if one was given, otherwise through slog at level.
+func (g *GhClient) report(ctx context.Context, level slog.Level, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if g.out == nil {
+		slog.Log(ctx, level, msg)
+		return
+	}
+	if level >= slog.LevelWarn {
+		msg = "warning: " + msg
+	}
+	fmt.Fprintln(g.out, msg)
+}
+
+// NewGhClient creates a client authenticated with the first token found in
+// GITHUB_TOKEN, GH_TOKEN or the gh CLI's stored credentials. It returns an
+// error if no token can be found.
+func NewGhClient(opts ...Option) (*GhClient, error) {
+	g, err := newGhClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	ghToken, source, err := resolveToken(g.host())
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("using GitHub token", "source", source)
+	g.token = ghToken
+	if err := g.connect(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// NewGhClientWithToken creates a client authenticated with the given token,
+// for callers that already resolved one and don't want the environment re-read.
+func NewGhClientWithToken(token string, opts ...Option) (*GhClient, error) {
+	if token == "" {
+		return nil, errors.New("empty GitHub token")
+	}
+	g, err := newGhClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	g.token = token
+	if err := g.connect(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// newGhClient applies the environment defaults and opts to an unconnected client.
+func newGhClient(opts []Option) (*GhClient, error) {
+	g := &GhClient{
+		baseURL:     os.Getenv("GITHUB_API_URL"),
+		concurrency: CONCURRENCY_LIMIT,
+		maxRetries:  DefaultMaxRetries,
+		timeout:     DefaultTimeout,
+		sleep:       sleepContext,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.concurrency < 1 {
+		return nil, fmt.Errorf("invalid concurrency %d: must be at least 1", g.concurrency)
+	}
+	if g.maxRetries < 0 {
+		return nil, fmt.Errorf("invalid max retries %d: must not be negative", g.maxRetries)
+	}
+	if g.timeout < 0 {
+		return nil, fmt.Errorf("invalid timeout %s: must not be negative", g.timeout)
+	}
+	if g.baseURL != "" {
+		if _, err := url.Parse(g.baseURL); err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL %q: %w", g.baseURL, err)
+		}
+	}
+	if g.cacheDir != "" {
+		pruneDiffCache(g.cacheDir, DiffCacheMaxAge)
+	}
+	return g, nil
+}
+
+// connect builds the underlying go-github client from the token and base URL.
+func (g *GhClient) connect() error {
 	ctx := context.Background()
+	if g.httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, g.httpClient)
+	}
 	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: ghToken},
+		&oauth2.Token{AccessToken: g.token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
+	tc.Timeout = g.timeout
 	client := github.NewClient(tc)
+	if g.baseURL != "" {
+		var err error
+		client, err = client.WithEnterpriseURLs(g.baseURL, g.baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid GitHub API URL %q: %w", g.baseURL, err)
+		}
+	}
+	g.c = client
+	return nil
+}
+
+// host returns the GitHub hostname the client talks to, as used by the gh CLI
+// to key its stored credentials.
+func (g *GhClient) host() string {
+	if g.baseURL == "" {
+		return defaultHost
+	}
+	u, err := url.Parse(g.baseURL)
+	if err != nil || u.Host == "" {
+		return defaultHost
+	}
+	if h := strings.TrimPrefix(u.Hostname(), "api."); h != "" {
+		return h
+	}
+	return defaultHost
+}
+
+// restURL builds an absolute REST API URL for the given path (without a
+// leading slash) against the configured host.
+func (g *GhClient) restURL(format string, args ...any) string {
+	return g.c.BaseURL.String() + fmt.Sprintf(format, args...)
+}
 
-	return &GhClient{
-		c:     client,
-		token: ghToken,
+// graphqlURL returns the GraphQL endpoint for the configured host. On
+// github.com it lives at api.github.com/graphql, on Enterprise Server at
+// <host>/api/graphql.
+func (g *GhClient) graphqlURL() string {
+	u := *g.c.BaseURL
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+	} else {
+		u.Path = "/graphql"
 	}
+	return u.String()
 }
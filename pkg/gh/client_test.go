@@ -0,0 +1,116 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newAPIServer serves handler under the Enterprise-style /api/v3 prefix that
+// newTestClient points the client at. The Enterprise GraphQL endpoint,
+// /api/graphql, reaches handler as /graphql.
+func newAPIServer(handler http.HandlerFunc) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/api/v3/", http.StripPrefix("/api/v3", handler))
+	mux.Handle("/api/graphql", http.StripPrefix("/api", handler))
+	return httptest.NewServer(mux)
+}
+
+// apiURL returns the REST API root of a server created by newAPIServer.
+func apiURL(srv *httptest.Server) string {
+	return srv.URL + "/api/v3"
+}
+
+// newTestClient returns a client whose requests all go to srv.
+func newTestClient(t *testing.T, srv *httptest.Server) *GhClient {
+	t.Helper()
+	g, err := NewGhClientWithToken("token", WithHTTPClient(srv.Client()), WithBaseURL(apiURL(srv)))
+	if err != nil {
+		t.Fatalf("NewGhClientWithToken: %v", err)
+	}
+	g.sleep = func(context.Context, time.Duration) error { return nil }
+	return g
+}
+
+func TestEnterpriseURLs(t *testing.T) {
+	tests := []struct {
+		name        string
+		baseURL     string
+		wantREST    string
+		wantGraphQL string
+		wantHost    string
+	}{
+		{
+			name:        "github.com default",
+			baseURL:     "",
+			wantREST:    "https://api.github.com/repos/o/r",
+			wantGraphQL: "https://api.github.com/graphql",
+			wantHost:    "github.com",
+		},
+		{
+			name:        "enterprise host only",
+			baseURL:     "https://ghe.example.com",
+			wantREST:    "https://ghe.example.com/api/v3/repos/o/r",
+			wantGraphQL: "https://ghe.example.com/api/graphql",
+			wantHost:    "ghe.example.com",
+		},
+		{
+			name:        "enterprise with api path",
+			baseURL:     "https://ghe.example.com/api/v3/",
+			wantREST:    "https://ghe.example.com/api/v3/repos/o/r",
+			wantGraphQL: "https://ghe.example.com/api/graphql",
+			wantHost:    "ghe.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_API_URL", "")
+			g, err := NewGhClientWithToken("token", WithBaseURL(tt.baseURL))
+			if err != nil {
+				t.Fatalf("NewGhClientWithToken: %v", err)
+			}
+			if got := g.restURL("repos/%s/%s", "o", "r"); got != tt.wantREST {
+				t.Errorf("restURL = %q, want %q", got, tt.wantREST)
+			}
+			if got := g.graphqlURL(); got != tt.wantGraphQL {
+				t.Errorf("graphqlURL = %q, want %q", got, tt.wantGraphQL)
+			}
+			if got := g.host(); got != tt.wantHost {
+				t.Errorf("host = %q, want %q", got, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestWithConcurrency(t *testing.T) {
+	g, err := NewGhClientWithToken("token")
+	if err != nil {
+		t.Fatalf("NewGhClientWithToken: %v", err)
+	}
+	if g.concurrency != CONCURRENCY_LIMIT {
+		t.Fatalf("default concurrency = %d, want %d", g.concurrency, CONCURRENCY_LIMIT)
+	}
+	g, err = NewGhClientWithToken("token", WithConcurrency(2))
+	if err != nil || g.concurrency != 2 {
+		t.Fatalf("WithConcurrency(2) = %v, %v", g, err)
+	}
+	if _, err := NewGhClientWithToken("token", WithConcurrency(0)); err == nil {
+		t.Fatal("WithConcurrency(0) succeeded, want error")
+	}
+}
+
+func TestReportToOutput(t *testing.T) {
+	var buf bytes.Buffer
+	g := &GhClient{out: &buf}
+	g.report(context.Background(), slog.LevelWarn, "failed for PR %d", 1)
+	g.report(context.Background(), slog.LevelInfo, "enabled auto-merge for PR %d", 2)
+	want := "warning: failed for PR 1\nenabled auto-merge for PR 2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,169 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v72/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// ChangeView selects how PrintChangesPerUser and the GUI present a PR's
+// changes.
+type ChangeView string
+
+const (
+	// ViewHunks lists each diff hunk under its hash, the unit approvals are
+	// decided on.
+	ViewHunks ChangeView = "hunks"
+	// ViewFiles lists each PR's changed files with their additions and
+	// deletions and the start of their patch, a summary that is often
+	// enough for trivial PRs.
+	ViewFiles ChangeView = "files"
+)
+
+// ParseChangeView validates a --view value; empty selects ViewHunks.
+func ParseChangeView(s string) (ChangeView, error) {
+	switch v := ChangeView(strings.ToLower(strings.TrimSpace(s))); v {
+	case "":
+		return ViewHunks, nil
+	case ViewHunks, ViewFiles:
+		return v, nil
+	default:
+		return "", fmt.Errorf("invalid view %q: want hunks or files", s)
+	}
+}
+
+// FileChange is a file changed by a PR.
+type FileChange struct {
+	Filename  string
+	Status    string // added, removed, modified, renamed, ...
+	Additions int
+	Deletions int
+	// Patch is the file's unified diff, empty for binary files and diffs
+	// too large for GitHub to include.
+	Patch string
+}
+
+// Summary describes f on one line, e.g. "pkg/a.go (+3 -1, modified)".
+func (f FileChange) Summary() string {
+	return fmt.Sprintf("%s (+%d -%d, %s)", f.Filename, f.Additions, f.Deletions, f.Status)
+}
+
+// PatchSnippet returns up to n lines of f's patch, followed by a line
+// counting the rest if it is longer.
+func (f FileChange) PatchSnippet(n int) []string {
+	if f.Patch == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(f.Patch, "\n"), "\n")
+	if len(lines) <= n {
+		return lines
+	}
+	return append(lines[:n:n], fmt.Sprintf("... %d more line(s)", len(lines)-n))
+}
+
+// PrFileMap maps a PR identifier (HTML URL) to the files it changes.
+type PrFileMap map[string][]FileChange
+
+// FilePatchLines is how much of each file's patch the files view shows.
+const FilePatchLines = 8
+
+// GetPrFiles lists the files pr changes, in the order GitHub returns them.
+func (g *GhClient) GetPrFiles(ctx context.Context, pr *github.PullRequest) ([]FileChange, error) {
+	owner, repo, err := prRepo(pr)
+	if err != nil {
+		return nil, err
+	}
+	var files []FileChange
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := g.c.PullRequests.ListFiles(ctx, owner, repo, pr.GetNumber(), opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files of PR %s: %w", pr.GetHTMLURL(), err)
+		}
+		for _, f := range page {
+			files = append(files, FileChange{
+				Filename:  f.GetFilename(),
+				Status:    f.GetStatus(),
+				Additions: f.GetAdditions(),
+				Deletions: f.GetDeletions(),
+				Patch:     f.GetPatch(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return files, nil
+}
+
+// PrFiles lists the files of every PR in parallel, keyed by HTML URL. PRs
+// whose files fail to load are logged and left out.
+func (g *GhClient) PrFiles(ctx context.Context, prs []*github.PullRequest) PrFileMap {
+	files := PrFileMap{}
+	var mu sync.Mutex
+	var eg errgroup.Group
+	eg.SetLimit(g.concurrency)
+	for _, pr := range prs {
+		eg.Go(func() error {
+			f, err := g.GetPrFiles(ctx, pr)
+			if err != nil {
+				slog.Warn("skipping PR files", "pr", pr.GetHTMLURL(), "err", err)
+				return nil
+			}
+			mu.Lock()
+			files[pr.GetHTMLURL()] = f
+			mu.Unlock()
+			return nil
+		})
+	}
+	eg.Wait()
+	return files
+}
+
+// userPRs returns the PRs of user in res once each, ordered per sortBy.
+func userPRs(res *FetchResult, user string, sortBy PRSort) []*github.PullRequest {
+	var prs []*github.PullRequest
+	seen := map[string]bool{}
+	for _, list := range res.UserHashPrMap[user] {
+		for _, pr := range list {
+			if !seen[pr.GetHTMLURL()] {
+				seen[pr.GetHTMLURL()] = true
+				prs = append(prs, pr)
+			}
+		}
+	}
+	slices.SortFunc(prs, sortBy.compare)
+	return prs
+}
+
+// printChangesByFile prints to w, for each of users, their PRs ordered per
+// sortBy with the files each changes from files.
+func printChangesByFile(w io.Writer, users []string, res *FetchResult, files PrFileMap, sortBy PRSort) {
+	for _, user := range users {
+		fmt.Fprintf(w, "User: %s\n", user)
+		for _, pr := range userPRs(res, user, sortBy) {
+			prKey := pr.GetHTMLURL()
+			fmt.Fprintf(w, "  PR: %s  %s\n", prKey, pr.GetTitle())
+			changed, ok := files[prKey]
+			if !ok {
+				fmt.Fprintln(w, "    Files could not be listed.")
+				continue
+			}
+			fmt.Fprintf(w, "    Files (%d):\n", len(changed))
+			for _, f := range changed {
+				fmt.Fprintf(w, "      %s\n", f.Summary())
+				for _, line := range f.PatchSnippet(FilePatchLines) {
+					fmt.Fprintf(w, "        %s\n", line)
+				}
+			}
+		}
+	}
+}
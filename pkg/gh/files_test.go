@@ -0,0 +1,82 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestPrintChangesByFile(t *testing.T) {
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/o/r/pulls/1/files" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `[{"filename":"a.go","status":"modified","additions":1,"deletions":1,"patch":"@@ -1 +1 @@\n-old\n+new"},`+
+			`{"filename":"logo.png","status":"added","additions":0,"deletions":0}]`)
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	repo := &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}}
+	pr := func(n int) *github.PullRequest {
+		return &github.PullRequest{
+			Number:  github.Ptr(n),
+			Title:   github.Ptr(fmt.Sprintf("PR %d", n)),
+			HTMLURL: github.Ptr(fmt.Sprintf("https://github.com/o/r/pull/%d", n)),
+			Base:    &github.PullRequestBranch{Repo: repo},
+		}
+	}
+	pr1, pr2 := pr(1), pr(2)
+	res := &FetchResult{UserHashPrMap: GhPrHashMap{"alice": {"h1": {pr1}, "h2": {pr1, pr2}}}}
+
+	files := g.PrFiles(context.Background(), []*github.PullRequest{pr1, pr2})
+	if len(files) != 1 || len(files[pr1.GetHTMLURL()]) != 2 {
+		t.Fatalf("files = %v, want PR 1's two files only", files)
+	}
+
+	var out bytes.Buffer
+	printChangesByFile(&out, []string{"alice"}, res, files, PRSortRepo)
+	want := strings.Join([]string{
+		"User: alice",
+		"  PR: https://github.com/o/r/pull/1  PR 1",
+		"    Files (2):",
+		"      a.go (+1 -1, modified)",
+		"        @@ -1 +1 @@",
+		"        -old",
+		"        +new",
+		"      logo.png (+0 -0, added)",
+		"  PR: https://github.com/o/r/pull/2  PR 2",
+		"    Files could not be listed.",
+		"",
+	}, "\n")
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestPatchSnippet(t *testing.T) {
+	f := FileChange{Patch: "@@ -1,3 +1,3 @@\n a\n-b\n+c\n"}
+	if got := f.PatchSnippet(2); len(got) != 3 || got[2] != "... 2 more line(s)" {
+		t.Errorf("PatchSnippet(2) = %q", got)
+	}
+	if got := f.PatchSnippet(4); len(got) != 4 {
+		t.Errorf("PatchSnippet(4) = %q, want the whole patch", got)
+	}
+}
+
+func TestParseChangeView(t *testing.T) {
+	for in, want := range map[string]ChangeView{"": ViewHunks, "hunks": ViewHunks, " Files ": ViewFiles} {
+		if got, err := ParseChangeView(in); err != nil || got != want {
+			t.Errorf("ParseChangeView(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseChangeView("tree"); err == nil {
+		t.Error("ParseChangeView(tree) succeeded, want error")
+	}
+}
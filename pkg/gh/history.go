@@ -0,0 +1,123 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// QueueHistoryRetention is how long SaveQueueHistory keeps past queues.
+const QueueHistoryRetention = 30 * 24 * time.Hour
+
+// historyLayout names history files by when they were saved, so they sort
+// by name.
+const historyLayout = "20060102T150405.000000000Z"
+
+// DefaultQueueHistoryDir returns the per-user directory past review queues
+// are kept in for comparing against later.
+func DefaultQueueHistoryDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-pr-review", "history"), nil
+}
+
+// SavedQueue is a review queue saved by SaveQueueHistory.
+type SavedQueue struct {
+	Saved  time.Time
+	Result *FetchResult
+	// Checks are the CI states of the queue's PRs by URL, nil if they were
+	// not saved with it.
+	Checks map[string]CheckState
+	// Path is the file the queue was read from.
+	Path string
+}
+
+// SaveQueueHistory saves res, with the CI states of its PRs, under dir for
+// later runs to compare against, and removes the queues saved there more
+// than QueueHistoryRetention ago. Like the queue snapshot, queues are kept
+// apart per GitHub user and fetch options. It returns the file written.
+func (g *GhClient) SaveQueueHistory(ctx context.Context, dir string, fetch FetchOptions, res *FetchResult, checks map[string]CheckState) (string, error) {
+	key, err := g.queueKey(ctx, fetch)
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, key)
+	snap := newQueueSnapshot(fetch, res)
+	snap.Checks = checks
+	p := filepath.Join(dir, snap.Saved.UTC().Format(historyLayout)+".json")
+	if err := writeSnapshotFile(dir, p, snap); err != nil {
+		return "", err
+	}
+	saved, _ := historyFiles(dir)
+	for _, f := range saved {
+		if time.Since(f.saved) > QueueHistoryRetention {
+			_ = os.Remove(f.path)
+		}
+	}
+	return p, nil
+}
+
+// LoadQueueHistory returns the newest queue saved under dir at or before at,
+// for the same GitHub user and fetch options.
+func (g *GhClient) LoadQueueHistory(ctx context.Context, dir string, fetch FetchOptions, at time.Time) (*SavedQueue, error) {
+	key, err := g.queueKey(ctx, fetch)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := historyFiles(filepath.Join(dir, key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for i := len(saved) - 1; i >= 0; i-- {
+		if !saved[i].saved.After(at) {
+			return ReadSavedQueue(saved[i].path)
+		}
+	}
+	return nil, fmt.Errorf("no review queue saved at or before %s", at.Local().Format("2006-01-02 15:04"))
+}
+
+// ReadSavedQueue reads a queue saved by SaveQueueHistory, or a queue
+// snapshot, from path.
+func ReadSavedQueue(path string) (*SavedQueue, error) {
+	snap, err := readSnapshotFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved review queue %s: %w", path, err)
+	}
+	return &SavedQueue{Saved: snap.Saved, Result: snap.Result, Checks: snap.Checks, Path: path}, nil
+}
+
+// historyFile is a queue saved in a history directory.
+type historyFile struct {
+	path  string
+	saved time.Time
+}
+
+// historyFiles lists the queues saved in dir, oldest first. Other files,
+// e.g. a temp file being written, are skipped.
+func historyFiles(dir string) ([]historyFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []historyFile
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		saved, err := time.Parse(historyLayout, name)
+		if err != nil {
+			continue
+		}
+		files = append(files, historyFile{path: filepath.Join(dir, e.Name()), saved: saved})
+	}
+	// ReadDir sorts by name, which historyLayout makes chronological
+	return files, nil
+}
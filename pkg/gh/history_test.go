@@ -0,0 +1,64 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueueHistory(t *testing.T) {
+	srv := newAPIServer(nil)
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.login = "me"
+	dir := t.TempDir()
+	ctx := context.Background()
+	res := &FetchResult{
+		PrMap:    PrHashMap{"https://github.com/o/r/pull/1": {"a"}},
+		Failures: map[string]error{"o/r#2": errors.New("boom")},
+	}
+	checks := map[string]CheckState{"https://github.com/o/r/pull/1": CheckPending}
+
+	p, err := g.SaveQueueHistory(ctx, dir, FetchOptions{}, res, checks)
+	if err != nil {
+		t.Fatalf("SaveQueueHistory: %v", err)
+	}
+	// move it back a day, and add one saved past the retention
+	day := time.Now().Add(-24 * time.Hour)
+	older := filepath.Join(filepath.Dir(p), day.UTC().Format(historyLayout)+".json")
+	if err := os.Rename(p, older); err != nil {
+		t.Fatal(err)
+	}
+	expired := filepath.Join(filepath.Dir(p), time.Now().Add(-QueueHistoryRetention-time.Hour).UTC().Format(historyLayout)+".json")
+	if err := os.WriteFile(expired, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.SaveQueueHistory(ctx, dir, FetchOptions{}, &FetchResult{}, nil); err != nil {
+		t.Fatalf("SaveQueueHistory: %v", err)
+	}
+	if _, err := os.Stat(expired); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("queue saved past the retention was kept: %v", err)
+	}
+
+	saved, err := g.LoadQueueHistory(ctx, dir, FetchOptions{}, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("LoadQueueHistory: %v", err)
+	}
+	if saved.Path != older || len(saved.Result.PrMap) != 1 || saved.Checks["https://github.com/o/r/pull/1"] != CheckPending {
+		t.Errorf("loaded %s with %v and checks %v, want the queue from a day ago", saved.Path, saved.Result.PrMap, saved.Checks)
+	}
+	if err := saved.Result.Failures["o/r#2"]; err == nil || err.Error() != "boom" {
+		t.Errorf("failures = %v, want o/r#2 restored", saved.Result.Failures)
+	}
+
+	if _, err := g.LoadQueueHistory(ctx, dir, FetchOptions{}, day.Add(-time.Hour)); err == nil {
+		t.Error("LoadQueueHistory before anything was saved returned no error")
+	}
+	// other filters keep their own history
+	if _, err := g.LoadQueueHistory(ctx, dir, FetchOptions{Repos: []string{"o/r"}}, time.Now()); err == nil {
+		t.Error("LoadQueueHistory for other filters found a queue")
+	}
+}
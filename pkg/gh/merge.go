@@ -0,0 +1,285 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// MergeMethod is how an approved PR gets merged.
+type MergeMethod string
+
+const (
+	MergeMethodMerge  MergeMethod = "merge"
+	MergeMethodSquash MergeMethod = "squash"
+	MergeMethodRebase MergeMethod = "rebase"
+)
+
+// DefaultMergeMethod is used when ApproveOptions.MergeMethod is empty.
+const DefaultMergeMethod = MergeMethodSquash
+
+// ParseMergeMethod validates a --merge-method value. An empty string yields
+// DefaultMergeMethod.
+func ParseMergeMethod(s string) (MergeMethod, error) {
+	switch m := MergeMethod(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return DefaultMergeMethod, nil
+	case MergeMethodMerge, MergeMethodSquash, MergeMethodRebase:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid merge method %q: want merge, squash or rebase", s)
+	}
+}
+
+// graphQL returns the PullRequestMergeMethod enum value.
+func (m MergeMethod) graphQL() string {
+	return strings.ToUpper(string(m.orDefault()))
+}
+
+// rest returns the merge_method value of the REST merge endpoint.
+func (m MergeMethod) rest() string {
+	return string(m.orDefault())
+}
+
+func (m MergeMethod) orDefault() MergeMethod {
+	if m == "" {
+		return DefaultMergeMethod
+	}
+	return m
+}
+
+// ApproveOptions controls what ApprovePr does after approving a PR.
+type ApproveOptions struct {
+	// MergeMethod is used both for auto-merge and for the immediate merge
+	// fallback. The zero value means DefaultMergeMethod.
+	MergeMethod MergeMethod
+	// ApproveOnly stops after the approval review, leaving the merge to a
+	// human. By default auto-merge is enabled.
+	ApproveOnly bool
+	// UpdateBranch brings the PR's head branch up to date with its base
+	// before approving, when it is behind. Off by default since updating via
+	// the API is unreliable and rewrites the author's branch.
+	UpdateBranch bool
+	// Comment is the body of the approval review; empty approves silently.
+	Comment string
+	// SubmitDeclines sends a REQUEST_CHANGES review for PRs whose changes
+	// were all declined, instead of only skipping them locally.
+	SubmitDeclines bool
+	// DeclineComment is the body of those reviews; GitHub requires one, so
+	// empty means DefaultDeclineComment.
+	DeclineComment string
+	// Force approves PRs the user already approved at their head commit,
+	// and PRs whose head moved since they were loaded; by default the
+	// former are skipped to avoid duplicate reviews and the latter fail
+	// with a *HeadChangedError, so nobody approves a diff they never saw.
+	Force bool
+	// RequireGreen skips PRs whose head commit's checks (see
+	// CombinedStatus) have not all passed.
+	RequireGreen bool
+	// MarkRead marks the PR's review-request notification read once it is
+	// approved, so it doesn't show up again on the next run.
+	MarkRead bool
+	// DryRun makes ApprovePr stop after its read-only checks, so nothing
+	// is updated, approved or merged; DryRunLine names what it would do.
+	DryRun bool
+}
+
+// DryRunLine is what a dry run reports instead of approving prKey with o,
+// naming every step ApprovePr would take, e.g. "[dry-run] Would approve PR
+// <url>; would enable auto-merge (squash), or squash merge if that fails".
+func (o ApproveOptions) DryRunLine(prKey string) string {
+	line := "[dry-run] Would approve PR " + prKey
+	for _, step := range o.steps() {
+		line += "; would " + step
+	}
+	return line
+}
+
+// steps describes what ApprovePr does with o besides the approval review.
+func (o ApproveOptions) steps() []string {
+	var steps []string
+	if o.UpdateBranch {
+		steps = append(steps, "first update branch if behind its base")
+	}
+	if o.MarkRead {
+		steps = append(steps, "mark review request read")
+	}
+	if !o.ApproveOnly {
+		m := o.MergeMethod.orDefault()
+		steps = append(steps, fmt.Sprintf("enable auto-merge (%s), or %s merge if that fails", m, m))
+	}
+	return steps
+}
+
+// ApproveResult describes what ApprovePr did to a PR.
+type ApproveResult struct {
+	PR        string // HTML URL of the PR
+	ReviewID  int64  // ID of the approval review; 0 if none was created
+	ReviewURL string // HTML URL of the approval review
+	AutoMerge bool   // auto-merge was enabled
+	Merged    bool   // auto-merge failed and the PR was merged immediately
+	Read      bool   // the review-request notification was marked read
+}
+
+// HeadChangedError is returned by ApprovePr when a PR's head commit is no
+// longer the one it was loaded at, so the reviewed diff is out of date.
+type HeadChangedError struct {
+	PR      string // HTML URL of the PR
+	Loaded  string // head SHA when the PR was loaded
+	Current string // head SHA on GitHub now
+}
+
+func (e *HeadChangedError) Error() string {
+	return fmt.Sprintf("PR %s changed since it was loaded (head %s, now %s); re-review it or use --force", e.PR, ShortSHA(e.Loaded), ShortSHA(e.Current))
+}
+
+// DefaultDeclineComment is the REQUEST_CHANGES review body used when
+// ApproveOptions.DeclineComment is empty.
+const DefaultDeclineComment = "Changes requested via gh-pr-review."
+
+// errMergeMethodNotAllowed builds the error reported when a repository's
+// settings reject the configured merge method.
+func errMergeMethodNotAllowed(pr *github.PullRequest, m MergeMethod) error {
+	return fmt.Errorf("%s merges are not allowed for PR %s; pick another --merge-method", m.orDefault(), pr.GetHTMLURL())
+}
+
+// isMergeMethodNotAllowed reports whether msg is GitHub rejecting the merge
+// method, as opposed to some other merge failure.
+func isMergeMethodNotAllowed(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "merge method") && strings.Contains(msg, "not allowed")
+}
+
+// isRESTMergeMethodNotAllowed reports whether err from the REST merge endpoint
+// is the 405 GitHub returns for a disallowed merge method.
+func isRESTMergeMethodNotAllowed(err error) bool {
+	var ghErr *github.ErrorResponse
+	if !errors.As(err, &ghErr) || ghErr.Response == nil {
+		return false
+	}
+	return ghErr.Response.StatusCode == http.StatusMethodNotAllowed && isMergeMethodNotAllowed(ghErr.Message)
+}
+
+// mergeAttempts bounds how many times tryMerge tries to merge a PR, waiting
+// mergeRetryDelay before each retry and each new look at a mergeability
+// GitHub is still computing.
+const (
+	mergeAttempts   = 3
+	mergeRetryDelay = 2 * time.Second
+)
+
+// ErrMergeConflicts is wrapped by the error tryMerge returns for a PR that
+// can't be merged because it conflicts with its base branch.
+var ErrMergeConflicts = errors.New("merge conflicts with the base branch")
+
+// awaitMergeState fetches the PR's MergeState, looking again a few times
+// while GitHub is still computing it. It returns MergeStateUnknown if that
+// takes too long or the PR can't be fetched, leaving it to the merge to tell.
+func (g *GhClient) awaitMergeState(ctx context.Context, owner, repo string, number int) MergeState {
+	for attempt := 1; ; attempt++ {
+		pr, err := g.GetPR(ctx, owner, repo, number)
+		if err != nil {
+			slog.Debug("failed to check mergeability", "pr", number, "err", err)
+			return MergeStateUnknown
+		}
+		state := PRMergeState(pr)
+		if state != MergeStateUnknown || attempt >= mergeAttempts {
+			return state
+		}
+		if err := g.sleep(ctx, mergeRetryDelay); err != nil {
+			return MergeStateUnknown
+		}
+	}
+}
+
+// isTransientMergeError reports whether err from the REST merge endpoint may
+// go away on its own: a 405 for a PR that is not mergeable yet, e.g. while
+// required statuses are still being reported. A 409, for a head that moved
+// away from the SHA being merged, is final.
+func isTransientMergeError(err error) bool {
+	var ghErr *github.ErrorResponse
+	if !errors.As(err, &ghErr) || ghErr.Response == nil {
+		return false
+	}
+	return ghErr.Response.StatusCode == http.StatusMethodNotAllowed
+}
+
+// MergeState is what GitHub reports about merging a PR.
+type MergeState string
+
+const (
+	// MergeStateUnknown means GitHub hasn't computed it yet.
+	MergeStateUnknown MergeState = ""
+	// MergeStateOpen is an open PR without conflicts or failing checks,
+	// though it may still wait for reviews.
+	MergeStateOpen          MergeState = "open"
+	MergeStateConflicts     MergeState = "conflicts"
+	MergeStateChecksFailing MergeState = "checks failing"
+	MergeStateMerged        MergeState = "merged"
+	MergeStateClosed        MergeState = "closed"
+)
+
+// PRMergeState reads the MergeState of pr as it was fetched.
+func PRMergeState(pr *github.PullRequest) MergeState {
+	switch {
+	case pr.GetMerged():
+		return MergeStateMerged
+	case pr.GetState() == "closed":
+		return MergeStateClosed
+	}
+	switch pr.GetMergeableState() {
+	case "", "unknown":
+		return MergeStateUnknown
+	case "dirty":
+		return MergeStateConflicts
+	case "unstable":
+		return MergeStateChecksFailing
+	default:
+		return MergeStateOpen
+	}
+}
+
+// MergeStates returns the MergeState of every PR, keyed by HTML URL. The
+// state comes with the PR, so only PRs GitHub was still computing it for when
+// they were fetched are fetched again, in parallel. PRs whose state stays
+// unknown are left out.
+func (g *GhClient) MergeStates(ctx context.Context, prs []*github.PullRequest) map[string]MergeState {
+	states := map[string]MergeState{}
+	var eg errgroup.Group
+	eg.SetLimit(g.concurrency)
+	for _, pr := range prs {
+		state := PRMergeState(pr)
+		if state != MergeStateUnknown {
+			g.mu.Lock()
+			states[pr.GetHTMLURL()] = state
+			g.mu.Unlock()
+			continue
+		}
+		eg.Go(func() error {
+			owner, repo, err := prRepo(pr)
+			if err != nil {
+				return nil
+			}
+			fresh, err := g.GetPR(ctx, owner, repo, pr.GetNumber())
+			if err != nil {
+				slog.Debug("skipping merge state", "pr", pr.GetHTMLURL(), "err", err)
+				return nil
+			}
+			if state := PRMergeState(fresh); state != MergeStateUnknown {
+				g.mu.Lock()
+				states[pr.GetHTMLURL()] = state
+				g.mu.Unlock()
+			}
+			return nil
+		})
+	}
+	eg.Wait()
+	return states
+}
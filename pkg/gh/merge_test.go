@@ -0,0 +1,557 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestParseMergeMethod(t *testing.T) {
+	tests := []struct {
+		in          string
+		want        MergeMethod
+		wantGraphQL string
+		wantErr     bool
+	}{
+		{in: "", want: MergeMethodSquash, wantGraphQL: "SQUASH"},
+		{in: "merge", want: MergeMethodMerge, wantGraphQL: "MERGE"},
+		{in: "Rebase", want: MergeMethodRebase, wantGraphQL: "REBASE"},
+		{in: "fast-forward", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMergeMethod(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseMergeMethod(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got != tt.want || got.graphQL() != tt.wantGraphQL || got.rest() != string(tt.want) {
+			t.Errorf("ParseMergeMethod(%q) = %q (%s/%s), want %q (%s)", tt.in, got, got.graphQL(), got.rest(), tt.want, tt.wantGraphQL)
+		}
+	}
+}
+
+func TestMergeMethodNotAllowed(t *testing.T) {
+	var gotGraphQL, gotREST string
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/graphql":
+			var req struct {
+				Variables map[string]string `json:"variables"`
+			}
+			_ = json.Unmarshal(body, &req)
+			gotGraphQL = req.Variables["mergeMethod"]
+			fmt.Fprint(w, `{"errors":[{"message":"Merge method rebase is not allowed on this repository"}]}`)
+		case strings.HasSuffix(r.URL.Path, "/merge"):
+			var req struct {
+				MergeMethod string `json:"merge_method"`
+			}
+			_ = json.Unmarshal(body, &req)
+			gotREST = req.MergeMethod
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			fmt.Fprint(w, `{"message":"Rebase merges are not allowed on this repository. Merge method rebase is not allowed."}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	pr := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/1")}
+
+	err := g.tryEnableAutoMerge(context.Background(), "node", pr, MergeMethodRebase)
+	if err == nil || !strings.Contains(err.Error(), "rebase merges are not allowed") {
+		t.Errorf("tryEnableAutoMerge error = %v, want merge method not allowed", err)
+	}
+	if gotGraphQL != "REBASE" {
+		t.Errorf("GraphQL mergeMethod = %q, want REBASE", gotGraphQL)
+	}
+
+	err = g.tryMerge(context.Background(), "o", "r", 1, pr, "", MergeMethodRebase)
+	if err == nil || !strings.Contains(err.Error(), "rebase merges are not allowed") {
+		t.Errorf("tryMerge error = %v, want merge method not allowed", err)
+	}
+	if gotREST != "rebase" {
+		t.Errorf("REST merge_method = %q, want rebase", gotREST)
+	}
+}
+
+func TestTryMergeRetries(t *testing.T) {
+	var gets, merges int
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/pulls/1":
+			gets++
+			state := "clean"
+			if gets == 1 {
+				state = "unknown"
+			}
+			fmt.Fprintf(w, `{"number":1,"state":"open","mergeable_state":%q}`, state)
+		case r.Method == http.MethodPut && r.URL.Path == "/repos/o/r/pulls/1/merge":
+			merges++
+			if merges == 1 {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				fmt.Fprint(w, `{"message":"Pull Request is not mergeable"}`)
+				return
+			}
+			fmt.Fprint(w, `{"merged":true}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	var waits int
+	g.sleep = func(context.Context, time.Duration) error { waits++; return nil }
+	pr := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/1")}
+
+	if err := g.tryMerge(context.Background(), "o", "r", 1, pr, "", MergeMethodSquash); err != nil {
+		t.Fatalf("tryMerge: %v", err)
+	}
+	// unknown, then clean; the merge is rejected once and retried
+	if gets != 3 || merges != 2 || waits != 2 {
+		t.Errorf("got %d lookups, %d merges and %d waits; want 3, 2 and 2", gets, merges, waits)
+	}
+}
+
+func TestTryMergeHeadMoved(t *testing.T) {
+	var merges int
+	var gotSHA string
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/pulls/1":
+			fmt.Fprint(w, `{"number":1,"state":"open","mergeable_state":"clean"}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/repos/o/r/pulls/1/merge":
+			merges++
+			var req struct {
+				SHA string `json:"sha"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			gotSHA = req.SHA
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"message":"Head branch was modified. Review and try the merge again."}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.sleep = func(context.Context, time.Duration) error { return nil }
+	pr := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/1")}
+
+	if err := g.tryMerge(context.Background(), "o", "r", 1, pr, "abc123", MergeMethodSquash); err == nil {
+		t.Fatal("tryMerge merged a PR whose head moved")
+	}
+	if merges != 1 || gotSHA != "abc123" {
+		t.Errorf("got %d merges at sha %q; want 1 at abc123, not retried", merges, gotSHA)
+	}
+}
+
+func TestTryMergeConflicts(t *testing.T) {
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/repos/o/r/pulls/1" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"number":1,"state":"open","mergeable_state":"dirty"}`)
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	pr := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/1")}
+
+	err := g.tryMerge(context.Background(), "o", "r", 1, pr, "", MergeMethodSquash)
+	if !errors.Is(err, ErrMergeConflicts) {
+		t.Errorf("tryMerge error = %v, want merge conflicts", err)
+	}
+}
+
+func TestApprovePrApproveOnly(t *testing.T) {
+	var reviews int
+	var review github.PullRequestReviewRequest
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/reviews"):
+			reviews++
+			_ = json.NewDecoder(r.Body).Decode(&review)
+			fmt.Fprint(w, `{"id":12345,"html_url":"https://github.com/o/r/pull/1#pullrequestreview-12345"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.out = io.Discard
+
+	pr := &github.PullRequest{
+		Number:  github.Ptr(1),
+		NodeID:  github.Ptr("node"),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Base: &github.PullRequestBranch{
+			Ref:  github.Ptr("main"),
+			Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}},
+		},
+		Head: &github.PullRequestBranch{Ref: github.Ptr("feature")},
+	}
+	res, err := g.ApprovePr(context.Background(), pr, ApproveOptions{ApproveOnly: true, Comment: "LGTM"})
+	if err != nil {
+		t.Fatalf("ApprovePr: %v", err)
+	}
+	want := ApproveResult{PR: "https://github.com/o/r/pull/1", ReviewID: 12345, ReviewURL: "https://github.com/o/r/pull/1#pullrequestreview-12345"}
+	if res != want {
+		t.Errorf("result = %+v, want %+v", res, want)
+	}
+	if reviews != 1 {
+		t.Errorf("got %d reviews, want 1", reviews)
+	}
+	if review.GetEvent() != "APPROVE" || review.GetBody() != "LGTM" {
+		t.Errorf("review = %s %q, want APPROVE \"LGTM\"", review.GetEvent(), review.GetBody())
+	}
+}
+
+func TestApprovePrAutoMergeResult(t *testing.T) {
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/reviews"):
+			fmt.Fprint(w, `{"id":7}`)
+		case r.URL.Path == "/graphql":
+			fmt.Fprint(w, `{"data":{}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.out = io.Discard
+
+	pr := &github.PullRequest{
+		Number:  github.Ptr(1),
+		NodeID:  github.Ptr("node"),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Base: &github.PullRequestBranch{
+			Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}},
+		},
+	}
+	res, err := g.ApprovePr(context.Background(), pr, ApproveOptions{})
+	if err != nil {
+		t.Fatalf("ApprovePr: %v", err)
+	}
+	if res.ReviewID != 7 || !res.AutoMerge || res.Merged {
+		t.Errorf("result = %+v, want review 7 with auto-merge", res)
+	}
+}
+
+func TestApprovePrUpdateBranch(t *testing.T) {
+	var calls []string
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/compare/"):
+			calls = append(calls, "compare")
+			fmt.Fprint(w, `{"status":"behind"}`)
+		case strings.HasSuffix(r.URL.Path, "/update-branch"):
+			calls = append(calls, "update-branch")
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, `{}`)
+		case strings.HasSuffix(r.URL.Path, "/reviews"):
+			calls = append(calls, "review")
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.out = io.Discard
+
+	pr := &github.PullRequest{
+		Number:  github.Ptr(1),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Base: &github.PullRequestBranch{
+			Ref:  github.Ptr("main"),
+			Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}},
+		},
+		Head: &github.PullRequestBranch{Ref: github.Ptr("feature")},
+	}
+	if _, err := g.ApprovePr(context.Background(), pr, ApproveOptions{ApproveOnly: true, UpdateBranch: true}); err != nil {
+		t.Fatalf("ApprovePr: %v", err)
+	}
+	if got := strings.Join(calls, ","); got != "compare,update-branch,review" {
+		t.Errorf("calls = %s, want compare,update-branch,review", got)
+	}
+}
+
+func TestApprovePrMergesUpdatedHead(t *testing.T) {
+	head, expected, merged := "aaaaaaaaaa", "", ""
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/pulls/1":
+			fmt.Fprintf(w, `{"number":1,"state":"open","mergeable_state":"clean","head":{"sha":%q}}`, head)
+		case strings.Contains(r.URL.Path, "/compare/"):
+			fmt.Fprint(w, `{"status":"behind"}`)
+		case strings.HasSuffix(r.URL.Path, "/update-branch"):
+			var req struct {
+				ExpectedHeadSHA string `json:"expected_head_sha"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			expected, head = req.ExpectedHeadSHA, "bbbbbbbbbb"
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, `{}`)
+		case strings.HasSuffix(r.URL.Path, "/reviews"):
+			fmt.Fprint(w, `{"id":1}`)
+		case r.URL.Path == "/graphql":
+			fmt.Fprint(w, `{"errors":[{"message":"auto-merge is not allowed"}]}`)
+		case strings.HasSuffix(r.URL.Path, "/merge"):
+			var req struct {
+				SHA string `json:"sha"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			merged = req.SHA
+			fmt.Fprint(w, `{"merged":true}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.out = io.Discard
+
+	pr := &github.PullRequest{
+		Number:  github.Ptr(1),
+		NodeID:  github.Ptr("node"),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Base: &github.PullRequestBranch{
+			Ref:  github.Ptr("main"),
+			Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}},
+		},
+		Head: &github.PullRequestBranch{Ref: github.Ptr("feature"), SHA: github.Ptr("aaaaaaaaaa")},
+	}
+	res, err := g.ApprovePr(context.Background(), pr, ApproveOptions{UpdateBranch: true})
+	if err != nil || !res.Merged {
+		t.Fatalf("ApprovePr = %+v, %v; want merged", res, err)
+	}
+	if expected != "aaaaaaaaaa" || merged != "bbbbbbbbbb" {
+		t.Errorf("updated branch expecting %q and merged %q; want aaaaaaaaaa and bbbbbbbbbb", expected, merged)
+	}
+}
+
+func TestApprovePrHeadChanged(t *testing.T) {
+	var reviews int
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/reviews"):
+			reviews++
+			fmt.Fprint(w, `{"id":1}`)
+		case r.URL.Path == "/repos/o/r/pulls/1":
+			fmt.Fprint(w, `{"number":1,"head":{"sha":"bbbbbbbbbb"}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.out = io.Discard
+
+	pr := &github.PullRequest{
+		Number:  github.Ptr(1),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Base:    &github.PullRequestBranch{Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}}},
+		Head:    &github.PullRequestBranch{SHA: github.Ptr("aaaaaaaaaa")},
+	}
+	_, err := g.ApprovePr(context.Background(), pr, ApproveOptions{ApproveOnly: true})
+	var hc *HeadChangedError
+	if !errors.As(err, &hc) || hc.Loaded != "aaaaaaaaaa" || hc.Current != "bbbbbbbbbb" {
+		t.Fatalf("ApprovePr error = %v, want a HeadChangedError", err)
+	}
+	if reviews != 0 {
+		t.Errorf("got %d reviews of a changed PR, want none", reviews)
+	}
+
+	if _, err := g.ApprovePr(context.Background(), pr, ApproveOptions{ApproveOnly: true, Force: true}); err != nil {
+		t.Fatalf("ApprovePr with Force: %v", err)
+	}
+	pr.Head.SHA = github.Ptr("bbbbbbbbbb")
+	if _, err := g.ApprovePr(context.Background(), pr, ApproveOptions{ApproveOnly: true}); err != nil {
+		t.Fatalf("ApprovePr at the current head: %v", err)
+	}
+	if reviews != 2 {
+		t.Errorf("got %d reviews, want 2", reviews)
+	}
+}
+
+func TestApprovePrDryRun(t *testing.T) {
+	head := "aaaaaaaaaa"
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/repos/o/r/pulls/1" {
+			t.Errorf("dry run sent %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"number":1,"head":{"sha":%q}}`, head)
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	var out strings.Builder
+	g.out = &out
+
+	pr := &github.PullRequest{
+		Number:  github.Ptr(1),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Base:    &github.PullRequestBranch{Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}}},
+		Head:    &github.PullRequestBranch{SHA: github.Ptr("aaaaaaaaaa")},
+	}
+	opts := ApproveOptions{UpdateBranch: true, MergeMethod: MergeMethodRebase, DryRun: true}
+	res, err := g.ApprovePr(context.Background(), pr, opts)
+	if err != nil || res.ReviewID != 0 || out.Len() != 0 {
+		t.Fatalf("ApprovePr = %+v, %v, output %q; want nothing done", res, err, out.String())
+	}
+	want := "[dry-run] Would approve PR https://github.com/o/r/pull/1; would first update branch if behind its base; would enable auto-merge (rebase), or rebase merge if that fails"
+	if got := opts.DryRunLine(pr.GetHTMLURL()); got != want {
+		t.Errorf("DryRunLine:\n%s\nwant:\n%s", got, want)
+	}
+
+	// a dry run still notices the head moved
+	head = "bbbbbbbbbb"
+	if _, err := g.ApprovePr(context.Background(), pr, opts); !errors.As(err, new(*HeadChangedError)) {
+		t.Errorf("ApprovePr error = %v, want a HeadChangedError", err)
+	}
+}
+
+func TestRequestChanges(t *testing.T) {
+	var review github.PullRequestReviewRequest
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/o/r/pulls/1/reviews" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&review)
+		fmt.Fprint(w, `{}`)
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	pr := &github.PullRequest{
+		Number:  github.Ptr(1),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Base: &github.PullRequestBranch{
+			Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}},
+		},
+	}
+	if err := g.RequestChanges(context.Background(), pr, ""); err != nil {
+		t.Fatalf("RequestChanges: %v", err)
+	}
+	if review.GetEvent() != "REQUEST_CHANGES" || review.GetBody() != DefaultDeclineComment {
+		t.Errorf("review = %s %q, want REQUEST_CHANGES with the default body", review.GetEvent(), review.GetBody())
+	}
+}
+
+func TestApprovePrMarkRead(t *testing.T) {
+	var marked []string
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/reviews"):
+			fmt.Fprint(w, `{"id":1}`)
+		case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/notifications/threads/"):
+			marked = append(marked, strings.TrimPrefix(r.URL.Path, "/notifications/threads/"))
+			w.WriteHeader(http.StatusResetContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.out = io.Discard
+
+	newPR := func(n int) *github.PullRequest {
+		return &github.PullRequest{
+			Number:  github.Ptr(n),
+			HTMLURL: github.Ptr(fmt.Sprintf("https://github.com/o/r/pull/%d", n)),
+			Base:    &github.PullRequestBranch{Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}}},
+		}
+	}
+	g.rememberThread("https://github.com/o/r/pull/1", "42")
+	opts := ApproveOptions{ApproveOnly: true, MarkRead: true}
+
+	res, err := g.ApprovePr(context.Background(), newPR(1), opts)
+	if err != nil || !res.Read {
+		t.Errorf("ApprovePr = %+v, %v; want the notification marked read", res, err)
+	}
+	// no notification is known for PR 2, so nothing is marked
+	if res, err := g.ApprovePr(context.Background(), newPR(2), opts); err != nil || res.Read {
+		t.Errorf("ApprovePr without a thread = %+v, %v; want nothing marked read", res, err)
+	}
+	if len(marked) != 1 || marked[0] != "42" {
+		t.Errorf("marked threads %v, want [42]", marked)
+	}
+}
+
+func TestMergeStates(t *testing.T) {
+	var fetched []string
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		fetched = append(fetched, r.URL.Path)
+		switch r.URL.Path {
+		case "/repos/o/r/pulls/1":
+			fmt.Fprint(w, `{"number":1,"state":"open","mergeable_state":"dirty","html_url":"https://github.com/o/r/pull/1"}`)
+		case "/repos/o/r/pulls/2":
+			fmt.Fprint(w, `{"number":2,"state":"open","mergeable_state":"unknown","html_url":"https://github.com/o/r/pull/2"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	g.concurrency = 1
+
+	pr := func(n int, state, mergeable string, merged bool) *github.PullRequest {
+		return &github.PullRequest{
+			Number:         github.Ptr(n),
+			State:          github.Ptr(state),
+			MergeableState: github.Ptr(mergeable),
+			Merged:         github.Ptr(merged),
+			HTMLURL:        github.Ptr(fmt.Sprintf("https://github.com/o/r/pull/%d", n)),
+			Base:           &github.PullRequestBranch{Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}}},
+		}
+	}
+	got := g.MergeStates(context.Background(), []*github.PullRequest{
+		pr(1, "open", "unknown", false),
+		pr(2, "open", "", false),
+		pr(3, "open", "unstable", false),
+		pr(4, "closed", "unknown", true),
+		pr(5, "closed", "dirty", false),
+		pr(6, "open", "blocked", false),
+	})
+	want := map[string]MergeState{
+		"https://github.com/o/r/pull/1": MergeStateConflicts,
+		"https://github.com/o/r/pull/3": MergeStateChecksFailing,
+		"https://github.com/o/r/pull/4": MergeStateMerged,
+		"https://github.com/o/r/pull/5": MergeStateClosed,
+		"https://github.com/o/r/pull/6": MergeStateOpen,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("MergeStates = %v, want %v", got, want)
+	}
+	for url, state := range want {
+		if got[url] != state {
+			t.Errorf("%s: %q, want %q", url, got[url], state)
+		}
+	}
+	// only the PRs whose state wasn't computed yet are fetched again
+	if len(fetched) != 2 {
+		t.Errorf("fetched %v, want only PRs 1 and 2", fetched)
+	}
+}
@@ -0,0 +1,136 @@
+package gh
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// noiseStripper is a named rule removing one bot's boilerplate from a PR
+// description.
+type noiseStripper struct {
+	name  string
+	strip func(string) string
+}
+
+var (
+	noiseMu sync.RWMutex
+	// noiseStrippers are applied in order by stripBotNoise.
+	noiseStrippers = []noiseStripper{
+		{"dependabot", removeDependabotTrailingCommand},
+		{"renovate", removeRenovateFooter},
+		{"bot-footer", removeBotFooter},
+	}
+)
+
+// RegisterNoiseStripper adds a rule, run after the built-in Dependabot,
+// Renovate and bot footer rules, that removes boilerplate from the PR
+// descriptions shown for review. name identifies it in logs and tests.
+func RegisterNoiseStripper(name string, strip func(string) string) {
+	noiseMu.Lock()
+	defer noiseMu.Unlock()
+	noiseStrippers = append(noiseStrippers, noiseStripper{name, strip})
+}
+
+// RegisterNoisePattern registers a stripper that cuts a PR description at
+// the first line matching re, e.g. a custom bot's "Posted by" footer.
+func RegisterNoisePattern(name string, re *regexp.Regexp) {
+	RegisterNoiseStripper(name, func(input string) string {
+		lines := strings.Split(input, "\n")
+		for i, l := range lines {
+			if re.MatchString(l) {
+				return cutLines(lines, i)
+			}
+		}
+		return input
+	})
+}
+
+// stripBotNoise runs input through every registered stripper in order.
+func stripBotNoise(input string) string {
+	noiseMu.RLock()
+	defer noiseMu.RUnlock()
+	for _, s := range noiseStrippers {
+		input = s.strip(input)
+	}
+	return input
+}
+
+// cutLines joins lines before i, dropping the blank lines and "---"
+// separators that led up to the removed block.
+func cutLines(lines []string, i int) string {
+	out := lines[:i]
+	for len(out) > 0 {
+		last := strings.TrimSpace(out[len(out)-1])
+		if last != "" && !isSeparator(last) {
+			break
+		}
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n")
+}
+
+// isSeparator reports whether a trimmed line is a markdown thematic break.
+func isSeparator(line string) bool {
+	return line == "---" || line == "***" || line == "___"
+}
+
+// removeRenovateFooter drops the "Configuration" section, the rebase/retry
+// checkbox and the "generated by Renovate" note that end Renovate PRs.
+func removeRenovateFooter(input string) string {
+	// a "Configuration" heading alone could be a human's, so only touch
+	// descriptions that mention Renovate or carry its checkbox
+	if low := strings.ToLower(input); !strings.Contains(low, "renovate") && !strings.Contains(low, "rebase/retry this pr") {
+		return input
+	}
+	lines := strings.Split(input, "\n")
+	for i, l := range lines {
+		low := strings.ToLower(strings.TrimSpace(l))
+		if strings.HasPrefix(low, "### configuration") ||
+			strings.Contains(low, "rebase/retry this pr") ||
+			(strings.Contains(low, "generated by") && strings.Contains(low, "renovate")) {
+			return cutLines(lines, i)
+		}
+	}
+	return input
+}
+
+// botFooterMaxLines bounds how long a footer removeBotFooter drops can be,
+// so a separator early in a human-written description is left alone.
+const botFooterMaxLines = 5
+
+// botFooterMarkers are phrases identifying a footer as bot-generated.
+var botFooterMarkers = []string{"generated by", "created by", "[bot]", "automated", "powered by"}
+
+// removeBotFooter drops a short footer after the last "---" separator when
+// it reads like a bot signature, e.g. "This PR was generated by Foo Bot".
+func removeBotFooter(input string) string {
+	lines := strings.Split(strings.TrimRight(input, " \t\r\n"), "\n")
+	sep := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if isSeparator(strings.TrimSpace(lines[i])) {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 {
+		return input
+	}
+	footer := lines[sep+1:]
+	nonBlank := 0
+	for _, l := range footer {
+		if strings.TrimSpace(l) != "" {
+			nonBlank++
+		}
+	}
+	if nonBlank == 0 || nonBlank > botFooterMaxLines {
+		return input
+	}
+	low := strings.ToLower(strings.Join(footer, "\n"))
+	for _, m := range botFooterMarkers {
+		if strings.Contains(low, m) {
+			return cutLines(lines, sep)
+		}
+	}
+	return input
+}
@@ -0,0 +1,85 @@
+package gh
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRemoveRenovateFooter(t *testing.T) {
+	input := `This PR contains the following updates:
+
+| Package | Change |
+|---|---|
+| actions/checkout | v4 -> v5 |
+
+---
+
+### Configuration
+
+📅 **Schedule**: Branch creation - At any time (no schedule defined).
+
+🚦 **Automerge**: Disabled by config.
+
+---
+
+ - [ ] If you want to rebase/retry this PR, check this box
+
+---
+
+This PR was generated by [Mend Renovate](https://mend.io/renovate/).
+`
+	want := "This PR contains the following updates:\n\n| Package | Change |\n|---|---|\n| actions/checkout | v4 -> v5 |"
+	if got := removeRenovateFooter(input); got != want {
+		t.Errorf("removeRenovateFooter:\ngot  %q\nwant %q", got, want)
+	}
+
+	// just the checkbox footer
+	input2 := "Bump x\n\n---\n\n - [ ] If you want to rebase/retry this PR, check this box\n"
+	if got := removeRenovateFooter(input2); got != "Bump x" {
+		t.Errorf("checkbox only: got %q", got)
+	}
+
+	// a human PR with a Configuration heading is not Renovate's
+	human := "Adds a section.\n\n### Configuration\n\nSet FOO=1."
+	if got := removeRenovateFooter(human); got != human {
+		t.Errorf("non-Renovate body changed: %q", got)
+	}
+}
+
+func TestRemoveBotFooter(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"bot footer", "Fix typo\n\n---\nThis PR was generated by Foo Bot.\n", "Fix typo"},
+		{"bot account", "Update deps\n\n***\n_Opened by release-bot[bot]_", "Update deps"},
+		{"human footer", "Fix typo\n\n---\nThanks for reviewing!", "Fix typo\n\n---\nThanks for reviewing!"},
+		{"no separator", "Generated by hand.", "Generated by hand."},
+		{"long section", "Intro\n---\ncreated by\n2\n3\n4\n5\n6", "Intro\n---\ncreated by\n2\n3\n4\n5\n6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := removeBotFooter(tt.input); got != tt.want {
+				t.Errorf("removeBotFooter = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterNoisePattern(t *testing.T) {
+	saved := noiseStrippers
+	defer func() { noiseStrippers = saved }()
+	noiseStrippers = append([]noiseStripper(nil), saved...)
+
+	RegisterNoisePattern("acme", regexp.MustCompile(`^Posted by ACME`))
+	got := stripBotNoise("Real change\n\nPosted by ACME deploy tool\nmore noise")
+	if got != "Real change" {
+		t.Errorf("stripBotNoise = %q, want %q", got, "Real change")
+	}
+}
+
+func TestCleanBotMessage(t *testing.T) {
+	input := "Bumps <b>lib</b> from 1 to 2.\n\n\n\nDetails.\n\nDependabot commands and options\n- `@dependabot rebase` will rebase this PR\n"
+	if got, want := cleanBotMessage(input), "Bumps lib from 1 to 2.\n\nDetails."; got != want {
+		t.Errorf("cleanBotMessage = %q, want %q", got, want)
+	}
+}
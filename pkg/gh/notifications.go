@@ -2,28 +2,98 @@ package gh
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v72/github"
 )
 
+// CONCURRENCY_LIMIT is the default number of PRs fetched in parallel.
 const CONCURRENCY_LIMIT = 10
 
+// maxNotificationPages bounds notification pagination so a misbehaving server
+// that keeps advertising a next page can't loop forever.
+const maxNotificationPages = 100
+
+// DefaultSince is how far back notifications are looked up when no explicit
+// window is configured.
+const DefaultSince = 72 * time.Hour
+
+// DefaultLoadDeadline bounds loading the whole review queue, after which
+// the PRs loaded so far are shown rather than waiting on a hung one.
+const DefaultLoadDeadline = 60 * time.Second
+
 // GhPrHashMap maps GitHub usernames to a map of hash strings to slices of Pull Requests
 type GhPrHashMap map[string]map[string][]*github.PullRequest
 
-// HashChangeMap maps hash strings to slices of change lines
-type HashChangeMap map[string][]string
+// Hunk is a single diff hunk together with where it was found.
+type Hunk struct {
+	File     string
+	OldStart int // first line in the old file, from the @@ header
+	NewStart int // first line in the new file, from the @@ header
+	// Lines holds the normalized +/- lines that make up the hash.
+	Lines []string
+	// Binary marks the synthetic hunk standing in for a binary file change,
+	// which has no @@ hunks; Lines then holds a single description of it.
+	Binary bool
+	// From is set on the synthetic hunk standing in for a rename (or a copy,
+	// with Copy) of From to File; Lines then holds a single description of
+	// it. Content changes to the renamed file are separate hunks.
+	From string
+	Copy bool
+}
+
+// Header describes the hunk's location, e.g. "main.go @@ -10 +12 @@", or
+// "logo.png (binary)" for a binary file change and "new.go (renamed from
+// old.go)" for a rename.
+func (h Hunk) Header() string {
+	switch {
+	case h.Binary:
+		return h.File + " (binary)"
+	case h.From != "" && h.Copy:
+		return fmt.Sprintf("%s (copied from %s)", h.File, h.From)
+	case h.From != "":
+		return fmt.Sprintf("%s (renamed from %s)", h.File, h.From)
+	}
+	return fmt.Sprintf("%s @@ -%d +%d @@", h.File, h.OldStart, h.NewStart)
+}
+
+// HashChangeMap maps hash strings to the hunk that produced them. When a hash
+// appears in several PRs, the first hunk seen is kept.
+type HashChangeMap map[string]Hunk
 
 // HashPrMap maps hash strings to slices of Pull Requests
 type HashPrMap map[string][]*github.PullRequest
 
+// PRsByURL indexes the PRs in m by HTML URL, for looking up the PR behind a
+// PrHashMap key.
+func (m HashPrMap) PRsByURL() map[string]*github.PullRequest {
+	prs := make(map[string]*github.PullRequest)
+	for _, list := range m {
+		for _, pr := range list {
+			prs[pr.GetHTMLURL()] = pr
+		}
+	}
+	return prs
+}
+
 // PrHashMap maps a PR identifier (HTML URL) to hashes associated with that PR
 type PrHashMap map[string][]string
 
 // PrVerifiedMap maps a PR identifier (HTML URL) to whether all its commits are verified (signed)
 type PrVerifiedMap map[string]bool
 
+// PrThreadMap maps a PR identifier (HTML URL) to the ID of the notification
+// thread that requested its review, for managing the notification.
+type PrThreadMap map[string]string
+
 // HashFileMap maps hash strings to a map of PR URL → filename, so the same
 // hash can track different file locations across different PRs/repos.
 type HashFileMap map[string]map[string]string
@@ -33,24 +103,342 @@ type HashFileMap map[string]map[string]string
 // surrounding context in the GUI.
 type HashRawChangeMap map[string][]string
 
-func (g *GhClient) getNotifications() ([]*github.Notification, error) {
+// HashCommitMap maps hash strings to the SHA of the commit that introduced
+// the hunk, for the hashes whose commit could be determined.
+type HashCommitMap map[string]string
+
+// FetchOptions controls which review requests GetPrReviewRequested collects.
+type FetchOptions struct {
+	// Since is the oldest notification update to consider. The zero value
+	// means DefaultSince ago.
+	Since time.Time
+	// IgnoreWhitespace hashes hunks without trailing whitespace and drops
+	// removed/added line pairs that only differ in indentation, so
+	// whitespace-only variations of a change share a hash.
+	IgnoreWhitespace bool
+	// IgnorePaths lists patterns of generated files whose hunks are left out
+	// of hashing and display (see DefaultIgnorePaths for the syntax).
+	IgnorePaths []string
+	// FailFast aborts the whole fetch on the first PR that fails to load
+	// instead of collecting it in FetchResult.Failures.
+	FailFast bool
+	// Repos limits the queue to repositories matching one of these
+	// "owner/name" or "owner/*" patterns (see ParseRepoFilter). Empty means
+	// every repository.
+	Repos []string
+	// BaseBranches limits the queue to PRs whose base branch matches one of
+	// these path.Match patterns, e.g. "main" or "release/*". Empty means
+	// every branch.
+	BaseBranches []string
+	// IncludeDrafts keeps draft PRs in the queue; by default they are left
+	// out until they are marked ready for review.
+	IncludeDrafts bool
+	// Source is where review requests are found; empty means
+	// SourceNotifications. Since only applies to notifications.
+	Source QueueSource
+	// IncludeTeamRequests also collects PRs requesting the review of one of
+	// your teams rather than yours, tagged in FetchResult.TeamMap. With
+	// SourceNotifications this considers every PR notification, not only
+	// review requests, costing a PR lookup each.
+	IncludeTeamRequests bool
+	// Reasons are the notification reasons (see NotificationReasons) whose
+	// PRs are collected, each tagged with them in FetchResult.ReasonMap.
+	// Empty means ReasonReviewRequested only. With SourceSearch only the
+	// reasons a search can express are supported.
+	Reasons []string
+	// Deadline bounds GetPrReviewRequested as a whole: when it passes, the
+	// PRs still loading are abandoned and counted in FetchResult.Unloaded.
+	// Zero means no deadline.
+	Deadline time.Duration
+	// PRTimeout bounds loading each PR, its diff and commits included; a PR
+	// that takes longer is recorded in FetchResult.Failures. Zero means no
+	// limit beyond the per-request timeout.
+	PRTimeout time.Duration
+
+	// authors, lowercased, limits the queue to PRs by these users; set by
+	// GetPrReviewRequestedForUser. Empty means every author.
+	authors []string
+}
+
+// Filter names used as FetchResult.Filtered keys, after the flags that set
+// them.
+const (
+	FilterRepo       = "--repo"
+	FilterBaseBranch = "--base-branch"
+	// FilterDrafts counts drafts, which are skipped unless --include-drafts.
+	FilterDrafts = "draft status (see --include-drafts)"
+)
+
+// DefaultIgnorePaths are lockfiles and vendored trees that tooling PRs churn
+// and nobody reviews line by line. A pattern without a slash matches a file's
+// base name, a pattern ending in a slash matches that directory anywhere in the
+// path, and anything else is matched against the full path with path.Match.
+var DefaultIgnorePaths = []string{
+	"go.sum",
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"Cargo.lock",
+	"Gemfile.lock",
+	"poetry.lock",
+	"composer.lock",
+	"vendor/",
+	"node_modules/",
+}
+
+// FetchResult is the review queue returned by GetPrReviewRequested.
+type FetchResult struct {
+	UserHashPrMap GhPrHashMap
+	ChangeMap     HashChangeMap
+	HashPrMap     HashPrMap
+	PrMap         PrHashMap
+	VerifiedMap   PrVerifiedMap
+	HashFileMap   HashFileMap
+	RawChangeMap  HashRawChangeMap
+	CommitMap     HashCommitMap
+	ThreadMap     PrThreadMap
+	// TeamMap tags the PRs whose review was only requested from one of your
+	// teams; set with FetchOptions.IncludeTeamRequests.
+	TeamMap PrTeamMap
+	// ReasonMap holds the notification reasons that brought each PR into
+	// the queue; see FetchOptions.Reasons.
+	ReasonMap PrReasonMap
+	// Failures maps a PR ("owner/repo#number") to the error that kept it out
+	// of the queue.
+	Failures map[string]error
+	// Filtered counts the PRs each filter (FilterRepo, ...) left out.
+	Filtered map[string]int
+	// Unloaded counts the PRs abandoned when FetchOptions.Deadline passed,
+	// leaving a partial queue.
+	Unloaded int
+}
+
+// addFiltered records a PR left out by filter.
+func (r *FetchResult) addFiltered(mu *sync.Mutex, filter string) {
+	mu.Lock()
+	defer mu.Unlock()
+	r.Filtered[filter]++
+}
+
+// addUnloaded records a PR abandoned because fetchCtx, the context bounded
+// by FetchOptions.Deadline, is done. A PR abandoned for another reason, such
+// as a fail-fast error, isn't counted.
+func (r *FetchResult) addUnloaded(mu *sync.Mutex, fetchCtx context.Context) {
+	if !errors.Is(fetchCtx.Err(), context.DeadlineExceeded) {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	r.Unloaded++
+}
+
+// FilterSummary describes how many PRs each filter left out, e.g.
+// "filtered out 3 PR(s) by --base-branch, 1 by --repo", or "" if none were.
+func (r *FetchResult) FilterSummary() string {
+	var filters []string
+	for f, n := range r.Filtered {
+		if n > 0 {
+			filters = append(filters, f)
+		}
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	sort.Strings(filters)
+	parts := make([]string, len(filters))
+	for i, f := range filters {
+		parts[i] = fmt.Sprintf("%d by %s", r.Filtered[f], f)
+	}
+	parts[0] = fmt.Sprintf("%d PR(s) by %s", r.Filtered[filters[0]], filters[0])
+	return "filtered out " + strings.Join(parts, ", ")
+}
+
+// FailureLines returns one sorted, human-readable line per failed PR.
+func (r *FetchResult) FailureLines() []string {
+	var lines []string
+	for pr, err := range r.Failures {
+		lines = append(lines, fmt.Sprintf("skipped %s: %v", pr, err))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// PartialWarning describes a queue cut short by FetchOptions.Deadline, or
+// returns "" for a complete one.
+func (r *FetchResult) PartialWarning() string {
+	if r.Unloaded == 0 {
+		return ""
+	}
+	return fmt.Sprintf("timed out, showing partial queue: %d PR(s) not loaded before the --load-deadline", r.Unloaded)
+}
+
+func (o FetchOptions) since() time.Time {
+	if o.Since.IsZero() {
+		return time.Now().Add(-DefaultSince)
+	}
+	return o.Since
+}
+
+func (o FetchOptions) reasons() []string {
+	if len(o.Reasons) == 0 {
+		return []string{ReasonReviewRequested}
+	}
+	return o.Reasons
+}
+
+func (o FetchOptions) source() QueueSource {
+	if o.Source == "" {
+		return SourceNotifications
+	}
+	return o.Source
+}
+
+// ParseRepoFilter validates --repo patterns, each "owner/name" or "owner/*"
+// for a whole org, and lowercases them for case-insensitive matching.
+func ParseRepoFilter(patterns []string) ([]string, error) {
+	var out []string
+	for _, raw := range patterns {
+		p := strings.ToLower(strings.TrimSpace(raw))
+		owner, name, ok := strings.Cut(p, "/")
+		if !ok || owner == "" || name == "" || strings.Contains(name, "/") || strings.Contains(owner, "*") {
+			return nil, fmt.Errorf("invalid --repo %q: want owner/name or owner/*", raw)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// ParseBaseBranchFilter validates --base-branch glob patterns.
+func ParseBaseBranchFilter(patterns []string) ([]string, error) {
+	var out []string
+	for _, raw := range patterns {
+		p := strings.TrimSpace(raw)
+		if _, err := path.Match(p, ""); p == "" || err != nil {
+			return nil, fmt.Errorf("invalid --base-branch %q: want a branch name or glob such as release/*", raw)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// includesBase reports whether a PR targeting base passes the BaseBranches
+// filter.
+func (o FetchOptions) includesBase(base string) bool {
+	if len(o.BaseBranches) == 0 {
+		return true
+	}
+	for _, p := range o.BaseBranches {
+		if ok, _ := path.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// includesAuthor reports whether a PR by login passes the authors filter.
+func (o FetchOptions) includesAuthor(login string) bool {
+	return len(o.authors) == 0 || slices.Contains(o.authors, strings.ToLower(login))
+}
+
+// includesRepo reports whether owner/repo passes the Repos filter.
+func (o FetchOptions) includesRepo(owner, repo string) bool {
+	if len(o.Repos) == 0 {
+		return true
+	}
+	owner, repo = strings.ToLower(owner), strings.ToLower(repo)
+	for _, p := range o.Repos {
+		pOwner, pName, _ := strings.Cut(p, "/")
+		if pOwner == owner && (pName == "*" || pName == repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSince parses a lookback window given either as a Go duration relative
+// to now (e.g. "168h") or as a date ("2006-01-02") or RFC 3339 timestamp.
+// An empty string yields the zero time, meaning the default window.
+func ParseSince(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("invalid --since %q: duration must be positive", s)
+		}
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: expected a duration like 168h or a date like 2006-01-02", s)
+}
+
+func (g *GhClient) getNotifications(ctx context.Context, since time.Time) ([]*github.Notification, error) {
 	var allNotifications []*github.Notification
 	opt := &github.NotificationListOptions{
 		All:         true,
-		Since:       time.Now().AddDate(0, 0, -3),
+		Since:       since,
 		ListOptions: github.ListOptions{PerPage: 50},
 	}
 
 	for page := 1; ; page++ {
+		if page > maxNotificationPages {
+			slog.Warn("stopped paging notifications", "pages", maxNotificationPages)
+			break
+		}
 		opt.Page = page
-		notifications, resp, err := g.c.Activity.ListNotifications(context.Background(), opt)
+		notifications, resp, err := g.c.Activity.ListNotifications(ctx, opt)
 		if err != nil {
 			return nil, err
 		}
 		allNotifications = append(allNotifications, notifications...)
-		if resp.NextPage == 0 {
+		if resp.NextPage == 0 || resp.NextPage <= page {
 			break
 		}
 	}
 	return allNotifications, nil
 }
+
+// MarkThreadRead marks the notification thread threadID read.
+func (g *GhClient) MarkThreadRead(ctx context.Context, threadID string) error {
+	if _, err := g.c.Activity.MarkThreadRead(ctx, threadID); err != nil {
+		return fmt.Errorf("failed to mark notification %s read: %w", threadID, err)
+	}
+	return nil
+}
+
+// rememberThread records threadID as the notification requesting a review
+// of the PR at prURL, for markPrRead.
+func (g *GhClient) rememberThread(prURL, threadID string) {
+	if threadID == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.threads == nil {
+		g.threads = map[string]string{}
+	}
+	g.threads[prURL] = threadID
+}
+
+// markPrRead marks the notification that requested a review of pr read,
+// reporting whether it did. A PR not found through GetPrReviewRequested on
+// this client has no known notification and is left alone.
+func (g *GhClient) markPrRead(ctx context.Context, pr *github.PullRequest) bool {
+	g.mu.Lock()
+	threadID, ok := g.threads[pr.GetHTMLURL()]
+	g.mu.Unlock()
+	if !ok {
+		slog.Debug("no review-request notification known, not marking it read", "pr", pr.GetHTMLURL())
+		return false
+	}
+	if err := g.MarkThreadRead(ctx, threadID); err != nil {
+		g.report(ctx, slog.LevelWarn, "PR %s: %v", pr.GetHTMLURL(), err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestParseSince(t *testing.T) {
+	before := time.Now()
+
+	got, err := ParseSince("168h")
+	if err != nil {
+		t.Fatalf("ParseSince(168h): %v", err)
+	}
+	if want := before.Add(-168 * time.Hour); got.Before(want.Add(-time.Second)) || got.After(time.Now().Add(-168*time.Hour)) {
+		t.Fatalf("ParseSince(168h) = %v, want about %v", got, want)
+	}
+
+	got, err = ParseSince("2025-01-31")
+	if err != nil {
+		t.Fatalf("ParseSince(date): %v", err)
+	}
+	if want := time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Fatalf("ParseSince(date) = %v, want %v", got, want)
+	}
+
+	if got, err := ParseSince(""); err != nil || !got.IsZero() {
+		t.Fatalf("ParseSince(\"\") = %v, %v; want zero time", got, err)
+	}
+
+	for _, bad := range []string{"yesterday", "-5h", "2025-13-01"} {
+		if _, err := ParseSince(bad); err == nil {
+			t.Errorf("ParseSince(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestParseRepoFilter(t *testing.T) {
+	got, err := ParseRepoFilter([]string{" Octo/Repo ", "acme/*"})
+	if err != nil {
+		t.Fatalf("ParseRepoFilter: %v", err)
+	}
+	if len(got) != 2 || got[0] != "octo/repo" || got[1] != "acme/*" {
+		t.Fatalf("ParseRepoFilter = %v, want [octo/repo acme/*]", got)
+	}
+	for _, bad := range []string{"repo", "/repo", "octo/", "a/b/c", "*/repo"} {
+		if _, err := ParseRepoFilter([]string{bad}); err == nil {
+			t.Errorf("ParseRepoFilter(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestParseBaseBranchFilter(t *testing.T) {
+	got, err := ParseBaseBranchFilter([]string{"main", " release/* "})
+	if err != nil || len(got) != 2 || got[1] != "release/*" {
+		t.Fatalf("ParseBaseBranchFilter = %v, %v; want [main release/*]", got, err)
+	}
+	for _, bad := range []string{"", "release/[", " "} {
+		if _, err := ParseBaseBranchFilter([]string{bad}); err == nil {
+			t.Errorf("ParseBaseBranchFilter(%q) succeeded, want error", bad)
+		}
+	}
+	fetch := FetchOptions{BaseBranches: got}
+	if !fetch.includesBase("release/1.2") || fetch.includesBase("release/1.2/hotfix") || fetch.includesBase("develop") {
+		t.Error("includesBase matched the wrong branches")
+	}
+}
+
+func TestFilterSummary(t *testing.T) {
+	res := &FetchResult{Filtered: map[string]int{FilterRepo: 1, FilterBaseBranch: 3, "--none": 0}}
+	if got, want := res.FilterSummary(), "filtered out 3 PR(s) by --base-branch, 1 by --repo"; got != want {
+		t.Errorf("FilterSummary = %q, want %q", got, want)
+	}
+	if got := (&FetchResult{}).FilterSummary(); got != "" {
+		t.Errorf("FilterSummary with nothing filtered = %q, want empty", got)
+	}
+}
+
+func TestIncludesRepo(t *testing.T) {
+	fetch := FetchOptions{Repos: []string{"octo/repo", "acme/*"}}
+	for _, tc := range []struct {
+		owner, repo string
+		want        bool
+	}{
+		{"octo", "repo", true},
+		{"Octo", "REPO", true},
+		{"octo", "other", false},
+		{"ACME", "anything", true},
+		{"acme-corp", "x", false},
+	} {
+		if got := fetch.includesRepo(tc.owner, tc.repo); got != tc.want {
+			t.Errorf("includesRepo(%s/%s) = %v, want %v", tc.owner, tc.repo, got, tc.want)
+		}
+	}
+	if !(FetchOptions{}).includesRepo("any", "repo") {
+		t.Error("empty filter excluded a repo")
+	}
+}
+
+func TestGetNotificationsKeepsSinceAcrossPages(t *testing.T) {
+	var sinces []string
+	var srv *httptest.Server
+	srv = newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		sinces = append(sinces, r.URL.Query().Get("since"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/notifications?page=%d>; rel="next"`, apiURL(srv), page+1))
+		}
+		fmt.Fprintf(w, `[{"id":"%d"}]`, page)
+	})
+	defer srv.Close()
+
+	g := newTestClient(t, srv)
+	since := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	n, err := g.getNotifications(context.Background(), since)
+	if err != nil {
+		t.Fatalf("getNotifications: %v", err)
+	}
+	if len(n) != 3 || len(sinces) != 3 {
+		t.Fatalf("got %d notifications over %d requests, want 3 over 3", len(n), len(sinces))
+	}
+	for i, s := range sinces {
+		if s != since.Format(time.RFC3339) {
+			t.Errorf("page %d since = %q, want %q", i+1, s, since.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestGetNotificationsStopsOnEndlessPaging(t *testing.T) {
+	requests := 0
+	var srv *httptest.Server
+	srv = newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		w.Header().Set("Link", fmt.Sprintf(`<%s/notifications?page=%d>; rel="next"`, apiURL(srv), page+1))
+		fmt.Fprint(w, `[]`)
+	})
+	defer srv.Close()
+
+	g := newTestClient(t, srv)
+	if _, err := g.getNotifications(context.Background(), time.Now()); err != nil {
+		t.Fatalf("getNotifications: %v", err)
+	}
+	if requests != maxNotificationPages {
+		t.Fatalf("made %d requests, want %d", requests, maxNotificationPages)
+	}
+}
+
+func TestPRsByURL(t *testing.T) {
+	pr1 := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/1")}
+	pr2 := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/2")}
+	prs := HashPrMap{"a": {pr1, pr2}, "b": {pr1}}.PRsByURL()
+	if len(prs) != 2 || prs[pr1.GetHTMLURL()] != pr1 || prs[pr2.GetHTMLURL()] != pr2 {
+		t.Errorf("PRsByURL = %v, want PRs 1 and 2 by URL", prs)
+	}
+}
@@ -2,13 +2,19 @@ package gh
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,61 +23,172 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-func (g *GhClient) getPrHash(pr *github.PullRequest) ([]string, map[string][]string, map[string]string, map[string][]string, error) {
-	diffURL := pr.GetURL()
-	req, err := http.NewRequest("GET", diffURL, nil)
+// fetchDiff returns the unified diff of pr, served from the diff cache when
+// the PR's head commit hasn't changed since it was last downloaded.
+func (g *GhClient) fetchDiff(ctx context.Context, pr *github.PullRequest) ([]byte, error) {
+	rc, err := g.openDiff(ctx, pr)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	return io.ReadAll(rc)
+}
+
+// GetRawDiff returns the unified diff of pr as GitHub renders it, for
+// tooling that wants to show or save it. It shares the retries, Enterprise
+// base URL and diff cache used for hashing.
+func (g *GhClient) GetRawDiff(ctx context.Context, pr *github.PullRequest) (string, error) {
+	diff, err := g.fetchDiff(ctx, pr)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch diff for PR %s: %w", pr.GetHTMLURL(), err)
+	}
+	return string(diff), nil
+}
+
+// openDiff streams the unified diff of pr, from the diff cache when the PR's
+// head commit hasn't changed since it was last downloaded. A download is
+// cached once it has been read to the end.
+func (g *GhClient) openDiff(ctx context.Context, pr *github.PullRequest) (io.ReadCloser, error) {
+	prURL, headSHA := pr.GetHTMLURL(), pr.GetHead().GetSHA()
+	if f, ok := g.openCachedDiff(prURL, headSHA); ok {
+		return f, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pr.GetURL(), nil)
+	if err != nil {
+		return nil, err
 	}
 	req.Header.Set("Accept", "application/vnd.github.diff")
 
-	resp, err := g.c.Client().Do(req)
+	resp, err := g.doWithRetry(req)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, fmt.Errorf("diff request returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if cache := g.newDiffCacheWriter(prURL, headSHA); cache != nil {
+		return &cachingBody{body: resp.Body, cache: cache}, nil
 	}
-	defer func() { _ = resp.Body.Close() }()
+	return resp.Body, nil
+}
 
-	diffBytes, err := io.ReadAll(resp.Body)
+func (g *GhClient) getPrHash(ctx context.Context, pr *github.PullRequest, fetch FetchOptions) ([]string, map[string]Hunk, map[string]string, map[string][]string, error) {
+	diff, err := g.openDiff(ctx, pr)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
-	diff := string(diffBytes)
+	defer func() { _ = diff.Close() }()
 
 	var hashes []string
 	var hunkLines []string
 	var rawHunkLines []string
 	inHunk := false
-	hunkMap := make(map[string][]string)
+	binary := false
+	oldStart, newStart := 0, 0
+	hunkMap := make(map[string]Hunk)
 	rawHunkMap := make(map[string][]string)
 	hashFileMap := make(map[string]string)
 	currentFile := ""
+	indexLine := ""   // "index <old>..<new>" blob IDs of the current file
+	renamedFrom := "" // old path while reading a rename or copy header
+	copied := false
 
 	flushHunk := func() {
-		if len(hunkLines) == 0 {
+		if len(hunkLines) == 0 || isIgnoredPath(currentFile, fetch.IgnorePaths) {
+			hunkLines = nil
+			rawHunkLines = nil
 			return
 		}
-		hash := sha256.Sum256([]byte(strings.Join(hunkLines, "\n")))
+		hashed := hunkLines
+		if fetch.IgnoreWhitespace && !binary && renamedFrom == "" {
+			// A whitespace-only hunk keeps its own lines: hashing nothing but
+			// the file path would share one hash across unrelated hunks.
+			if lines := ignoreWhitespaceChanges(hunkLines); len(lines) > 0 {
+				hashed = lines
+			}
+		}
+		// The file path is part of the hashed content so the same edit in two
+		// different files is reviewed as two separate changes.
+		hash := sha256.Sum256([]byte(currentFile + "\n" + strings.Join(hashed, "\n")))
 		h := hex.EncodeToString(hash[:])
 		hashes = append(hashes, h)
-		hunkMap[h] = append([]string(nil), hunkLines...)
+		hunkMap[h] = Hunk{
+			File:     currentFile,
+			OldStart: oldStart,
+			NewStart: newStart,
+			Lines:    append([]string(nil), hunkLines...),
+			Binary:   binary,
+			From:     renamedFrom,
+			Copy:     copied,
+		}
 		rawHunkMap[h] = append([]string(nil), rawHunkLines...)
 		hashFileMap[h] = currentFile
 		hunkLines = nil
 		rawHunkLines = nil
 	}
 
-	for _, line := range strings.Split(diff, "\n") {
+	sc := newDiffScanner(diff)
+	for sc.Scan() {
+		line := sc.Text()
 		if strings.HasPrefix(line, "diff --git ") {
 			flushHunk()
 			inHunk = false
 			if idx := strings.LastIndex(line, " b/"); idx >= 0 {
 				currentFile = line[idx+3:]
 			}
+			indexLine = ""
+			renamedFrom, copied = "", false
+			continue
+		}
+		if !inHunk && strings.HasPrefix(line, "index ") {
+			indexLine = line
+			continue
+		}
+		if !inHunk && strings.HasPrefix(line, "Binary files ") {
+			// binary files have no @@ hunks; stand in a single change so
+			// they still show up for review. The blob IDs from the index
+			// line tell different contents apart.
+			hunkLines = []string{binaryChangeLine(currentFile, indexLine)}
+			rawHunkLines = []string{line}
+			binary = true
+			oldStart, newStart = 0, 0
+			flushHunk()
+			binary = false
+			continue
+		}
+		if !inHunk && (strings.HasPrefix(line, "rename from ") || strings.HasPrefix(line, "copy from ")) {
+			copied = strings.HasPrefix(line, "copy ")
+			_, renamedFrom, _ = strings.Cut(line, " from ")
+			rawHunkLines = []string{line}
+			continue
+		}
+		if !inHunk && renamedFrom != "" && (strings.HasPrefix(line, "rename to ") || strings.HasPrefix(line, "copy to ")) {
+			// a rename is its own change, hashed from both paths, so a pure
+			// rename is reviewed rather than dropped for having no hunks
+			_, currentFile, _ = strings.Cut(line, " to ")
+			hunkLines = []string{renameChangeLine(renamedFrom, currentFile, copied)}
+			rawHunkLines = append(rawHunkLines, line)
+			oldStart, newStart = 0, 0
+			flushHunk()
+			renamedFrom, copied = "", false
+			continue
+		}
+		if !inHunk && strings.HasPrefix(line, "+++ ") {
+			// the +++ header is authoritative for the new path (quoting and
+			// spaces make the "diff --git" line ambiguous); /dev/null means
+			// the file was deleted, so keep the old name
+			if path, ok := strings.CutPrefix(line, "+++ b/"); ok {
+				currentFile = path
+			}
 			continue
 		}
 		if strings.HasPrefix(line, "@@") {
 			flushHunk()
 			inHunk = true
+			oldStart, newStart = parseHunkHeader(line)
 			continue
 		}
 		if inHunk {
@@ -85,99 +202,222 @@ func (g *GhClient) getPrHash(pr *github.PullRequest) ([]string, map[string][]str
 			}
 		}
 	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to read diff: %w", err)
+	}
 	flushHunk()
 
 	return hashes, hunkMap, hashFileMap, rawHunkMap, nil
 }
 
-func (g *GhClient) GetPrReviewRequested() (GhPrHashMap, HashChangeMap, HashPrMap, PrHashMap, PrVerifiedMap, HashFileMap, HashRawChangeMap, error) {
-	userHashPrMap := make(GhPrHashMap)
-	n, err := g.getNotifications()
+// GetPrReviewRequested collects every open PR the authenticated user has been
+// asked to review, found as fetch.Source says, hashing each diff hunk so
+// identical changes can be grouped.
+// Unless fetch.FailFast is set, a PR that fails to load is recorded in the
+// result's Failures and the rest of the queue is still returned. When
+// fetch.Deadline passes, the PRs loaded so far are returned as a partial
+// queue, counting the others in Unloaded. Canceling ctx stops the fetch and
+// returns ctx's error. With WithQueueSnapshot, a queue
+// saved by a recent run is returned instead of fetching it again.
+func (g *GhClient) GetPrReviewRequested(ctx context.Context, fetch FetchOptions) (*FetchResult, error) {
+	if res, ok := g.loadQueueSnapshot(ctx, fetch); ok {
+		return res, nil
+	}
+	fetchCtx := ctx
+	if fetch.Deadline > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, fetch.Deadline)
+		defer cancel()
+	}
+	reqs, err := g.listReviewRequests(fetchCtx, fetch)
 	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, err
+		return nil, err
 	}
 
-	hashChangeMap := make(map[string][]string)
-	hashRawChangeMap := make(HashRawChangeMap)
-	hashPrMap := make(HashPrMap)
-	prHashMap := make(PrHashMap)
-	prVerifiedMap := make(PrVerifiedMap)
-	hashFileMap := make(HashFileMap)
+	res := &FetchResult{
+		UserHashPrMap: make(GhPrHashMap),
+		ChangeMap:     make(HashChangeMap),
+		HashPrMap:     make(HashPrMap),
+		PrMap:         make(PrHashMap),
+		VerifiedMap:   make(PrVerifiedMap),
+		HashFileMap:   make(HashFileMap),
+		RawChangeMap:  make(HashRawChangeMap),
+		CommitMap:     make(HashCommitMap),
+		ThreadMap:     make(PrThreadMap),
+		TeamMap:       make(PrTeamMap),
+		ReasonMap:     make(PrReasonMap),
+		Failures:      make(map[string]error),
+		Filtered:      make(map[string]int),
+	}
 
 	mu := sync.Mutex{}
-	eg := new(errgroup.Group)
-	eg.SetLimit(CONCURRENCY_LIMIT)
+	eg, egCtx := errgroup.WithContext(fetchCtx)
+	eg.SetLimit(g.concurrency)
 
-	for _, notification := range n {
+	for _, req := range reqs {
 		eg.Go(func() error {
-			if notification.GetReason() != "review_requested" {
+			if egCtx.Err() != nil {
+				res.addUnloaded(&mu, fetchCtx)
 				return nil
 			}
-			url := notification.GetSubject().GetURL()
-			owner := notification.GetRepository().GetOwner().GetLogin()
-			repo := notification.GetRepository().GetName()
-			prNumber, err := strconv.Atoi(strings.Split(url, "/pulls/")[1])
-			if err != nil {
-				return fmt.Errorf("failed to parse PR number from %s: %w", url, err)
-			}
-			pr, _, err := g.c.PullRequests.Get(context.Background(), owner, repo, prNumber)
-			if err != nil {
-				return err
-			}
-			if pr == nil || pr.GetState() != "open" {
+			if !fetch.includesRepo(req.Owner, req.Repo) {
+				res.addFiltered(&mu, FilterRepo)
 				return nil
 			}
-			prUser := pr.GetUser().GetLogin()
-
-			prHash, localChangeMap, localFileMap, localRawChangeMap, err := g.getPrHash(pr)
-			if err != nil {
-				return err
-			}
-
-			verified := g.areCommitsVerified(owner, repo, pr.GetNumber())
-
-			mu.Lock()
-			if userHashPrMap[prUser] == nil {
-				userHashPrMap[prUser] = make(map[string][]*github.PullRequest)
+			prCtx := egCtx
+			if fetch.PRTimeout > 0 {
+				var cancel context.CancelFunc
+				prCtx, cancel = context.WithTimeout(egCtx, fetch.PRTimeout)
+				defer cancel()
 			}
-			prKey := pr.GetHTMLURL()
-			prVerifiedMap[prKey] = verified
-			for _, h := range prHash {
-				if !containsPR(userHashPrMap[prUser][h], prKey) {
-					userHashPrMap[prUser][h] = append(userHashPrMap[prUser][h], pr)
+			if err := g.collectPr(prCtx, req, fetch, res, &mu); err != nil {
+				if fetch.FailFast {
+					return err
 				}
-				if !containsPR(hashPrMap[h], prKey) {
-					hashPrMap[h] = append(hashPrMap[h], pr)
+				if egCtx.Err() != nil {
+					res.addUnloaded(&mu, fetchCtx)
+					return nil
 				}
-				if !containsString(prHashMap[prKey], h) {
-					prHashMap[prKey] = append(prHashMap[prKey], h)
+				if prCtx.Err() != nil {
+					err = fmt.Errorf("timed out after %s: %w", fetch.PRTimeout, err)
 				}
+				mu.Lock()
+				res.Failures[req.String()] = err
+				mu.Unlock()
 			}
-			for k, v := range localChangeMap {
-				if _, ok := hashChangeMap[k]; !ok {
-					hashChangeMap[k] = v
-				}
-			}
-			for h, file := range localFileMap {
-				if hashFileMap[h] == nil {
-					hashFileMap[h] = make(map[string]string)
-				}
-				hashFileMap[h][prKey] = file
-			}
-			for k, v := range localRawChangeMap {
-				if _, ok := hashRawChangeMap[k]; !ok {
-					hashRawChangeMap[k] = v
-				}
-			}
-			mu.Unlock()
 			return nil
 		})
 	}
 
 	if err := eg.Wait(); err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, err
+		return nil, err
 	}
-	return userHashPrMap, hashChangeMap, hashPrMap, prHashMap, prVerifiedMap, hashFileMap, hashRawChangeMap, nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// a partial queue isn't worth reusing
+	if res.Unloaded == 0 {
+		g.saveQueueSnapshot(ctx, fetch, res)
+	}
+	return res, nil
+}
+
+// GetPrReviewRequestedForUser is GetPrReviewRequested limited to PRs authored
+// by one of users (entries may be comma-separated), compared
+// case-insensitively. Other authors' PRs are dropped before their diff and
+// commits are fetched, so a single user's queue costs a fraction of the API
+// budget. Hashes they share with the kept PRs are therefore not linked to
+// them. Empty users means every author.
+func (g *GhClient) GetPrReviewRequestedForUser(ctx context.Context, users []string, fetch FetchOptions) (*FetchResult, error) {
+	fetch.authors = nil
+	for _, u := range users {
+		for _, token := range strings.Split(u, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				fetch.authors = append(fetch.authors, strings.ToLower(token))
+			}
+		}
+	}
+	slices.Sort(fetch.authors)
+	return g.GetPrReviewRequested(ctx, fetch)
+}
+
+// collectPr fetches the PR of req and its diff and merges them into res
+// under mu.
+func (g *GhClient) collectPr(ctx context.Context, req reviewRequest, fetch FetchOptions, res *FetchResult, mu *sync.Mutex) error {
+	owner, repo, prNumber, threadID := req.Owner, req.Repo, req.Number, req.threadID
+	pr, err := g.GetPR(ctx, owner, repo, prNumber)
+	if err != nil {
+		return err
+	}
+	if pr.GetState() != "open" {
+		return nil
+	}
+	var teams []string
+	if fetch.IncludeTeamRequests {
+		if teams, err = g.requestedTeams(ctx, owner, pr); err != nil {
+			return err
+		}
+		if req.team && len(teams) == 0 {
+			// a notification about the PR, but not a review request
+			return nil
+		}
+	}
+	if pr.GetDraft() && !fetch.IncludeDrafts {
+		res.addFiltered(mu, FilterDrafts)
+		return nil
+	}
+	if !fetch.includesBase(pr.GetBase().GetRef()) {
+		res.addFiltered(mu, FilterBaseBranch)
+		return nil
+	}
+	prUser := pr.GetUser().GetLogin()
+	if !fetch.includesAuthor(prUser) {
+		return nil
+	}
+
+	prHash, localChangeMap, localFileMap, localRawChangeMap, err := g.getPrHash(ctx, pr, fetch)
+	if err != nil {
+		return fmt.Errorf("failed to fetch diff for PR %s/%s#%d: %w", owner, repo, prNumber, err)
+	}
+
+	// commits only add metadata, so failing to list them doesn't fail the PR
+	commits, err := g.listPrCommits(ctx, owner, repo, pr.GetNumber())
+	if err != nil {
+		slog.Debug("failed to list PR commits", "pr", pr.GetHTMLURL(), "err", err)
+	}
+	verified := allCommitsVerified(commits)
+	localCommitMap := g.prAttribution(ctx, owner, repo, pr, commits, localChangeMap, localRawChangeMap)
+	g.rememberThread(pr.GetHTMLURL(), threadID)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if res.UserHashPrMap[prUser] == nil {
+		res.UserHashPrMap[prUser] = make(map[string][]*github.PullRequest)
+	}
+	prKey := pr.GetHTMLURL()
+	res.VerifiedMap[prKey] = verified
+	if threadID != "" {
+		res.ThreadMap[prKey] = threadID
+	}
+	if len(teams) > 0 {
+		res.TeamMap[prKey] = teams
+	}
+	if len(req.reasons) > 0 {
+		res.ReasonMap[prKey] = slices.Sorted(slices.Values(req.reasons))
+	}
+	for _, h := range prHash {
+		if !containsPR(res.UserHashPrMap[prUser][h], prKey) {
+			res.UserHashPrMap[prUser][h] = append(res.UserHashPrMap[prUser][h], pr)
+		}
+		if !containsPR(res.HashPrMap[h], prKey) {
+			res.HashPrMap[h] = append(res.HashPrMap[h], pr)
+		}
+		if !containsString(res.PrMap[prKey], h) {
+			res.PrMap[prKey] = append(res.PrMap[prKey], h)
+		}
+	}
+	for k, v := range localChangeMap {
+		if _, ok := res.ChangeMap[k]; !ok {
+			res.ChangeMap[k] = v
+		}
+	}
+	for h, file := range localFileMap {
+		if res.HashFileMap[h] == nil {
+			res.HashFileMap[h] = make(map[string]string)
+		}
+		res.HashFileMap[h][prKey] = file
+	}
+	for k, v := range localRawChangeMap {
+		if _, ok := res.RawChangeMap[k]; !ok {
+			res.RawChangeMap[k] = v
+		}
+	}
+	for k, v := range localCommitMap {
+		if _, ok := res.CommitMap[k]; !ok {
+			res.CommitMap[k] = v
+		}
+	}
+	return nil
 }
 
 func containsPR(prs []*github.PullRequest, url string) bool {
@@ -198,53 +438,141 @@ func containsString(ss []string, s string) bool {
 	return false
 }
 
-// areCommitsVerified checks whether all commits in a PR are verified (signed).
-func (g *GhClient) areCommitsVerified(owner, repo string, number int) bool {
-	commits, _, err := g.c.PullRequests.ListCommits(context.Background(), owner, repo, number, nil)
+// PRRef identifies a pull request by repository and number.
+type PRRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+func (r PRRef) String() string {
+	return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+}
+
+// ParsePRRef parses a PR given as "owner/repo#123" or as its URL, e.g.
+// "https://github.com/owner/repo/pull/123" (any host, for GitHub Enterprise).
+func ParsePRRef(s string) (PRRef, error) {
+	s = strings.TrimSpace(s)
+	invalid := fmt.Errorf("invalid PR %q: want owner/repo#123 or a PR URL", s)
+	var owner, repo, num string
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return PRRef{}, invalid
+		}
+		// owner/repo/pull/123, ignoring a trailing "/files" and the like
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) < 4 || parts[2] != "pull" {
+			return PRRef{}, invalid
+		}
+		owner, repo, num = parts[0], parts[1], parts[3]
+	} else {
+		fullName, n, ok := strings.Cut(s, "#")
+		if !ok {
+			return PRRef{}, invalid
+		}
+		num = n
+		if owner, repo, ok = strings.Cut(fullName, "/"); !ok || strings.Contains(repo, "/") {
+			return PRRef{}, invalid
+		}
+	}
+	n, err := strconv.Atoi(num)
+	if owner == "" || repo == "" || err != nil || n <= 0 {
+		return PRRef{}, invalid
+	}
+	return PRRef{Owner: owner, Repo: repo, Number: n}, nil
+}
+
+// GetPR fetches a single PR, whether or not a review was requested on it.
+func (g *GhClient) GetPR(ctx context.Context, owner, repo string, number int) (*github.PullRequest, error) {
+	pr, _, err := g.c.PullRequests.Get(ctx, owner, repo, number)
 	if err != nil {
-		return false
+		return nil, fmt.Errorf("failed to fetch PR %s/%s#%d: %w", owner, repo, number, err)
 	}
-	for _, c := range commits {
-		if c.GetCommit().GetVerification().GetVerified() != true {
-			return false
-		}
+	if pr == nil {
+		return nil, fmt.Errorf("PR %s/%s#%d not found", owner, repo, number)
+	}
+	return pr, nil
+}
+
+// checkHead returns a *HeadChangedError if pr's head commit on GitHub is no
+// longer the one pr was loaded at. A PR loaded without its head SHA can't be
+// compared and passes.
+func (g *GhClient) checkHead(ctx context.Context, owner, repo string, pr *github.PullRequest) error {
+	loaded := pr.GetHead().GetSHA()
+	if loaded == "" {
+		return nil
+	}
+	current, err := g.GetPR(ctx, owner, repo, pr.GetNumber())
+	if err != nil {
+		return err
+	}
+	if sha := current.GetHead().GetSHA(); sha != loaded {
+		return &HeadChangedError{PR: pr.GetHTMLURL(), Loaded: loaded, Current: sha}
 	}
-	return len(commits) > 0
+	return nil
 }
 
-func (g *GhClient) ApprovePr(pr *github.PullRequest, reviewBody string) error {
+// ApprovePr approves pr with an APPROVE review, first updating its branch if
+// opts.UpdateBranch is set and it is behind its base, and then, unless
+// opts.ApproveOnly is set, enables auto-merge with the configured merge
+// method, merging immediately at the reviewed head commit (or the one
+// updating the branch made) if auto-merge can't be enabled. Unless
+// opts.Force is set, pr is re-fetched first and a *HeadChangedError returned
+// if its head commit moved since pr was loaded. With opts.DryRun it stops
+// after that check, leaving the caller to report opts.DryRunLine. The result
+// describes what was done, also when an error stops it partway.
+func (g *GhClient) ApprovePr(ctx context.Context, pr *github.PullRequest, opts ApproveOptions) (ApproveResult, error) {
+	res := ApproveResult{PR: pr.GetHTMLURL()}
 	if pr == nil {
-		return fmt.Errorf("nil PR")
+		return res, fmt.Errorf("nil PR")
 	}
 
 	base := pr.GetBase()
 	if base == nil || base.GetRepo() == nil || base.GetRepo().GetOwner() == nil {
-		return fmt.Errorf("unable to determine owner/repo for PR %s", pr.GetHTMLURL())
+		return res, fmt.Errorf("unable to determine owner/repo for PR %s", pr.GetHTMLURL())
 	}
 	owner := base.GetRepo().GetOwner().GetLogin()
 	repo := base.GetRepo().GetName()
 	number := pr.GetNumber()
 
-	// 1) Try to update the branch (rebase) using the REST endpoint
+	if !opts.Force {
+		if err := g.checkHead(ctx, owner, repo, pr); err != nil {
+			return res, err
+		}
+	}
+	if opts.DryRun {
+		return res, nil
+	}
+
+	// headSHA is the commit the PR is merged at, so nothing pushed after the
+	// review gets merged along with it.
+	headSHA := pr.GetHead().GetSHA()
+
+	// 1) If asked to, update the branch (rebase) using the REST endpoint.
 	// Only attempt to update the branch if the head is behind the base branch.
-	baseRef := base.GetRef()
-	headRef := pr.GetHead().GetRef()
-	if baseRef == "" || headRef == "" {
-		fmt.Printf("warning: unable to determine refs for PR %s, skipping update-branch\n", pr.GetHTMLURL())
-	} else {
-		behind, err := g.isBranchBehind(owner, repo, baseRef, headRef)
-		if err != nil {
-			fmt.Printf("warning: failed to check branch status for PR %s: %v\n", pr.GetHTMLURL(), err)
-		} else if behind {
-			if err := g.tryUpdateBranch(owner, repo, number); err != nil {
-				// TODO: this doesn't work, no idea why rebasing via API is so broken,
-				// but we should detect if we _need_ to rebase first before trying, and
-				// if it fails, we should return errors properly.
-				fmt.Printf("warning: failed to update branch for PR %s: %v\n", pr.GetHTMLURL(), err)
-				//return err
-			}
+	if opts.UpdateBranch {
+		baseRef := base.GetRef()
+		headRef := pr.GetHead().GetRef()
+		if baseRef == "" || headRef == "" {
+			g.report(ctx, slog.LevelWarn, "unable to determine refs for PR %s, skipping update-branch", pr.GetHTMLURL())
 		} else {
-			fmt.Printf("branch for PR %s is up-to-date with base (%s), skipping update-branch\n", pr.GetHTMLURL(), baseRef)
+			behind, err := g.isBranchBehind(ctx, owner, repo, baseRef, headRef)
+			if err != nil {
+				g.report(ctx, slog.LevelWarn, "failed to check branch status for PR %s: %v", pr.GetHTMLURL(), err)
+			} else if behind {
+				if sha, err := g.tryUpdateBranch(ctx, owner, repo, number, headSHA); err != nil {
+					// TODO: this doesn't work, no idea why rebasing via API is so broken,
+					// but we should detect if we _need_ to rebase first before trying, and
+					// if it fails, we should return errors properly.
+					g.report(ctx, slog.LevelWarn, "failed to update branch for PR %s: %v", pr.GetHTMLURL(), err)
+					//return err
+				} else if sha != "" {
+					headSHA = sha
+				}
+			} else {
+				g.report(ctx, slog.LevelInfo, "branch for PR %s is up-to-date with base (%s), skipping update-branch", pr.GetHTMLURL(), baseRef)
+			}
 		}
 	}
 
@@ -253,54 +581,97 @@ func (g *GhClient) ApprovePr(pr *github.PullRequest, reviewBody string) error {
 	review := &github.PullRequestReviewRequest{
 		Event: &approveEvent,
 	}
-	if reviewBody != "" {
-		review.Body = &reviewBody
+	if opts.Comment != "" {
+		review.Body = &opts.Comment
 	}
-	_, _, revErr := g.c.PullRequests.CreateReview(context.Background(), owner, repo, number, review)
+	created, _, revErr := g.c.PullRequests.CreateReview(ctx, owner, repo, number, review)
 	if revErr != nil {
-		return fmt.Errorf("failed to create approval for PR %s: %w", pr.GetHTMLURL(), revErr)
+		return res, fmt.Errorf("failed to create approval for PR %s: %w", pr.GetHTMLURL(), revErr)
+	}
+	res.ReviewID, res.ReviewURL = created.GetID(), created.GetHTMLURL()
+	g.invalidateQueueSnapshots()
+	g.mu.Lock()
+	if g.reviews == nil {
+		g.reviews = map[string]int64{}
+	}
+	g.reviews[pr.GetHTMLURL()] = created.GetID()
+	g.mu.Unlock()
+	if opts.MarkRead {
+		res.Read = g.markPrRead(ctx, pr)
+	}
+	if opts.ApproveOnly {
+		return res, nil
 	}
 
 	// 3) Enable auto-merge for the PR using GraphQL mutation
 	// Use the enablePullRequestAutoMerge mutation (requires PR node ID)
 	nodeID := pr.GetNodeID()
 	if nodeID == "" {
-		return fmt.Errorf("PR %s has no node ID, cant enable auto-merge", pr.GetHTMLURL())
+		return res, fmt.Errorf("PR %s has no node ID, cant enable auto-merge", pr.GetHTMLURL())
 	} else {
-		if err := g.tryEnableAutoMerge(nodeID, pr); err != nil {
-			fmt.Printf("warning: enabling auto-merge failed for PR %s: %v; attempting squash merge\n", pr.GetHTMLURL(), err)
-			if mergeErr := g.trySquashMerge(owner, repo, number, pr); mergeErr != nil {
-				return fmt.Errorf("squash merge failed for PR %s: %v; original auto-merge error: %w", pr.GetHTMLURL(), mergeErr, err)
+		if err := g.tryEnableAutoMerge(ctx, nodeID, pr, opts.MergeMethod); err != nil {
+			g.report(ctx, slog.LevelWarn, "enabling auto-merge failed for PR %s: %v; attempting %s merge", pr.GetHTMLURL(), err, opts.MergeMethod.orDefault())
+			if mergeErr := g.tryMerge(ctx, owner, repo, number, pr, headSHA, opts.MergeMethod); mergeErr != nil {
+				return res, fmt.Errorf("%s merge failed for PR %s: %v; original auto-merge error: %w", opts.MergeMethod.orDefault(), pr.GetHTMLURL(), mergeErr, err)
 			}
+			res.Merged = true
+		} else {
+			res.AutoMerge = true
 		}
 	}
 
+	return res, nil
+}
+
+// RequestChanges submits a REQUEST_CHANGES review on pr. GitHub rejects such
+// reviews without a body, so an empty body is replaced by DefaultDeclineComment.
+func (g *GhClient) RequestChanges(ctx context.Context, pr *github.PullRequest, body string) error {
+	if pr == nil {
+		return fmt.Errorf("nil PR")
+	}
+	base := pr.GetBase()
+	if base == nil || base.GetRepo() == nil || base.GetRepo().GetOwner() == nil {
+		return fmt.Errorf("unable to determine owner/repo for PR %s", pr.GetHTMLURL())
+	}
+	if body == "" {
+		body = DefaultDeclineComment
+	}
+	event := "REQUEST_CHANGES"
+	review := &github.PullRequestReviewRequest{
+		Event: &event,
+		Body:  &body,
+	}
+	_, _, err := g.c.PullRequests.CreateReview(ctx, base.GetRepo().GetOwner().GetLogin(), base.GetRepo().GetName(), pr.GetNumber(), review)
+	if err != nil {
+		return fmt.Errorf("failed to request changes on PR %s: %w", pr.GetHTMLURL(), err)
+	}
+	g.invalidateQueueSnapshots()
 	return nil
 }
 
 // tryEnableAutoMerge attempts to enable auto-merge for the given PR using GraphQL.
 // It returns nil on success or an error describing the failure so callers can
 // decide on fallback behavior.
-func (g *GhClient) tryEnableAutoMerge(nodeID string, pr *github.PullRequest) error {
-	graphqlURL := "https://api.github.com/graphql"
+func (g *GhClient) tryEnableAutoMerge(ctx context.Context, nodeID string, pr *github.PullRequest, method MergeMethod) error {
+	graphqlURL := g.graphqlURL()
 	mutation := `mutation EnableAutoMerge($pullId:ID!, $mergeMethod:PullRequestMergeMethod!) { enablePullRequestAutoMerge(input:{pullRequestId:$pullId, mergeMethod:$mergeMethod}) { pullRequest { id } } }`
 	vars := map[string]any{
 		"pullId":      nodeID,
-		"mergeMethod": "SQUASH",
+		"mergeMethod": method.graphQL(),
 	}
 	payload := map[string]any{
 		"query":     mutation,
 		"variables": vars,
 	}
 	bodyBytes, _ := json.Marshal(payload)
-	reqGQL, err := http.NewRequest("POST", graphqlURL, bytes.NewReader(bodyBytes))
+	reqGQL, err := http.NewRequestWithContext(ctx, "POST", graphqlURL, bytes.NewReader(bodyBytes))
 	if err != nil {
 		return fmt.Errorf("failed to create GraphQL request for PR %s: %w", pr.GetHTMLURL(), err)
 	}
 	reqGQL.Header.Set("Accept", "application/vnd.github+json")
 	reqGQL.Header.Set("Content-Type", "application/json")
 	reqGQL.Header.Set("Authorization", "Bearer "+g.token)
-	respGQL, err := g.c.Client().Do(reqGQL)
+	respGQL, err := g.doWithRetry(reqGQL)
 	if err != nil {
 		return fmt.Errorf("GraphQL request failed for PR %s: %w", pr.GetHTMLURL(), err)
 	}
@@ -317,30 +688,169 @@ func (g *GhClient) tryEnableAutoMerge(nodeID string, pr *github.PullRequest) err
 	if err := json.Unmarshal(body, &gqlResp); err != nil {
 		return fmt.Errorf("failed to decode GraphQL response for PR %s: %w", pr.GetHTMLURL(), err)
 	} else if len(gqlResp.Errors) > 0 {
+		for _, e := range gqlResp.Errors {
+			if msg, _ := e["message"].(string); isMergeMethodNotAllowed(msg) {
+				return errMergeMethodNotAllowed(pr, method)
+			}
+		}
 		return fmt.Errorf("GraphQL returned errors for PR %s: %v", pr.GetHTMLURL(), gqlResp.Errors)
 	}
-	fmt.Printf("enabled auto-merge (GraphQL) for PR %s\n", pr.GetHTMLURL())
+	g.report(ctx, slog.LevelInfo, "enabled auto-merge (GraphQL) for PR %s", pr.GetHTMLURL())
 	return nil
 }
 
-func (g *GhClient) GetPrComment(pr *github.PullRequest) (string, error) {
+// GetPrComment returns the cleaned-up PR description used as the approval
+// review body.
+func (g *GhClient) GetPrComment(ctx context.Context, pr *github.PullRequest) (string, error) {
 	if pr == nil {
 		return "", fmt.Errorf("nil PR")
 	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 
 	// Prefer the PR description/body if it's present
 	if body := strings.TrimSpace(pr.GetBody()); body != "" {
-		return cleanDependabotMessage(body), nil
+		return cleanBotMessage(body), nil
 	}
 	// No comment found
 	return "", fmt.Errorf("no comment/body found for PR %s", pr.GetHTMLURL())
 }
 
-func (g *GhClient) PrintChangesPerUser(users []string) {
-	userHashPrMap, hashChangeMap, _, prHashMap, _, _, _, err := g.GetPrReviewRequested()
+// GetPrCommentOrDiscussion is GetPrComment falling back, when the PR has no
+// description, to its most recent issue comment and then to its first review
+// comment, so PRs whose context lives in the conversation still show some.
+// Unlike GetPrComment it may call the API.
+func (g *GhClient) GetPrCommentOrDiscussion(ctx context.Context, pr *github.PullRequest) (string, error) {
+	body, err := g.GetPrComment(ctx, pr)
+	if err == nil || pr == nil || ctx.Err() != nil {
+		return body, err
+	}
+	owner, repo, err := prRepo(pr)
 	if err != nil {
-		fmt.Printf("Error fetching PR review requests: %v\n", err)
-		return
+		return "", err
+	}
+
+	// issue comments are listed oldest first, so keep the last non-blank one
+	var latest string
+	opt := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := g.c.Issues.ListComments(ctx, owner, repo, pr.GetNumber(), opt)
+		if err != nil {
+			return "", fmt.Errorf("failed to list comments for PR %s: %w", pr.GetHTMLURL(), err)
+		}
+		for _, c := range comments {
+			if b := strings.TrimSpace(c.GetBody()); b != "" {
+				latest = b
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	if latest != "" {
+		return cleanBotMessage(latest), nil
+	}
+
+	reviewComments, _, err := g.c.PullRequests.ListComments(ctx, owner, repo, pr.GetNumber(), &github.PullRequestListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}})
+	if err != nil {
+		return "", fmt.Errorf("failed to list review comments for PR %s: %w", pr.GetHTMLURL(), err)
+	}
+	for _, c := range reviewComments {
+		if b := strings.TrimSpace(c.GetBody()); b != "" {
+			return cleanBotMessage(b), nil
+		}
+	}
+	return "", fmt.Errorf("no body or comments found for PR %s", pr.GetHTMLURL())
+}
+
+// PRSort orders the PRs listed by PrintChangesPerUser.
+type PRSort string
+
+const (
+	// PRSortName orders PRs by title.
+	PRSortName PRSort = "name"
+	// PRSortRepo orders PRs by repository and number.
+	PRSortRepo PRSort = "repo"
+	// PRSortUpdated lists the most recently updated PRs first.
+	PRSortUpdated PRSort = "updated"
+	// PRSortCreated lists the oldest PRs, waiting the longest, first.
+	PRSortCreated PRSort = "created"
+)
+
+// ParsePRSort validates a --sort value for the PR listing; empty selects
+// PRSortRepo.
+func ParsePRSort(s string) (PRSort, error) {
+	switch ps := PRSort(strings.ToLower(strings.TrimSpace(s))); ps {
+	case "":
+		return PRSortRepo, nil
+	case PRSortName, PRSortRepo, PRSortUpdated, PRSortCreated:
+		return ps, nil
+	default:
+		return "", fmt.Errorf("invalid sort %q: want name, repo, updated or created", s)
+	}
+}
+
+// compare orders a before b per s, falling back to repository and number so
+// the order is total.
+func (s PRSort) compare(a, b *github.PullRequest) int {
+	var c int
+	switch s {
+	case PRSortName:
+		c = strings.Compare(strings.ToLower(a.GetTitle()), strings.ToLower(b.GetTitle()))
+	case PRSortUpdated:
+		c = b.GetUpdatedAt().Compare(a.GetUpdatedAt().Time)
+	case PRSortCreated:
+		c = a.GetCreatedAt().Compare(b.GetCreatedAt().Time)
+	}
+	if c != 0 {
+		return c
+	}
+	return cmp.Or(
+		strings.Compare(a.GetBase().GetRepo().GetFullName(), b.GetBase().GetRepo().GetFullName()),
+		cmp.Compare(a.GetNumber(), b.GetNumber()),
+		strings.Compare(a.GetHTMLURL(), b.GetHTMLURL()),
+	)
+}
+
+// sortedHashes returns the hashes of hashMap with their PRs sorted per
+// sortBy, ordered by their first PR and then by hash.
+func sortedHashes(hashMap map[string][]*github.PullRequest, sortBy PRSort) []string {
+	hashes := slices.Sorted(maps.Keys(hashMap))
+	for _, h := range hashes {
+		slices.SortStableFunc(hashMap[h], sortBy.compare)
+	}
+	slices.SortStableFunc(hashes, func(a, b string) int {
+		pa, pb := hashMap[a], hashMap[b]
+		if len(pa) == 0 || len(pb) == 0 {
+			return cmp.Compare(len(pb), len(pa))
+		}
+		return sortBy.compare(pa[0], pb[0])
+	})
+	return hashes
+}
+
+// PrintChangesPerUser prints, for each user in users (or everyone if empty)
+// in alphabetical order, their hashes and PRs ordered per sortBy. With
+// dedupe, each hash is printed once with the users and PRs it appears in
+// instead. With ViewFiles, each PR is printed with the files it changes
+// rather than its hashes, and dedupe doesn't apply. It returns the queue it
+// printed, for callers that go on to act on it.
+func (g *GhClient) PrintChangesPerUser(ctx context.Context, users []string, sortBy PRSort, dedupe bool, view ChangeView, fetch FetchOptions) (*FetchResult, error) {
+	res, err := g.GetPrReviewRequestedForUser(ctx, users, fetch)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching PR review requests: %w", err)
+	}
+	userHashPrMap, hashChangeMap, prHashMap := res.UserHashPrMap, res.ChangeMap, res.PrMap
+	if warning := res.PartialWarning(); warning != "" {
+		slog.Warn(warning)
+	}
+	for _, line := range res.FailureLines() {
+		slog.Warn(line)
+	}
+	if summary := res.FilterSummary(); summary != "" {
+		slog.Info(summary)
 	}
 
 	// normalize and dedupe requested users into a lookup map (lowercase)
@@ -358,21 +868,38 @@ func (g *GhClient) PrintChangesPerUser(users []string) {
 		}
 	}
 
-	for user, hashMap := range userHashPrMap {
-		lowerUser := strings.ToLower(user)
+	var listed []string
+	for _, user := range slices.Sorted(maps.Keys(userHashPrMap)) {
 		// if filter provided, skip users not in the filter
 		if len(filter) > 0 {
-			if _, ok := filter[lowerUser]; !ok {
+			if _, ok := filter[strings.ToLower(user)]; !ok {
 				continue
 			}
 		}
+		listed = append(listed, user)
+	}
+	if view == ViewFiles {
+		var prs []*github.PullRequest
+		for _, user := range listed {
+			prs = append(prs, userPRs(res, user, sortBy)...)
+		}
+		printChangesByFile(os.Stdout, listed, res, g.PrFiles(ctx, prs), sortBy)
+		return res, nil
+	}
+	if dedupe {
+		printChangesDeduped(os.Stdout, listed, res, sortBy)
+		return res, nil
+	}
 
+	for _, user := range listed {
+		hashMap := userHashPrMap[user]
 		fmt.Printf("User: %s\n", user)
-		for hash, prs := range hashMap {
+		for _, hash := range sortedHashes(hashMap, sortBy) {
+			prs := hashMap[hash]
 			fmt.Printf("  Hash: %s\n", hash)
-			if changes, ok := hashChangeMap[hash]; ok {
-				fmt.Println("    Changes:")
-				for _, line := range changes {
+			if hunk, ok := hashChangeMap[hash]; ok {
+				fmt.Printf("    Changes (%s):\n", hunk.Header())
+				for _, line := range hunk.Lines {
 					fmt.Printf("      %s\n", line)
 				}
 			} else {
@@ -394,9 +921,9 @@ func (g *GhClient) PrintChangesPerUser(users []string) {
 						fmt.Printf("    Additional hashes linked in PR %s:\n", prKey)
 						for _, ah := range extras {
 							fmt.Printf("      %s\n", ah)
-							if changes2, ok2 := hashChangeMap[ah]; ok2 {
-								fmt.Println("        Changes:")
-								for _, line := range changes2 {
+							if hunk2, ok2 := hashChangeMap[ah]; ok2 {
+								fmt.Printf("        Changes (%s):\n", hunk2.Header())
+								for _, line := range hunk2.Lines {
 									fmt.Printf("          %s\n", line)
 								}
 							} else {
@@ -408,26 +935,97 @@ func (g *GhClient) PrintChangesPerUser(users []string) {
 			}
 		}
 	}
+	return res, nil
+}
+
+// printChangesDeduped prints to w each hash of users once, with its changes
+// and then every user and PR it appears in. Like manual approval's firstSeen
+// tracking, a hash is listed where it is first seen going through users in
+// order, with each user's hashes ordered per sortBy.
+func printChangesDeduped(w io.Writer, users []string, res *FetchResult, sortBy PRSort) {
+	type seenIn struct {
+		user string
+		prs  []*github.PullRequest
+	}
+	firstSeen := map[string]string{}
+	var order []string
+	in := map[string][]seenIn{}
+	for _, user := range users {
+		hashMap := res.UserHashPrMap[user]
+		for _, hash := range sortedHashes(hashMap, sortBy) {
+			if _, seen := firstSeen[hash]; !seen {
+				firstSeen[hash] = user
+				order = append(order, hash)
+			}
+			in[hash] = append(in[hash], seenIn{user: user, prs: hashMap[hash]})
+		}
+	}
+
+	fmt.Fprintf(w, "%d unique hashes across %d users\n", len(order), len(users))
+	for _, hash := range order {
+		fmt.Fprintf(w, "Hash: %s\n", hash)
+		if hunk, ok := res.ChangeMap[hash]; ok {
+			fmt.Fprintf(w, "  Changes (%s):\n", hunk.Header())
+			for _, line := range hunk.Lines {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		} else {
+			fmt.Fprintln(w, "  No changes found for this hash.")
+		}
+		fmt.Fprintln(w, "  Found in:")
+		for _, s := range in[hash] {
+			for _, pr := range s.prs {
+				fmt.Fprintf(w, "    %s: %s\n", s.user, pr.GetHTMLURL())
+			}
+		}
+	}
 }
 
-// trySquashMerge attempts to immediately squash-merge the given PR.
-// Returns nil on success or an error describing the failure.
-func (g *GhClient) trySquashMerge(owner, repo string, number int, pr *github.PullRequest) error {
+// tryMerge attempts to immediately merge the given PR with method, at head
+// commit sha unless it is empty, so GitHub refuses if the head moved. Since
+// GitHub computes mergeability in the background, typically just after a PR
+// was approved or updated, it first waits for it to be known, and retries
+// merges GitHub rejects as not (yet) mergeable a few times. Returns nil on
+// success, an error wrapping ErrMergeConflicts for a conflicting PR, or an
+// error describing the failure.
+func (g *GhClient) tryMerge(ctx context.Context, owner, repo string, number int, pr *github.PullRequest, sha string, method MergeMethod) error {
 	commitMessage := fmt.Sprintf("Squash merge PR #%d: %s", number, pr.GetTitle())
+	if method.orDefault() != MergeMethodSquash {
+		// only squash merges take their message from us
+		commitMessage = ""
+	}
 	opt := &github.PullRequestOptions{
-		MergeMethod: "squash",
+		MergeMethod: method.rest(),
 		CommitTitle: "",
+		SHA:         sha,
 	}
-	_, _, err := g.c.PullRequests.Merge(
-		context.Background(),
-		owner,
-		repo,
-		number,
-		commitMessage,
-		opt)
+	for attempt := 1; ; attempt++ {
+		switch g.awaitMergeState(ctx, owner, repo, number) {
+		case MergeStateConflicts:
+			return fmt.Errorf("can't merge PR %s: %w; resolve them and merge it by hand", pr.GetHTMLURL(), ErrMergeConflicts)
+		case MergeStateMerged:
+			return nil
+		}
+		_, _, err := g.c.PullRequests.Merge(
+			ctx,
+			owner,
+			repo,
+			number,
+			commitMessage,
+			opt)
 
-	if err != nil {
-		return fmt.Errorf("merge failed for PR %s: %w", pr.GetHTMLURL(), err)
+		if isRESTMergeMethodNotAllowed(err) {
+			return errMergeMethodNotAllowed(pr, method)
+		}
+		if err == nil {
+			return nil
+		}
+		if attempt >= mergeAttempts || !isTransientMergeError(err) {
+			return fmt.Errorf("merge failed for PR %s: %w", pr.GetHTMLURL(), err)
+		}
+		slog.Debug("retrying merge", "pr", pr.GetHTMLURL(), "err", err, "attempt", attempt)
+		if err := g.sleep(ctx, mergeRetryDelay); err != nil {
+			return err
+		}
 	}
-	return nil
 }
@@ -0,0 +1,676 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// fakeGitHub serves just enough of the GitHub API for GetPrReviewRequested:
+// one review_requested notification per entry in diffs, keyed by PR number.
+// A PR whose diff is empty responds with 404; PRs listed in drafts are
+// drafts.
+func fakeGitHub(t *testing.T, diffs map[int]string, drafts ...int) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/notifications":
+			var items []string
+			for num := range diffs {
+				items = append(items, fmt.Sprintf(`{"id":"%d","reason":"review_requested","repository":{"name":"r","owner":{"login":"o"}},"subject":{"url":"%s/repos/o/r/pulls/%d"}}`, num, apiURL(srv), num))
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+		case r.URL.Path == "/search/issues":
+			var items []string
+			for num := range diffs {
+				items = append(items, fmt.Sprintf(`{"number":%d,"repository_url":"%s/repos/o/r","html_url":"https://github.com/o/r/pull/%d"}`, num, apiURL(srv), num))
+			}
+			fmt.Fprintf(w, `{"total_count":%d,"items":[%s]}`, len(items), strings.Join(items, ","))
+		case strings.HasSuffix(r.URL.Path, "/commits"):
+			fmt.Fprint(w, `[{"commit":{"verification":{"verified":true}}}]`)
+		case strings.HasPrefix(r.URL.Path, "/repos/o/r/pulls/"):
+			var num int
+			fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/repos/o/r/pulls/"), "%d", &num)
+			diff := diffs[num]
+			if diff == "" {
+				http.NotFound(w, r)
+				return
+			}
+			if r.Header.Get("Accept") == "application/vnd.github.diff" {
+				fmt.Fprint(w, diff)
+				return
+			}
+			fmt.Fprintf(w, `{"number":%d,"state":"open","draft":%t,"url":"%s/repos/o/r/pulls/%d","html_url":"https://github.com/o/r/pull/%d","user":{"login":"alice"},"base":{"ref":"main"}}`, num, slices.Contains(drafts, num), apiURL(srv), num, num)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return srv
+}
+
+func TestGetPrReviewRequestedCollectsFailures(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{
+		1: "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n",
+		2: "",
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	res, err := g.GetPrReviewRequested(context.Background(), FetchOptions{})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested: %v", err)
+	}
+	if len(res.PrMap) != 1 {
+		t.Fatalf("got %d PRs, want 1", len(res.PrMap))
+	}
+	if _, ok := res.Failures["o/r#2"]; !ok || len(res.Failures) != 1 {
+		t.Fatalf("failures = %v, want only o/r#2", res.Failures)
+	}
+	if got := g.threads["https://github.com/o/r/pull/1"]; got != "1" {
+		t.Errorf("thread of PR 1 = %q, want 1", got)
+	}
+	if want := (PrThreadMap{"https://github.com/o/r/pull/1": "1"}); !maps.Equal(res.ThreadMap, want) {
+		t.Errorf("ThreadMap = %v, want %v", res.ThreadMap, want)
+	}
+
+	if _, err := g.GetPrReviewRequested(context.Background(), FetchOptions{FailFast: true}); err == nil {
+		t.Fatal("FailFast fetch succeeded, want error")
+	}
+}
+
+func TestGetPrReviewRequestedRepoFilter(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{
+		1: "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n",
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	res, err := g.GetPrReviewRequested(context.Background(), FetchOptions{Repos: []string{"o/*"}})
+	if err != nil || len(res.PrMap) != 1 {
+		t.Fatalf("o/* filter: %d PRs, err %v; want 1 PR", len(res.PrMap), err)
+	}
+	res, err = g.GetPrReviewRequested(context.Background(), FetchOptions{Repos: []string{"other/r"}})
+	if err != nil || len(res.PrMap) != 0 || len(res.Failures) != 0 {
+		t.Fatalf("other/r filter: %d PRs, failures %v, err %v; want nothing", len(res.PrMap), res.Failures, err)
+	}
+	if got, want := res.FilterSummary(), "filtered out 1 PR(s) by --repo"; got != want {
+		t.Errorf("FilterSummary = %q, want %q", got, want)
+	}
+}
+
+func TestGetPrReviewRequestedBaseBranchFilter(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{
+		1: "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n",
+		2: "diff --git a/b.go b/b.go\n@@ -1 +1 @@\n-old\n+new\n",
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	res, err := g.GetPrReviewRequested(context.Background(), FetchOptions{BaseBranches: []string{"release/*", "main"}})
+	if err != nil || len(res.PrMap) != 2 {
+		t.Fatalf("main filter: %d PRs, err %v; want 2", len(res.PrMap), err)
+	}
+	res, err = g.GetPrReviewRequested(context.Background(), FetchOptions{BaseBranches: []string{"release/*"}, Repos: []string{"o/r"}})
+	if err != nil || len(res.PrMap) != 0 {
+		t.Fatalf("release/* filter: %d PRs, err %v; want none", len(res.PrMap), err)
+	}
+	if got, want := res.FilterSummary(), "filtered out 2 PR(s) by --base-branch"; got != want {
+		t.Errorf("FilterSummary = %q, want %q", got, want)
+	}
+}
+
+func TestGetPrReviewRequestedForUser(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{
+		1: "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n",
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	res, err := g.GetPrReviewRequestedForUser(context.Background(), []string{"bob, ALICE"}, FetchOptions{})
+	if err != nil || len(res.UserHashPrMap["alice"]) != 1 {
+		t.Fatalf("alice's queue: %v, err %v; want 1 hash", res.UserHashPrMap, err)
+	}
+	res, err = g.GetPrReviewRequestedForUser(context.Background(), []string{"bob"}, FetchOptions{})
+	if err != nil || len(res.PrMap) != 0 || len(res.Failures) != 0 {
+		t.Fatalf("bob's queue: %d PRs, failures %v, err %v; want nothing", len(res.PrMap), res.Failures, err)
+	}
+	if got := res.FilterSummary(); got != "" {
+		t.Errorf("FilterSummary = %q, want none for other authors", got)
+	}
+	res, err = g.GetPrReviewRequestedForUser(context.Background(), nil, FetchOptions{})
+	if err != nil || len(res.PrMap) != 1 {
+		t.Fatalf("everyone's queue: %d PRs, err %v; want 1", len(res.PrMap), err)
+	}
+}
+
+func TestGetPrReviewRequestedSkipsDrafts(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{
+		1: "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n",
+		2: "diff --git a/b.go b/b.go\n@@ -1 +1 @@\n-old\n+new\n",
+	}, 2)
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	res, err := g.GetPrReviewRequested(context.Background(), FetchOptions{})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested: %v", err)
+	}
+	if _, ok := res.PrMap["https://github.com/o/r/pull/1"]; !ok || len(res.PrMap) != 1 {
+		t.Fatalf("PRs = %v, want only the non-draft PR 1", res.PrMap)
+	}
+	if res.Filtered[FilterDrafts] != 1 {
+		t.Errorf("Filtered = %v, want 1 draft", res.Filtered)
+	}
+
+	res, err = g.GetPrReviewRequested(context.Background(), FetchOptions{IncludeDrafts: true})
+	if err != nil || len(res.PrMap) != 2 {
+		t.Fatalf("IncludeDrafts: %d PRs, err %v; want 2", len(res.PrMap), err)
+	}
+}
+
+func TestGetPrReviewRequestedCanceled(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{
+		1: "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n",
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := g.GetPrReviewRequested(ctx, FetchOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetPrReviewRequested error = %v, want context.Canceled", err)
+	}
+}
+
+// hangingGitHub serves PRs 1 and 2 like fakeGitHub, except that PR 2's diff
+// never comes until the request is abandoned.
+func hangingGitHub(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/notifications":
+			fmt.Fprintf(w, `[{"id":"1","reason":"review_requested","repository":{"name":"r","owner":{"login":"o"}},"subject":{"url":"%[1]s/repos/o/r/pulls/1"}},`+
+				`{"id":"2","reason":"review_requested","repository":{"name":"r","owner":{"login":"o"}},"subject":{"url":"%[1]s/repos/o/r/pulls/2"}}]`, apiURL(srv))
+		case strings.HasSuffix(r.URL.Path, "/commits"):
+			fmt.Fprint(w, `[]`)
+		case strings.HasPrefix(r.URL.Path, "/repos/o/r/pulls/"):
+			num := strings.TrimPrefix(r.URL.Path, "/repos/o/r/pulls/")
+			if r.Header.Get("Accept") == "application/vnd.github.diff" {
+				if num == "2" {
+					<-r.Context().Done()
+					return
+				}
+				fmt.Fprint(w, "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n")
+				return
+			}
+			fmt.Fprintf(w, `{"number":%[2]s,"state":"open","url":"%[1]s/repos/o/r/pulls/%[2]s","html_url":"https://github.com/o/r/pull/%[2]s","user":{"login":"alice"},"base":{"ref":"main"}}`, apiURL(srv), num)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return srv
+}
+
+func TestGetPrReviewRequestedDeadline(t *testing.T) {
+	srv := hangingGitHub(t)
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	res, err := g.GetPrReviewRequested(context.Background(), FetchOptions{Deadline: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested: %v", err)
+	}
+	if _, ok := res.PrMap["https://github.com/o/r/pull/1"]; !ok || len(res.PrMap) != 1 {
+		t.Errorf("PrMap = %v, want PR 1 only", res.PrMap)
+	}
+	if res.Unloaded != 1 || len(res.Failures) != 0 {
+		t.Errorf("Unloaded = %d, failures %v; want PR 2 unloaded, not failed", res.Unloaded, res.Failures)
+	}
+	if warning := res.PartialWarning(); !strings.Contains(warning, "1 PR(s) not loaded") || len(res.FailureLines()) != 0 {
+		t.Errorf("PartialWarning = %q, FailureLines = %q; want the partial queue warning only", warning, res.FailureLines())
+	}
+}
+
+func TestGetPrReviewRequestedPRTimeout(t *testing.T) {
+	srv := hangingGitHub(t)
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	res, err := g.GetPrReviewRequested(context.Background(), FetchOptions{PRTimeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested: %v", err)
+	}
+	if len(res.PrMap) != 1 || res.Unloaded != 0 {
+		t.Errorf("PrMap = %v, Unloaded = %d; want PR 1 loaded", res.PrMap, res.Unloaded)
+	}
+	if err := res.Failures["o/r#2"]; err == nil || !strings.Contains(err.Error(), "timed out after 200ms") {
+		t.Errorf("failure of PR 2 = %v, want a timeout", err)
+	}
+}
+
+func TestGetPrHash(t *testing.T) {
+	diff := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package a
+-var x = 1
++var x = 2
+ // end
+diff --git a/b.yml b/b.yml
+--- a/b.yml
++++ b/b.yml
+@@ -1 +1 @@
+-      - uses: actions/checkout@v5
++      - uses: actions/checkout@v6
+`
+	srv := fakeGitHub(t, map[int]string{1: diff})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	pr, _, err := g.c.PullRequests.Get(t.Context(), "o", "r", 1)
+	if err != nil {
+		t.Fatalf("get PR: %v", err)
+	}
+	hashes, changes, files, raw, err := g.getPrHash(context.Background(), pr, FetchOptions{})
+	if err != nil {
+		t.Fatalf("getPrHash: %v", err)
+	}
+	if len(hashes) != 2 {
+		t.Fatalf("got %d hashes, want 2", len(hashes))
+	}
+	if got := files[hashes[0]]; got != "a.go" {
+		t.Errorf("first hunk file = %q, want a.go", got)
+	}
+	if got := files[hashes[1]]; got != "b.yml" {
+		t.Errorf("second hunk file = %q, want b.yml", got)
+	}
+	if got := changes[hashes[0]].Header(); got != "a.go @@ -1 +1 @@" {
+		t.Errorf("first hunk header = %q", got)
+	}
+	if got := strings.Join(changes[hashes[0]].Lines, "\n"); got != "-var x = 1\n+var x = 2" {
+		t.Errorf("first hunk changes = %q", got)
+	}
+	if got := strings.Join(changes[hashes[1]].Lines, "\n"); got != "-uses: actions/checkout@v5\n+uses: actions/checkout@v6" {
+		t.Errorf("second hunk changes = %q", got)
+	}
+	if got := len(raw[hashes[0]]); got != 4 {
+		t.Errorf("first hunk has %d raw lines, want 4 (with context)", got)
+	}
+}
+
+func TestGetPrHashBinaryFiles(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-x := 1\n+x := 2\n" +
+		"diff --git a/logo.png b/logo.png\nindex 1a2b3c4..5d6e7f8 100644\nBinary files a/logo.png and b/logo.png differ\n" +
+		"diff --git a/icon.png b/icon.png\nnew file mode 100644\nindex 0000000..9abcdef\nBinary files /dev/null and b/icon.png differ\n"
+	srv := fakeGitHub(t, map[int]string{1: diff})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	pr, _, err := g.c.PullRequests.Get(t.Context(), "o", "r", 1)
+	if err != nil {
+		t.Fatalf("get PR: %v", err)
+	}
+	hashes, changes, files, raw, err := g.getPrHash(context.Background(), pr, FetchOptions{IgnoreWhitespace: true})
+	if err != nil {
+		t.Fatalf("getPrHash: %v", err)
+	}
+	if len(hashes) != 3 {
+		t.Fatalf("got %d hashes, want 3 (one text hunk, two binary files)", len(hashes))
+	}
+	if text := changes[hashes[0]]; text.Binary || text.Header() != "a.go @@ -1 +1 @@" {
+		t.Errorf("text hunk = %+v", text)
+	}
+	bin := changes[hashes[1]]
+	if !bin.Binary || files[hashes[1]] != "logo.png" {
+		t.Fatalf("second hunk = %+v, want binary change in logo.png", bin)
+	}
+	if got := bin.Header(); got != "logo.png (binary)" {
+		t.Errorf("binary header = %q", got)
+	}
+	if got := strings.Join(bin.Lines, "\n"); got != "binary change in logo.png (index 1a2b3c4..5d6e7f8)" {
+		t.Errorf("binary lines = %q", got)
+	}
+	if got := strings.Join(raw[hashes[1]], "\n"); got != "Binary files a/logo.png and b/logo.png differ" {
+		t.Errorf("binary raw lines = %q", got)
+	}
+	if got := files[hashes[2]]; got != "icon.png" || !changes[hashes[2]].Binary {
+		t.Errorf("third hunk = %q %+v, want binary change in icon.png", got, changes[hashes[2]])
+	}
+}
+
+func TestGetPrHashRenames(t *testing.T) {
+	diff := "diff --git a/old.go b/new.go\nsimilarity index 100%\nrename from old.go\nrename to new.go\n" +
+		"diff --git a/a.go b/moved/a.go\nsimilarity index 90%\nrename from a.go\nrename to moved/a.go\n--- a/a.go\n+++ b/moved/a.go\n@@ -1 +1 @@\n-x := 1\n+x := 2\n"
+	srv := fakeGitHub(t, map[int]string{1: diff})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	pr, _, err := g.c.PullRequests.Get(t.Context(), "o", "r", 1)
+	if err != nil {
+		t.Fatalf("get PR: %v", err)
+	}
+	hashes, changes, files, _, err := g.getPrHash(context.Background(), pr, FetchOptions{IgnoreWhitespace: true})
+	if err != nil {
+		t.Fatalf("getPrHash: %v", err)
+	}
+	if len(hashes) != 3 {
+		t.Fatalf("got %d hashes, want 3 (two renames, one content change)", len(hashes))
+	}
+	rename := changes[hashes[0]]
+	if rename.From != "old.go" || files[hashes[0]] != "new.go" {
+		t.Fatalf("first hunk = %+v, want rename of old.go to new.go", rename)
+	}
+	if got := rename.Header(); got != "new.go (renamed from old.go)" {
+		t.Errorf("rename header = %q", got)
+	}
+	if got := strings.Join(rename.Lines, "\n"); got != "renamed old.go → new.go" {
+		t.Errorf("rename lines = %q", got)
+	}
+	if got := changes[hashes[1]].From; got != "a.go" {
+		t.Errorf("second hunk renamed from %q, want a.go", got)
+	}
+	edit := changes[hashes[2]]
+	if edit.From != "" || edit.Header() != "moved/a.go @@ -1 +1 @@" {
+		t.Errorf("content hunk = %+v, want a plain hunk in moved/a.go", edit)
+	}
+	if len(map[string]bool{hashes[0]: true, hashes[1]: true, hashes[2]: true}) != 3 {
+		t.Errorf("hashes = %v, want three distinct hashes", hashes)
+	}
+}
+
+func TestGetRawDiff(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-x := 1\n+x := 2\n"
+	srv := fakeGitHub(t, map[int]string{1: diff})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	pr, _, err := g.c.PullRequests.Get(t.Context(), "o", "r", 1)
+	if err != nil {
+		t.Fatalf("get PR: %v", err)
+	}
+	got, err := g.GetRawDiff(context.Background(), pr)
+	if err != nil {
+		t.Fatalf("GetRawDiff: %v", err)
+	}
+	if got != diff {
+		t.Errorf("GetRawDiff = %q, want %q", got, diff)
+	}
+
+	pr.URL = github.Ptr(apiURL(srv) + "/repos/o/r/pulls/2")
+	if _, err := g.GetRawDiff(context.Background(), pr); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("GetRawDiff of a missing PR = %v, want a 404 error", err)
+	}
+}
+
+func TestGetPrCommentOrDiscussion(t *testing.T) {
+	var issueComments, reviewComments string
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/issues/1/comments":
+			fmt.Fprint(w, issueComments)
+		case "/repos/o/r/pulls/1/comments":
+			fmt.Fprint(w, reviewComments)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	pr := &github.PullRequest{
+		Number:  github.Ptr(1),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Base: &github.PullRequestBranch{
+			Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}},
+		},
+	}
+	ctx := context.Background()
+
+	issueComments = `[{"body":"first"},{"body":"latest"},{"body":"  "}]`
+	reviewComments = `[{"body":"on a line"}]`
+	if got, err := g.GetPrCommentOrDiscussion(ctx, pr); err != nil || got != "latest" {
+		t.Errorf("with issue comments = %q, %v; want latest", got, err)
+	}
+	if _, err := g.GetPrComment(ctx, pr); err == nil {
+		t.Error("GetPrComment fell back to comments, want body only")
+	}
+
+	issueComments = `[]`
+	if got, err := g.GetPrCommentOrDiscussion(ctx, pr); err != nil || got != "on a line" {
+		t.Errorf("with review comments only = %q, %v; want on a line", got, err)
+	}
+
+	reviewComments = `[]`
+	if _, err := g.GetPrCommentOrDiscussion(ctx, pr); err == nil {
+		t.Error("without body or comments got no error")
+	}
+
+	// a description is used without calling the API
+	pr.Body = github.Ptr("the description")
+	srv.Close()
+	if got, err := g.GetPrCommentOrDiscussion(ctx, pr); err != nil || got != "the description" {
+		t.Errorf("with body = %q, %v; want the description", got, err)
+	}
+}
+
+func TestIgnoreWhitespaceKeepsReorders(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n" +
+		"@@ -1,2 +1,2 @@\n-a()\n-b()\n+b()\n+a()\n" +
+		"@@ -10,2 +10,2 @@\n-c()\n-d()\n+d()\n+c()\n" +
+		"@@ -20 +20 @@\n-e()\n+\te()\n" +
+		"@@ -30 +30 @@\n-f() \n+f()\n"
+	srv := fakeGitHub(t, map[int]string{1: diff})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	pr, _, err := g.c.PullRequests.Get(t.Context(), "o", "r", 1)
+	if err != nil {
+		t.Fatalf("get PR: %v", err)
+	}
+	hashes, _, _, _, err := g.getPrHash(context.Background(), pr, FetchOptions{IgnoreWhitespace: true})
+	if err != nil {
+		t.Fatalf("getPrHash: %v", err)
+	}
+	if len(hashes) != 4 {
+		t.Fatalf("got %d hashes, want 4", len(hashes))
+	}
+	if hashes[0] == hashes[1] {
+		t.Error("two different reorder-only hunks share a hash")
+	}
+	if hashes[2] == hashes[3] {
+		t.Error("two different whitespace-only hunks share a hash")
+	}
+}
+
+func TestIgnoreWhitespaceSharesHash(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{
+		1: "diff --git a/a.go b/a.go\n@@ -1,2 +1,2 @@\n-x := 1\n+x := 2\n",
+		2: "diff --git a/a.go b/a.go\n@@ -1,3 +1,3 @@\n-x := 1 \n+x := 2\t\n-\tf()\n+\t\tf()\n",
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	res, err := g.GetPrReviewRequested(context.Background(), FetchOptions{})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested: %v", err)
+	}
+	if len(res.HashPrMap) != 2 {
+		t.Fatalf("without IgnoreWhitespace got %d hashes, want 2", len(res.HashPrMap))
+	}
+
+	res, err = g.GetPrReviewRequested(context.Background(), FetchOptions{IgnoreWhitespace: true})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested: %v", err)
+	}
+	if len(res.HashPrMap) != 1 {
+		t.Fatalf("with IgnoreWhitespace got %d hashes, want 1", len(res.HashPrMap))
+	}
+	for _, prs := range res.HashPrMap {
+		if len(prs) != 2 {
+			t.Fatalf("shared hash has %d PRs, want 2", len(prs))
+		}
+	}
+}
+
+func TestGetPrHashSeparatesFiles(t *testing.T) {
+	change := "@@ -1,3 +1,3 @@\n-a := 1\n-b := 2\n-c := 3\n+a := 10\n+b := 20\n+c := 30\n"
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n" + change +
+		"diff --git a/b.go b/b.go\n--- a/b.go\n+++ b/b.go\n" + change
+	srv := fakeGitHub(t, map[int]string{1: diff})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	pr, _, err := g.c.PullRequests.Get(t.Context(), "o", "r", 1)
+	if err != nil {
+		t.Fatalf("get PR: %v", err)
+	}
+	hashes, _, files, _, err := g.getPrHash(context.Background(), pr, FetchOptions{})
+	if err != nil {
+		t.Fatalf("getPrHash: %v", err)
+	}
+	if len(hashes) != 2 || hashes[0] == hashes[1] {
+		t.Fatalf("hashes = %v, want two distinct hashes", hashes)
+	}
+	if files[hashes[0]] != "a.go" || files[hashes[1]] != "b.go" {
+		t.Fatalf("files = %v, want a.go and b.go", files)
+	}
+}
+
+func TestParsePRRef(t *testing.T) {
+	want := PRRef{Owner: "o", Repo: "r", Number: 12}
+	for _, in := range []string{
+		"o/r#12",
+		" o/r#12 ",
+		"https://github.com/o/r/pull/12",
+		"https://ghe.example.com/o/r/pull/12/files#diff-1",
+	} {
+		got, err := ParsePRRef(in)
+		if err != nil || got != want {
+			t.Errorf("ParsePRRef(%q) = %+v, %v; want %+v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "o/r", "o#12", "o/r/x#12", "o/r#0", "o/r#abc", "https://github.com/o/r/issues/12"} {
+		if got, err := ParsePRRef(in); err == nil {
+			t.Errorf("ParsePRRef(%q) = %+v, want error", in, got)
+		}
+	}
+	if got := want.String(); got != "o/r#12" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestGetPR(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{7: "diff --git a/a.go b/a.go\n"})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	pr, err := g.GetPR(context.Background(), "o", "r", 7)
+	if err != nil {
+		t.Fatalf("GetPR: %v", err)
+	}
+	if pr.GetNumber() != 7 || pr.GetHTMLURL() != "https://github.com/o/r/pull/7" {
+		t.Errorf("GetPR = #%d %s", pr.GetNumber(), pr.GetHTMLURL())
+	}
+	if _, err := g.GetPR(context.Background(), "o", "r", 8); err == nil || !strings.Contains(err.Error(), "o/r#8") {
+		t.Errorf("GetPR(missing) error = %v, want one naming o/r#8", err)
+	}
+}
+
+func TestSortedHashes(t *testing.T) {
+	day := func(d int) *github.Timestamp {
+		return &github.Timestamp{Time: time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)}
+	}
+	pr := func(repo string, n int, title string, created, updated int) *github.PullRequest {
+		return &github.PullRequest{
+			Number:    github.Ptr(n),
+			Title:     github.Ptr(title),
+			HTMLURL:   github.Ptr(fmt.Sprintf("https://github.com/%s/pull/%d", repo, n)),
+			Base:      &github.PullRequestBranch{Repo: &github.Repository{FullName: github.Ptr(repo)}},
+			CreatedAt: day(created),
+			UpdatedAt: day(updated),
+		}
+	}
+	old := pr("o/b", 9, "zeta", 1, 2)
+	recent := pr("o/a", 10, "Alpha", 3, 9)
+	mid := pr("o/a", 2, "beta", 2, 5)
+	numbers := func(prs []*github.PullRequest) []int {
+		var out []int
+		for _, p := range prs {
+			out = append(out, p.GetNumber())
+		}
+		return out
+	}
+
+	for sortBy, want := range map[PRSort]struct {
+		hashes []string
+		prs    []int
+	}{
+		PRSortName:    {[]string{"y", "x"}, []int{10, 2, 9}},
+		PRSortRepo:    {[]string{"y", "x"}, []int{2, 10, 9}},
+		PRSortUpdated: {[]string{"y", "x"}, []int{10, 2, 9}},
+		PRSortCreated: {[]string{"x", "y"}, []int{9, 2, 10}},
+	} {
+		hashMap := map[string][]*github.PullRequest{
+			"x": {old},
+			"y": {old, mid, recent},
+		}
+		if got := sortedHashes(hashMap, sortBy); !slices.Equal(got, want.hashes) {
+			t.Errorf("%s: hashes = %v, want %v", sortBy, got, want.hashes)
+		}
+		if got := numbers(hashMap["y"]); !slices.Equal(got, want.prs) {
+			t.Errorf("%s: PRs = %v, want %v", sortBy, got, want.prs)
+		}
+	}
+}
+
+func TestParsePRSort(t *testing.T) {
+	for in, want := range map[string]PRSort{"": PRSortRepo, "name": PRSortName, " Updated ": PRSortUpdated, "created": PRSortCreated} {
+		if got, err := ParsePRSort(in); err != nil || got != want {
+			t.Errorf("ParsePRSort(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParsePRSort("count"); err == nil {
+		t.Error("ParsePRSort(count) succeeded, want error")
+	}
+}
+
+func TestPrintChangesDeduped(t *testing.T) {
+	pr := func(n int) *github.PullRequest {
+		return &github.PullRequest{Number: github.Ptr(n), HTMLURL: github.Ptr(fmt.Sprintf("https://github.com/o/r/pull/%d", n))}
+	}
+	res := &FetchResult{
+		UserHashPrMap: GhPrHashMap{
+			"alice": {"shared": {pr(1)}, "a": {pr(1)}},
+			"bob":   {"shared": {pr(2), pr(3)}},
+		},
+		ChangeMap: HashChangeMap{"shared": {File: "go.mod", Lines: []string{"+bump"}}},
+	}
+	var b strings.Builder
+	printChangesDeduped(&b, []string{"alice", "bob"}, res, PRSortRepo)
+	out := b.String()
+	if n := strings.Count(out, "+bump"); n != 1 {
+		t.Errorf("shared change printed %d times, want once:\n%s", n, out)
+	}
+	for _, s := range []string{
+		"2 unique hashes across 2 users",
+		"Hash: shared\n",
+		"  Found in:\n    alice: https://github.com/o/r/pull/1\n    bob: https://github.com/o/r/pull/2\n    bob: https://github.com/o/r/pull/3\n",
+		"Hash: a\n  No changes found for this hash.\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output missing %q:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// LowRateLimitThreshold is the remaining core request budget below which
+// callers should warn that the token is about to be throttled.
+const LowRateLimitThreshold = 100
+
+// RateLimit returns the token's current core REST rate limit. Querying it
+// does not count against the limit.
+func (g *GhClient) RateLimit(ctx context.Context) (*github.Rate, error) {
+	limits, _, err := g.c.RateLimit.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rate limit: %w", err)
+	}
+	if limits.GetCore() == nil {
+		return nil, fmt.Errorf("rate limit response has no core limit")
+	}
+	return limits.GetCore(), nil
+}
@@ -0,0 +1,80 @@
+package gh
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ReasonReviewRequested is the notification reason of a review request, the
+// only one fetched by default.
+const ReasonReviewRequested = "review_requested"
+
+// NotificationReasons are the reasons GitHub gives for a notification, as
+// documented for the notifications API.
+var NotificationReasons = []string{
+	"approval_requested",
+	"assign",
+	"author",
+	"ci_activity",
+	"comment",
+	"invitation",
+	"manual",
+	"member_feature_requested",
+	"mention",
+	ReasonReviewRequested,
+	"security_advisory_credit",
+	"security_alert",
+	"state_change",
+	"subscribed",
+	"team_mention",
+}
+
+// reasonQualifiers are the search qualifiers finding the open PRs a
+// notification reason would, for the reasons SourceSearch supports.
+var reasonQualifiers = map[string]string{
+	ReasonReviewRequested: "review-requested:@me",
+	"assign":              "assignee:@me",
+	"author":              "author:@me",
+	"mention":             "mentions:@me",
+}
+
+// ParseReasons validates --reasons values against NotificationReasons,
+// lowercasing them and dropping duplicates.
+func ParseReasons(values []string) ([]string, error) {
+	var out []string
+	for _, raw := range values {
+		r := strings.ToLower(strings.TrimSpace(raw))
+		if !slices.Contains(NotificationReasons, r) {
+			return nil, fmt.Errorf("invalid reason %q: want one of %s", raw, strings.Join(NotificationReasons, ", "))
+		}
+		if !slices.Contains(out, r) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// reasonQuery finds the open PRs that notifications for reason would surface,
+// or reports false if the search API can't express it.
+func reasonQuery(reason string) (string, bool) {
+	q, ok := reasonQualifiers[reason]
+	if !ok {
+		return "", false
+	}
+	return "is:open is:pr " + q + " archived:false", true
+}
+
+// PrReasonMap maps a PR identifier (HTML URL) to the notification reasons,
+// sorted, that brought it into the queue.
+type PrReasonMap map[string][]string
+
+// Tag describes why prKey is in the queue when it is not only a review
+// request, e.g. "assign, mention", or "" otherwise.
+func (m PrReasonMap) Tag(prKey string) string {
+	reasons := m[prKey]
+	if len(reasons) == 0 || slices.Equal(reasons, []string{ReasonReviewRequested}) {
+		return ""
+	}
+	return strings.Join(reasons, ", ")
+}
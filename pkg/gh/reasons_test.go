@@ -0,0 +1,98 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseReasons(t *testing.T) {
+	got, err := ParseReasons([]string{" Mention", "review_requested", "mention"})
+	if err != nil || !slices.Equal(got, []string{"mention", "review_requested"}) {
+		t.Errorf("ParseReasons = %v, %v; want mention and review_requested", got, err)
+	}
+	if _, err := ParseReasons([]string{"review-requested"}); err == nil {
+		t.Error("ParseReasons(review-requested) succeeded, want error")
+	}
+}
+
+// PR 1 requests my review, PR 2 mentions me, PR 3 is assigned to me and
+// issue 4 mentions me.
+func TestFetchReasons(t *testing.T) {
+	var queries []string
+	var srv *httptest.Server
+	srv = newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/notifications":
+			fmt.Fprint(w, `[`+
+				`{"id":"1","reason":"review_requested","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"PullRequest","url":"x/pulls/1"}},`+
+				`{"id":"2","reason":"mention","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"PullRequest","url":"x/pulls/2"}},`+
+				`{"id":"3","reason":"assign","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"PullRequest","url":"x/pulls/3"}},`+
+				`{"id":"4","reason":"mention","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"Issue","url":"x/issues/4"}}]`)
+		case r.URL.Path == "/search/issues":
+			q := r.URL.Query().Get("q")
+			queries = append(queries, q)
+			num := 1
+			if strings.Contains(q, "mentions:@me") {
+				num = 2
+			}
+			fmt.Fprintf(w, `{"total_count":1,"items":[{"number":%d,"repository_url":"https://api.github.com/repos/o/r"}]}`, num)
+		case strings.HasSuffix(r.URL.Path, "/commits"):
+			fmt.Fprint(w, `[]`)
+		case strings.HasPrefix(r.URL.Path, "/repos/o/r/pulls/"):
+			var num int
+			fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/repos/o/r/pulls/"), "%d", &num)
+			if r.Header.Get("Accept") == "application/vnd.github.diff" {
+				fmt.Fprintf(w, "diff --git a/%d.go b/%d.go\n@@ -1 +1 @@\n-old\n+new\n", num, num)
+				return
+			}
+			fmt.Fprintf(w, `{"number":%d,"state":"open","url":"%s/repos/o/r/pulls/%d","html_url":"https://github.com/o/r/pull/%d","user":{"login":"alice"},"base":{"ref":"main"}}`, num, apiURL(srv), num, num)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	pr := func(n int) string { return fmt.Sprintf("https://github.com/o/r/pull/%d", n) }
+	ctx := context.Background()
+
+	g := newTestClient(t, srv)
+	res, err := g.GetPrReviewRequested(ctx, FetchOptions{})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested: %v", err)
+	}
+	if len(res.PrMap) != 1 || res.ReasonMap.Tag(pr(1)) != "" {
+		t.Errorf("by default got %v tagged %v, want PR 1 untagged", res.PrMap, res.ReasonMap)
+	}
+
+	res, err = g.GetPrReviewRequested(ctx, FetchOptions{Reasons: []string{"review_requested", "mention", "assign"}})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested: %v", err)
+	}
+	if len(res.PrMap) != 3 {
+		t.Errorf("PrMap = %v, want PRs 1 to 3 and no issue", res.PrMap)
+	}
+	if got := res.ReasonMap.Tag(pr(2)); got != "mention" {
+		t.Errorf("tag of PR 2 = %q, want mention", got)
+	}
+	if got := res.ReasonMap.Tag(pr(3)); got != "assign" {
+		t.Errorf("tag of PR 3 = %q, want assign", got)
+	}
+
+	res, err = g.GetPrReviewRequested(ctx, FetchOptions{Source: SourceSearch, Reasons: []string{"review_requested", "mention"}})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested via search: %v", err)
+	}
+	if len(res.PrMap) != 2 || res.ReasonMap.Tag(pr(2)) != "mention" {
+		t.Errorf("via search got %v tagged %v, want PRs 1 and 2 with 2 a mention", res.PrMap, res.ReasonMap)
+	}
+	if !slices.ContainsFunc(queries, func(q string) bool { return strings.Contains(q, "mentions:@me") }) {
+		t.Errorf("queries = %q, want a mentions search", queries)
+	}
+	if _, err := g.GetPrReviewRequested(ctx, FetchOptions{Source: SourceSearch, Reasons: []string{"comment"}}); err == nil {
+		t.Error("searching for comment notifications succeeded, want error")
+	}
+}
@@ -0,0 +1,134 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRetries is how many times a rate-limited request is retried.
+const DefaultMaxRetries = 3
+
+const (
+	retryBaseDelay = time.Second
+	// maxRetryWait caps how long a single retry waits; a rate-limit reset
+	// further away than this is reported instead of slept through.
+	maxRetryWait = 2 * time.Minute
+)
+
+// doWithRetry sends req, retrying with exponential backoff when GitHub
+// responds with a (secondary) rate limit or a transient 5xx. Retry-After and
+// X-RateLimit-Reset are honored when present. Once retries are exhausted the
+// last response is returned for the caller to report.
+func (g *GhClient) doWithRetry(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := g.c.Client().Do(req)
+		if err != nil {
+			if isTimeout(err) && req.Context().Err() == nil {
+				return nil, fmt.Errorf("request timed out after %s: %w", g.timeout, err)
+			}
+			return nil, err
+		}
+		wait, retry := retryDelay(resp, attempt)
+		if !retry || attempt >= g.maxRetries {
+			return resp, nil
+		}
+		if wait > maxRetryWait {
+			return resp, nil
+		}
+		// drain so the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		slog.Debug("retrying rate-limited request", "url", req.URL.String(), "status", resp.StatusCode, "wait", wait, "attempt", attempt+1)
+		if err := g.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if req, err = rewindRequest(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isTimeout reports whether err is a request exceeding its deadline.
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// sleepContext waits for d, returning early with ctx's error if it is canceled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// retryDelay reports whether resp should be retried and how long to wait first.
+func retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	backoff := retryBaseDelay << attempt
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return backoff, true
+	case http.StatusForbidden, http.StatusTooManyRequests:
+	default:
+		return 0, false
+	}
+
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if secs, err := strconv.Atoi(s); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Until(time.Unix(reset, 0)), 0), true
+		}
+		return backoff, true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || isSecondaryRateLimit(resp) {
+		return backoff, true
+	}
+	return 0, false
+}
+
+// isSecondaryRateLimit peeks at a 403 body for GitHub's secondary rate limit
+// message, leaving the body readable for the caller.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(body)), "secondary rate limit")
+}
+
+// rewindRequest returns a copy of req with a fresh body so it can be resent.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, fmt.Errorf("cannot retry %s %s: request body is not rewindable", req.Method, req.URL)
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	}
+	return next, nil
+}
@@ -0,0 +1,142 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDoWithRetryHonorsRetryAfter(t *testing.T) {
+	calls := 0
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("call %d body = %q, want payload", calls, body)
+		}
+		switch calls {
+		case 1:
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusForbidden)
+		case 2:
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit."}`)
+		default:
+			fmt.Fprint(w, "ok")
+		}
+	})
+	defer srv.Close()
+
+	g := newTestClient(t, srv)
+	var waits []time.Duration
+	g.sleep = func(_ context.Context, d time.Duration) error { waits = append(waits, d); return nil }
+
+	req, _ := http.NewRequest("POST", apiURL(srv)+"/graphql", strings.NewReader("payload"))
+	resp, err := g.doWithRetry(req)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Fatalf("status %d after %d calls, want 200 after 3", resp.StatusCode, calls)
+	}
+	if len(waits) != 2 || waits[0] != 7*time.Second || waits[1] != 2*retryBaseDelay {
+		t.Fatalf("waits = %v, want [7s %v]", waits, 2*retryBaseDelay)
+	}
+}
+
+func TestDoWithRetryGivesUp(t *testing.T) {
+	calls := 0
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, "slow down")
+	})
+	defer srv.Close()
+
+	g := newTestClient(t, srv)
+	g.maxRetries = 2
+
+	req, _ := http.NewRequest("GET", apiURL(srv)+"/graphql", nil)
+	resp, err := g.doWithRetry(req)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusTooManyRequests || string(body) != "slow down" || calls != 3 {
+		t.Fatalf("got %d %q after %d calls, want 429 after 3", resp.StatusCode, body, calls)
+	}
+}
+
+func TestDoWithRetryIgnoresPlainForbidden(t *testing.T) {
+	calls := 0
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+	})
+	defer srv.Close()
+
+	g := newTestClient(t, srv)
+	req, _ := http.NewRequest("GET", apiURL(srv)+"/graphql", nil)
+	resp, err := g.doWithRetry(req)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Fatalf("made %d calls, want 1", calls)
+	}
+}
+
+func TestDoWithRetryStopsWhenCanceled(t *testing.T) {
+	calls := 0
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	defer srv.Close()
+
+	g := newTestClient(t, srv)
+	g.sleep = sleepContext
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", apiURL(srv)+"/graphql", nil)
+	if _, err := g.doWithRetry(req); err == nil {
+		t.Fatal("doWithRetry on a canceled context succeeded, want error")
+	}
+	if calls > 1 {
+		t.Fatalf("server called %d times after cancellation, want at most 1", calls)
+	}
+}
+
+func TestDoWithRetryTimesOut(t *testing.T) {
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	defer srv.Close()
+
+	g, err := NewGhClientWithToken("token", WithHTTPClient(srv.Client()), WithBaseURL(apiURL(srv)), WithTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewGhClientWithToken: %v", err)
+	}
+	req, _ := http.NewRequestWithContext(context.Background(), "GET", apiURL(srv)+"/repos/o/r/pulls/1", nil)
+	_, err = g.doWithRetry(req)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Fatalf("doWithRetry error = %v, want a timeout", err)
+	}
+
+	if _, err := NewGhClientWithToken("token", WithTimeout(-time.Second)); err == nil {
+		t.Error("negative timeout accepted, want error")
+	}
+}
@@ -0,0 +1,232 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// QueueSource is where GetPrReviewRequested finds the PRs to review.
+type QueueSource string
+
+const (
+	// SourceNotifications finds review requests in the notifications updated
+	// within FetchOptions.Since, knowing each one's notification so it can
+	// be marked read. Older or dismissed notifications are missed.
+	SourceNotifications QueueSource = "notifications"
+	// SourceSearch finds every open PR the search API lists as requesting
+	// your review, however old, but without their notifications.
+	SourceSearch QueueSource = "search"
+)
+
+// ParseQueueSource validates a --source value; empty selects
+// SourceNotifications.
+func ParseQueueSource(s string) (QueueSource, error) {
+	switch qs := QueueSource(strings.ToLower(strings.TrimSpace(s))); qs {
+	case "":
+		return SourceNotifications, nil
+	case SourceNotifications, SourceSearch:
+		return qs, nil
+	default:
+		return "", fmt.Errorf("invalid source %q: want notifications or search", s)
+	}
+}
+
+// reviewRequestedQuery finds the open PRs requesting the authenticated
+// user's review.
+const reviewRequestedQuery = "is:open is:pr review-requested:@me archived:false"
+
+// maxSearchPages bounds search pagination; the search API returns at most
+// 1000 results, 10 pages of 100.
+const maxSearchPages = 10
+
+// ListReviewRequestedViaSearch lists the open PRs requesting the
+// authenticated user's review through the search API rather than
+// notifications, so requests of any age are found, read or not.
+func (g *GhClient) ListReviewRequestedViaSearch(ctx context.Context) ([]PRRef, error) {
+	return g.searchPRs(ctx, reviewRequestedQuery)
+}
+
+// searchPRs lists the PRs the search API finds for query.
+func (g *GhClient) searchPRs(ctx context.Context, query string) ([]PRRef, error) {
+	var refs []PRRef
+	opt := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for page := 1; ; page++ {
+		if page > maxSearchPages {
+			slog.Warn("stopped paging search results", "pages", maxSearchPages, "query", query)
+			break
+		}
+		opt.Page = page
+		result, resp, err := g.c.Search.Issues(ctx, query, opt)
+		if err != nil {
+			return nil, err
+		}
+		if result.GetIncompleteResults() {
+			slog.Warn("search for review requests timed out; some PRs may be missing", "query", query)
+		}
+		for _, issue := range result.Issues {
+			ref, err := issueRef(issue)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, ref)
+		}
+		if resp.NextPage == 0 || resp.NextPage <= page {
+			break
+		}
+	}
+	return refs, nil
+}
+
+// issueRef returns the PR behind a search result, whose repository is only
+// given as its API URL, e.g. https://api.github.com/repos/owner/repo.
+func issueRef(issue *github.Issue) (PRRef, error) {
+	_, fullName, ok := strings.Cut(issue.GetRepositoryURL(), "/repos/")
+	owner, repo, ok2 := strings.Cut(fullName, "/")
+	if !ok || !ok2 || owner == "" || repo == "" || issue.GetNumber() == 0 {
+		return PRRef{}, fmt.Errorf("failed to parse PR from search result %s", issue.GetHTMLURL())
+	}
+	return PRRef{Owner: owner, Repo: repo, Number: issue.GetNumber()}, nil
+}
+
+// reviewRequest is a PR to review, with the notification thread that
+// requested it if known.
+type reviewRequest struct {
+	PRRef
+	threadID string
+	// reasons are the FetchOptions.Reasons that surfaced the PR.
+	reasons []string
+	// team marks a PR found through FetchOptions.IncludeTeamRequests, kept
+	// only if it requests the review of one of the user's teams.
+	team bool
+}
+
+// listReviewRequests finds the PRs to review from fetch.Source, each once.
+func (g *GhClient) listReviewRequests(ctx context.Context, fetch FetchOptions) ([]reviewRequest, error) {
+	var (
+		reqs []reviewRequest
+		err  error
+	)
+	if fetch.source() == SourceSearch {
+		reqs, err = g.searchReviewRequests(ctx, fetch)
+	} else {
+		reqs, err = g.notifiedReviewRequests(ctx, fetch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// a direct request wins over a team one for the same PR, and a PR
+	// surfaced for several reasons keeps them all
+	seen := make(map[PRRef]int)
+	var kept []reviewRequest
+	for _, team := range []bool{false, true} {
+		for _, req := range reqs {
+			if req.team != team {
+				continue
+			}
+			i, ok := seen[req.PRRef]
+			if !ok {
+				seen[req.PRRef] = len(kept)
+				kept = append(kept, req)
+				continue
+			}
+			for _, r := range req.reasons {
+				if !slices.Contains(kept[i].reasons, r) {
+					kept[i].reasons = append(kept[i].reasons, r)
+				}
+			}
+		}
+	}
+	return kept, nil
+}
+
+// searchReviewRequests finds the PRs requesting your review, or those
+// fetch.Reasons name, and with fetch.IncludeTeamRequests those requesting one
+// of your teams', through the search API.
+func (g *GhClient) searchReviewRequests(ctx context.Context, fetch FetchOptions) ([]reviewRequest, error) {
+	var reqs []reviewRequest
+	for _, reason := range fetch.reasons() {
+		query, ok := reasonQuery(reason)
+		if !ok {
+			return nil, fmt.Errorf("PRs notified for %s can't be searched for; use --source %s", reason, SourceNotifications)
+		}
+		refs, err := g.searchPRs(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search PRs notified for %s: %w", reason, err)
+		}
+		for _, ref := range refs {
+			reqs = append(reqs, reviewRequest{PRRef: ref, reasons: []string{reason}})
+		}
+	}
+	if !fetch.IncludeTeamRequests {
+		return reqs, nil
+	}
+	teams, err := g.UserTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, team := range teams {
+		refs, err := g.searchPRs(ctx, teamReviewRequestedQuery(team))
+		if err != nil {
+			return nil, fmt.Errorf("failed to search review requests of team %s: %w", team, err)
+		}
+		for _, ref := range refs {
+			reqs = append(reqs, reviewRequest{PRRef: ref, team: true})
+		}
+	}
+	return reqs, nil
+}
+
+// notifiedReviewRequests finds the PRs whose notifications request your
+// review, or are for one of fetch.Reasons. With fetch.IncludeTeamRequests
+// every other PR notification is a candidate team request too, as those
+// don't always come as review requests.
+func (g *GhClient) notifiedReviewRequests(ctx context.Context, fetch FetchOptions) ([]reviewRequest, error) {
+	n, err := g.getNotifications(ctx, fetch.since())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	var reqs []reviewRequest
+	reasons := fetch.reasons()
+	for _, notification := range n {
+		reason := notification.GetReason()
+		isPR := reason == ReasonReviewRequested || notification.GetSubject().GetType() == "PullRequest"
+		team := false
+		switch {
+		case !isPR:
+			continue
+		case slices.Contains(reasons, reason):
+		case fetch.IncludeTeamRequests:
+			team = true
+		default:
+			continue
+		}
+		url := notification.GetSubject().GetURL()
+		_, numStr, ok := strings.Cut(url, "/pulls/")
+		prNumber, err := strconv.Atoi(numStr)
+		if !ok || err != nil {
+			// one odd notification shouldn't hide every other review request
+			slog.Warn("skipping notification without a PR number", "url", url)
+			continue
+		}
+		req := reviewRequest{
+			PRRef: PRRef{
+				Owner:  notification.GetRepository().GetOwner().GetLogin(),
+				Repo:   notification.GetRepository().GetName(),
+				Number: prNumber,
+			},
+			threadID: notification.GetID(),
+			team:     team,
+		}
+		if !team {
+			req.reasons = []string{reason}
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
@@ -0,0 +1,84 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGetPrReviewRequestedViaSearch(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{
+		1: "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n",
+		2: "diff --git a/b.go b/b.go\n@@ -1 +1 @@\n-old\n+new\n",
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	res, err := g.GetPrReviewRequested(context.Background(), FetchOptions{Source: SourceSearch})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested: %v", err)
+	}
+	if len(res.PrMap) != 2 {
+		t.Errorf("PrMap = %v, want both PRs", res.PrMap)
+	}
+	if len(res.ThreadMap) != 0 {
+		t.Errorf("ThreadMap = %v, want none without notifications", res.ThreadMap)
+	}
+	if len(res.UserHashPrMap["alice"]) != 2 {
+		t.Errorf("UserHashPrMap = %v, want alice's two hashes", res.UserHashPrMap)
+	}
+}
+
+func TestListReviewRequestedViaSearch(t *testing.T) {
+	var query string
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		fmt.Fprint(w, `{"total_count":1,"items":[{"number":7,"repository_url":"https://api.github.com/repos/Org/Repo"}]}`)
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	refs, err := g.ListReviewRequestedViaSearch(context.Background())
+	if err != nil {
+		t.Fatalf("ListReviewRequestedViaSearch: %v", err)
+	}
+	if want := []PRRef{{Owner: "Org", Repo: "Repo", Number: 7}}; !slices.Equal(refs, want) {
+		t.Errorf("refs = %v, want %v", refs, want)
+	}
+	if !strings.Contains(query, "review-requested:@me") || !strings.Contains(query, "is:open") {
+		t.Errorf("query = %q", query)
+	}
+}
+
+func TestParseQueueSource(t *testing.T) {
+	for in, want := range map[string]QueueSource{"": SourceNotifications, "notifications": SourceNotifications, " Search ": SourceSearch} {
+		if got, err := ParseQueueSource(in); err != nil || got != want {
+			t.Errorf("ParseQueueSource(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseQueueSource("graphql"); err == nil {
+		t.Error("ParseQueueSource(graphql) succeeded, want error")
+	}
+}
+
+func TestNotifiedReviewRequestsSkipsUnparseable(t *testing.T) {
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[`+
+			`{"id":"1","reason":"review_requested","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"PullRequest","url":"x/pulls/1"}},`+
+			`{"id":"2","reason":"review_requested","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"PullRequest","url":"x/commits/abc"}},`+
+			`{"id":"3","reason":"review_requested","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"PullRequest","url":"x/pulls/3"}}]`)
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	reqs, err := g.notifiedReviewRequests(context.Background(), FetchOptions{})
+	if err != nil {
+		t.Fatalf("notifiedReviewRequests: %v", err)
+	}
+	if len(reqs) != 2 || reqs[0].Number != 1 || reqs[1].Number != 3 {
+		t.Errorf("notifiedReviewRequests = %+v, want PRs 1 and 3", reqs)
+	}
+}
@@ -0,0 +1,206 @@
+package gh
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// QueueSnapshotTTL is how long a review queue saved by one run is reused by
+// the next, e.g. listing users and then reviewing one of them.
+const QueueSnapshotTTL = 5 * time.Minute
+
+// DefaultQueueSnapshotDir returns the per-user directory review queue
+// snapshots are saved in.
+func DefaultQueueSnapshotDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-pr-review", "queue"), nil
+}
+
+// WithQueueSnapshot saves every review queue GetPrReviewRequested fetches
+// under dir and reuses one saved less than ttl ago instead of fetching again.
+// A zero ttl only saves, to refresh the snapshot. An empty dir disables
+// snapshots, which is the default.
+func WithQueueSnapshot(dir string, ttl time.Duration) Option {
+	return func(g *GhClient) {
+		g.snapshotDir, g.snapshotTTL = dir, ttl
+	}
+}
+
+// WithFreshQueue makes GetPrReviewRequested fetch the queue even when a
+// recent snapshot exists, still saving the result for later runs. Given
+// after WithQueueSnapshot, e.g. to refresh a queue that is already shown.
+func WithFreshQueue() Option {
+	return func(g *GhClient) {
+		g.snapshotTTL = 0
+	}
+}
+
+// queueSnapshot is a FetchResult saved to disk with what it was fetched for.
+type queueSnapshot struct {
+	Saved time.Time
+	Since time.Time
+	// Authors is the authors filter of the fetch, nil for every author.
+	Authors []string
+	Result  *FetchResult
+	// Failures holds Result.Failures, as errors don't survive JSON.
+	Failures map[string]string
+	// Checks are the CI states of the queue's PRs by URL; only saved in the
+	// queue history.
+	Checks map[string]CheckState `json:",omitempty"`
+}
+
+// newQueueSnapshot returns res ready to be saved, fetched with fetch.
+func newQueueSnapshot(fetch FetchOptions, res *FetchResult) queueSnapshot {
+	snap := queueSnapshot{Saved: time.Now(), Since: fetch.since(), Authors: fetch.authors, Failures: make(map[string]string, len(res.Failures))}
+	result := *res
+	result.Failures = nil
+	snap.Result = &result
+	for pr, err := range res.Failures {
+		snap.Failures[pr] = err.Error()
+	}
+	return snap
+}
+
+// readSnapshotFile reads a snapshot written by writeSnapshotFile, restoring
+// its Result.Failures.
+func readSnapshotFile(p string) (*queueSnapshot, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var snap queueSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	if snap.Result == nil {
+		return nil, errors.New("no review queue saved")
+	}
+	snap.Result.Failures = make(map[string]error, len(snap.Failures))
+	for pr, msg := range snap.Failures {
+		snap.Result.Failures[pr] = errors.New(msg)
+	}
+	return &snap, nil
+}
+
+// queueKey identifies the queues of the authenticated user for the fetch
+// options that shape them. The --since window and the authors are left out:
+// snapshots check them when loading instead, so a relative window that has
+// moved on a little still matches, and one snapshot serves every author.
+func (g *GhClient) queueKey(ctx context.Context, fetch FetchOptions) (string, error) {
+	login, err := g.CurrentUser(ctx)
+	if err != nil {
+		return "", err
+	}
+	key, _ := json.Marshal([]any{g.c.BaseURL.String(), login, fetch.IgnoreWhitespace, fetch.IgnorePaths, fetch.Repos, fetch.BaseBranches, fetch.IncludeDrafts, fetch.source(), fetch.IncludeTeamRequests, fetch.reasons()})
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// snapshotPath returns the snapshot file for the authenticated user and the
+// fetch options that shape the queue (see queueKey).
+func (g *GhClient) snapshotPath(ctx context.Context, fetch FetchOptions) (string, bool) {
+	if g.snapshotDir == "" {
+		return "", false
+	}
+	key, err := g.queueKey(ctx, fetch)
+	if err != nil {
+		slog.Debug("review queue snapshot disabled", "err", err)
+		return "", false
+	}
+	return filepath.Join(g.snapshotDir, key+".json"), true
+}
+
+// loadQueueSnapshot returns the saved queue for fetch if one was saved less
+// than the TTL ago over the same window, for every author or the same ones.
+// A snapshot of every author is returned as is for an authors filter.
+func (g *GhClient) loadQueueSnapshot(ctx context.Context, fetch FetchOptions) (*FetchResult, bool) {
+	if g.snapshotTTL <= 0 {
+		return nil, false
+	}
+	p, ok := g.snapshotPath(ctx, fetch)
+	if !ok {
+		return nil, false
+	}
+	snap, err := readSnapshotFile(p)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Debug("ignoring unreadable review queue snapshot", "path", p, "err", err)
+		}
+		return nil, false
+	}
+	age := time.Since(snap.Saved)
+	if age < 0 || age >= g.snapshotTTL || fetch.since().Sub(snap.Since).Abs() >= g.snapshotTTL {
+		return nil, false
+	}
+	if len(snap.Authors) > 0 && !slices.Equal(snap.Authors, fetch.authors) {
+		return nil, false
+	}
+	res := snap.Result
+	for prURL, threadID := range res.ThreadMap {
+		g.rememberThread(prURL, threadID)
+	}
+	slog.Info("reusing the review queue saved by a recent run; pass --refresh to fetch it again", "age", age.Round(time.Second))
+	return res, true
+}
+
+// saveQueueSnapshot saves res for later runs. A failure only costs a refetch,
+// so it is logged rather than returned.
+func (g *GhClient) saveQueueSnapshot(ctx context.Context, fetch FetchOptions, res *FetchResult) {
+	p, ok := g.snapshotPath(ctx, fetch)
+	if !ok {
+		return
+	}
+	if err := writeSnapshotFile(g.snapshotDir, p, newQueueSnapshot(fetch, res)); err != nil {
+		slog.Debug("failed to save review queue snapshot", "path", p, "err", err)
+	}
+}
+
+// writeSnapshotFile writes snap through a temp file renamed into place, so a
+// concurrent run never reads a partial snapshot.
+func writeSnapshotFile(dir, p string, snap queueSnapshot) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	err = errors.Join(json.NewEncoder(f).Encode(snap), f.Close())
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
+// invalidateQueueSnapshots removes every saved queue after a review was
+// submitted, so the next run doesn't offer the reviewed PR again.
+func (g *GhClient) invalidateQueueSnapshots() {
+	if g.snapshotDir == "" {
+		return
+	}
+	entries, err := os.ReadDir(g.snapshotDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".json") {
+			_ = os.Remove(filepath.Join(g.snapshotDir, e.Name()))
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package gh
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueueSnapshot(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{
+		1: "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n",
+		2: "",
+	})
+	g := newTestClient(t, srv)
+	g.login = "me"
+	g.snapshotDir, g.snapshotTTL = t.TempDir(), time.Minute
+
+	if _, err := g.GetPrReviewRequested(context.Background(), FetchOptions{}); err != nil {
+		t.Fatalf("GetPrReviewRequested: %v", err)
+	}
+	// with the server gone, only a snapshot can answer
+	srv.Close()
+	g.threads = nil
+
+	res, err := g.GetPrReviewRequested(context.Background(), FetchOptions{})
+	if err != nil {
+		t.Fatalf("GetPrReviewRequested from snapshot: %v", err)
+	}
+	if len(res.PrMap) != 1 || len(res.UserHashPrMap["alice"]) != 1 {
+		t.Errorf("snapshot queue = %v, want alice's PR 1", res.UserHashPrMap)
+	}
+	if err := res.Failures["o/r#2"]; err == nil || len(res.Failures) != 1 {
+		t.Errorf("snapshot failures = %v, want o/r#2", res.Failures)
+	}
+	if got := g.threads["https://github.com/o/r/pull/1"]; got != "1" {
+		t.Errorf("thread of PR 1 after loading = %q, want 1", got)
+	}
+	// a snapshot of every author also serves a single author
+	if _, err := g.GetPrReviewRequestedForUser(context.Background(), []string{"alice"}, FetchOptions{}); err != nil {
+		t.Errorf("single-author fetch from snapshot: %v", err)
+	}
+
+	for name, fetch := range map[string]FetchOptions{
+		"other filters": {Repos: []string{"o/r"}},
+		"other window":  {Since: time.Now().Add(-time.Hour)},
+	} {
+		if _, err := g.GetPrReviewRequested(context.Background(), fetch); err == nil {
+			t.Errorf("%s: reused the snapshot, want a fetch", name)
+		}
+	}
+
+	WithFreshQueue()(g)
+	if _, err := g.GetPrReviewRequested(context.Background(), FetchOptions{}); err == nil {
+		t.Error("refresh reused the snapshot, want a fetch")
+	}
+	g.snapshotTTL = time.Minute
+	g.invalidateQueueSnapshots()
+	if _, err := g.GetPrReviewRequested(context.Background(), FetchOptions{}); err == nil {
+		t.Error("invalidated snapshot was reused, want a fetch")
+	}
+}
+
+func TestQueueSnapshotAuthors(t *testing.T) {
+	srv := fakeGitHub(t, map[int]string{1: "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-old\n+new\n"})
+	g := newTestClient(t, srv)
+	g.login = "me"
+	g.snapshotDir, g.snapshotTTL = t.TempDir(), time.Minute
+
+	if _, err := g.GetPrReviewRequestedForUser(context.Background(), []string{"alice"}, FetchOptions{}); err != nil {
+		t.Fatalf("GetPrReviewRequestedForUser: %v", err)
+	}
+	srv.Close()
+	if _, err := g.GetPrReviewRequestedForUser(context.Background(), []string{"ALICE"}, FetchOptions{}); err != nil {
+		t.Errorf("same author: %v, want the snapshot", err)
+	}
+	if _, err := g.GetPrReviewRequested(context.Background(), FetchOptions{}); err == nil {
+		t.Error("a single author's snapshot served every author, want a fetch")
+	}
+}
@@ -0,0 +1,90 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// PrTeamMap maps a PR identifier (HTML URL) to the teams ("org/slug") of
+// the authenticated user whose review it requests, for PRs in the queue only
+// through a team rather than a request of the user's own.
+type PrTeamMap map[string][]string
+
+// Tag describes how prKey's review was requested, e.g. "team org/infra",
+// or "" for a request of the user's own.
+func (m PrTeamMap) Tag(prKey string) string {
+	teams := m[prKey]
+	if len(teams) == 0 {
+		return ""
+	}
+	return "team " + strings.Join(teams, ", ")
+}
+
+// UserTeams returns the teams ("org/slug", lowercased and sorted) the
+// authenticated user belongs to. The first successful lookup is cached on
+// the client.
+func (g *GhClient) UserTeams(ctx context.Context) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.teams != nil {
+		return g.teams, nil
+	}
+	teams := []string{}
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := g.c.Teams.ListUserTeams(ctx, opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list your teams: %w", err)
+		}
+		for _, t := range page {
+			teams = append(teams, teamName(t.GetOrganization().GetLogin(), t))
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	slices.Sort(teams)
+	g.teams = teams
+	return g.teams, nil
+}
+
+// teamName returns "org/slug" for team of org, lowercased.
+func teamName(org string, team *github.Team) string {
+	return strings.ToLower(org + "/" + team.GetSlug())
+}
+
+// requestedTeams returns the authenticated user's teams whose review pr
+// requests, or none if the user's own review is requested as well.
+func (g *GhClient) requestedTeams(ctx context.Context, owner string, pr *github.PullRequest) ([]string, error) {
+	login, err := g.CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range pr.RequestedReviewers {
+		if strings.EqualFold(u.GetLogin(), login) {
+			return nil, nil
+		}
+	}
+	mine, err := g.UserTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var teams []string
+	for _, t := range pr.RequestedTeams {
+		if name := teamName(owner, t); slices.Contains(mine, name) {
+			teams = append(teams, name)
+		}
+	}
+	return teams, nil
+}
+
+// teamReviewRequestedQuery finds the open PRs requesting the review of team
+// ("org/slug").
+func teamReviewRequestedQuery(team string) string {
+	return "is:open is:pr team-review-requested:" + team + " archived:false"
+}
@@ -0,0 +1,89 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// PR 1 requests me, PR 2 my team o/infra and PR 3 another team.
+func TestIncludeTeamRequests(t *testing.T) {
+	reviewers := map[int]string{
+		1: `"requested_reviewers":[{"login":"me"}],"requested_teams":[{"slug":"infra"}]`,
+		2: `"requested_reviewers":[{"login":"bob"}],"requested_teams":[{"slug":"Infra"}]`,
+		3: `"requested_teams":[{"slug":"web"}]`,
+	}
+	var queries []string
+	var srv *httptest.Server
+	srv = newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/user/teams":
+			fmt.Fprint(w, `[{"slug":"infra","organization":{"login":"O"}}]`)
+		case r.URL.Path == "/notifications":
+			fmt.Fprint(w, `[`+
+				`{"id":"1","reason":"review_requested","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"PullRequest","url":"x/pulls/1"}},`+
+				`{"id":"2","reason":"team_mention","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"PullRequest","url":"x/pulls/2"}},`+
+				`{"id":"3","reason":"subscribed","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"PullRequest","url":"x/pulls/3"}},`+
+				`{"id":"4","reason":"mention","repository":{"name":"r","owner":{"login":"o"}},"subject":{"type":"Issue","url":"x/issues/4"}}]`)
+		case r.URL.Path == "/search/issues":
+			q := r.URL.Query().Get("q")
+			queries = append(queries, q)
+			num := 1
+			if strings.Contains(q, "team-review-requested:o/infra") {
+				num = 2
+			}
+			fmt.Fprintf(w, `{"total_count":1,"items":[{"number":%d,"repository_url":"https://api.github.com/repos/o/r"}]}`, num)
+		case strings.HasSuffix(r.URL.Path, "/commits"):
+			fmt.Fprint(w, `[]`)
+		case strings.HasPrefix(r.URL.Path, "/repos/o/r/pulls/"):
+			var num int
+			fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/repos/o/r/pulls/"), "%d", &num)
+			if r.Header.Get("Accept") == "application/vnd.github.diff" {
+				fmt.Fprintf(w, "diff --git a/%d.go b/%d.go\n@@ -1 +1 @@\n-old\n+new\n", num, num)
+				return
+			}
+			fmt.Fprintf(w, `{"number":%d,"state":"open","url":"%s/repos/o/r/pulls/%d","html_url":"https://github.com/o/r/pull/%d","user":{"login":"alice"},"base":{"ref":"main"},%s}`, num, apiURL(srv), num, num, reviewers[num])
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	pr := func(n int) string { return fmt.Sprintf("https://github.com/o/r/pull/%d", n) }
+
+	for _, source := range []QueueSource{SourceNotifications, SourceSearch} {
+		t.Run(string(source), func(t *testing.T) {
+			g := newTestClient(t, srv)
+			g.login = "me"
+			ctx := context.Background()
+
+			res, err := g.GetPrReviewRequested(ctx, FetchOptions{Source: source})
+			if err != nil {
+				t.Fatalf("GetPrReviewRequested: %v", err)
+			}
+			if len(res.PrMap) != 1 || len(res.TeamMap) != 0 {
+				t.Errorf("without team requests got %v tagged %v, want PR 1 only", res.PrMap, res.TeamMap)
+			}
+
+			res, err = g.GetPrReviewRequested(ctx, FetchOptions{Source: source, IncludeTeamRequests: true})
+			if err != nil {
+				t.Fatalf("GetPrReviewRequested: %v", err)
+			}
+			if _, ok := res.PrMap[pr(1)]; !ok || len(res.PrMap) != 2 {
+				t.Errorf("PrMap = %v, want PRs 1 and 2", res.PrMap)
+			}
+			if got := res.TeamMap.Tag(pr(2)); got != "team o/infra" {
+				t.Errorf("tag of PR 2 = %q, want team o/infra", got)
+			}
+			if got := res.TeamMap.Tag(pr(1)); got != "" {
+				t.Errorf("tag of PR 1 = %q, want none as it requests me", got)
+			}
+		})
+	}
+	if !slices.ContainsFunc(queries, func(q string) bool { return strings.Contains(q, "team-review-requested:o/infra") }) {
+		t.Errorf("queries = %q, want a team search", queries)
+	}
+}
@@ -0,0 +1,106 @@
+package gh
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const defaultHost = "github.com"
+
+// resolveToken looks up a GitHub token from, in order: the GITHUB_TOKEN and
+// GH_TOKEN environment variables, `gh auth token`, and the gh CLI hosts.yml
+// config for host. The first non-empty source wins; the returned source names it.
+func resolveToken(host string) (token string, source string, err error) {
+	for _, env := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if t := strings.TrimSpace(os.Getenv(env)); t != "" {
+			return t, env, nil
+		}
+	}
+	if t := ghAuthToken(host); t != "" {
+		return t, "gh auth token", nil
+	}
+	if path := ghHostsFile(); path != "" {
+		if t := hostsFileToken(path, host); t != "" {
+			return t, path, nil
+		}
+	}
+	return "", "", errors.New("no GitHub token found: set GITHUB_TOKEN or GH_TOKEN, or log in with `gh auth login`")
+}
+
+// ghAuthToken asks the gh CLI for its stored token for host. It returns an
+// empty string if gh is not installed or not logged in.
+func ghAuthToken(host string) string {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+	out, err := exec.Command("gh", "auth", "token", "--hostname", host).Output()
+	if err != nil {
+		slog.Debug("gh auth token failed", "err", err)
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// ghHostsFile returns the path of the gh CLI hosts.yml, honoring GH_CONFIG_DIR
+// and XDG_CONFIG_HOME the same way gh does.
+func ghHostsFile() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml")
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh", "hosts.yml")
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("AppData"); dir != "" {
+			return filepath.Join(dir, "GitHub CLI", "hosts.yml")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gh", "hosts.yml")
+}
+
+func hostsFileToken(path, host string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	return parseHostsToken(f, host)
+}
+
+// parseHostsToken extracts the oauth_token for host from a gh hosts.yml file.
+// Only the small subset of YAML that gh writes is understood: a top-level
+// "host:" key followed by indented "key: value" lines.
+func parseHostsToken(r io.Reader, host string) string {
+	scanner := bufio.NewScanner(r)
+	inHost := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inHost = strings.TrimSuffix(trimmed, ":") == host
+			continue
+		}
+		if !inHost {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if ok && strings.TrimSpace(key) == "oauth_token" {
+			return strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package gh
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHostsToken(t *testing.T) {
+	hosts := `github.com:
+    user: alice
+    oauth_token: gho_public
+    git_protocol: https
+ghe.example.com:
+    oauth_token: "gho_enterprise"
+`
+	if got := parseHostsToken(strings.NewReader(hosts), "github.com"); got != "gho_public" {
+		t.Fatalf("github.com token = %q, want %q", got, "gho_public")
+	}
+	if got := parseHostsToken(strings.NewReader(hosts), "ghe.example.com"); got != "gho_enterprise" {
+		t.Fatalf("enterprise token = %q, want %q", got, "gho_enterprise")
+	}
+	if got := parseHostsToken(strings.NewReader(hosts), "missing.example.com"); got != "" {
+		t.Fatalf("missing host token = %q, want empty", got)
+	}
+}
+
+func TestResolveTokenPrefersGithubToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "from-github-token")
+	t.Setenv("GH_TOKEN", "from-gh-token")
+	token, source, err := resolveToken(defaultHost)
+	if err != nil {
+		t.Fatalf("resolveToken: %v", err)
+	}
+	if token != "from-github-token" || source != "GITHUB_TOKEN" {
+		t.Fatalf("got (%q, %q), want GITHUB_TOKEN value", token, source)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "")
+	token, source, err = resolveToken(defaultHost)
+	if err != nil {
+		t.Fatalf("resolveToken: %v", err)
+	}
+	if token != "from-gh-token" || source != "GH_TOKEN" {
+		t.Fatalf("got (%q, %q), want GH_TOKEN value", token, source)
+	}
+}
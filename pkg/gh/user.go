@@ -0,0 +1,126 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// CurrentUser returns the login of the user the token belongs to. The first
+// successful lookup is cached on the client.
+func (g *GhClient) CurrentUser(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.login != "" {
+		return g.login, nil
+	}
+	u, _, err := g.c.Users.Get(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the authenticated user: %w", err)
+	}
+	if u.GetLogin() == "" {
+		return "", fmt.Errorf("authenticated user has no login")
+	}
+	g.login = u.GetLogin()
+	return g.login, nil
+}
+
+// HasApproved reports whether the authenticated user's latest review on pr
+// approves its current head commit.
+func (g *GhClient) HasApproved(ctx context.Context, pr *github.PullRequest) (bool, error) {
+	latest, err := g.latestReview(ctx, pr)
+	if err != nil {
+		return false, err
+	}
+	return latest != nil && latest.GetState() == "APPROVED" && latest.GetCommitID() == pr.GetHead().GetSHA(), nil
+}
+
+// DismissReview dismisses the authenticated user's approval of pr with
+// reason as the dismissal message. It targets the review ApprovePr created
+// on this client if there is one, and otherwise the user's latest review,
+// which must be an approval.
+func (g *GhClient) DismissReview(ctx context.Context, pr *github.PullRequest, reason string) error {
+	owner, repo, err := prRepo(pr)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	id := g.reviews[pr.GetHTMLURL()]
+	g.mu.Unlock()
+	if id == 0 {
+		latest, err := g.latestReview(ctx, pr)
+		if err != nil {
+			return err
+		}
+		if latest == nil || latest.GetState() != "APPROVED" {
+			return fmt.Errorf("no approval of yours to dismiss on PR %s", pr.GetHTMLURL())
+		}
+		id = latest.GetID()
+	}
+	_, _, err = g.c.PullRequests.DismissReview(ctx, owner, repo, pr.GetNumber(), id, &github.PullRequestReviewDismissalRequest{Message: &reason})
+	if err != nil {
+		return fmt.Errorf("failed to dismiss review %d on PR %s: %w", id, pr.GetHTMLURL(), err)
+	}
+	g.mu.Lock()
+	delete(g.reviews, pr.GetHTMLURL())
+	g.mu.Unlock()
+	g.invalidateQueueSnapshots()
+	return nil
+}
+
+// DisableAutoMerge turns off auto-merge on pr, as ApprovePr enables it, so
+// a PR whose approval was withdrawn doesn't merge anyway.
+func (g *GhClient) DisableAutoMerge(ctx context.Context, pr *github.PullRequest) error {
+	nodeID := pr.GetNodeID()
+	if nodeID == "" {
+		return fmt.Errorf("PR %s has no node ID, can't disable auto-merge", pr.GetHTMLURL())
+	}
+	const mutation = `mutation DisableAutoMerge($pullId:ID!) { disablePullRequestAutoMerge(input:{pullRequestId:$pullId}) { pullRequest { id } } }`
+	var out struct{}
+	if err := g.graphQL(ctx, mutation, map[string]any{"pullId": nodeID}, &out); err != nil {
+		return fmt.Errorf("failed to disable auto-merge for PR %s: %w", pr.GetHTMLURL(), err)
+	}
+	return nil
+}
+
+// latestReview returns the authenticated user's latest review on pr other
+// than plain comments, or nil if there is none.
+func (g *GhClient) latestReview(ctx context.Context, pr *github.PullRequest) (*github.PullRequestReview, error) {
+	login, err := g.CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owner, repo, err := prRepo(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *github.PullRequestReview
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := g.c.PullRequests.ListReviews(ctx, owner, repo, pr.GetNumber(), opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reviews for PR %s: %w", pr.GetHTMLURL(), err)
+		}
+		for _, r := range reviews {
+			// plain comments don't change whether a reviewer approved
+			if r.GetUser().GetLogin() == login && r.GetState() != "COMMENTED" {
+				latest = r
+			}
+		}
+		if resp.NextPage == 0 {
+			return latest, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// prRepo returns the owner and name of the repository pr targets.
+func prRepo(pr *github.PullRequest) (owner, repo string, err error) {
+	base := pr.GetBase()
+	if base == nil || base.GetRepo() == nil || base.GetRepo().GetOwner() == nil {
+		return "", "", fmt.Errorf("unable to determine owner/repo for PR %s", pr.GetHTMLURL())
+	}
+	return base.GetRepo().GetOwner().GetLogin(), base.GetRepo().GetName(), nil
+}
@@ -0,0 +1,178 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v72/github"
+)
+
+func TestHasApproved(t *testing.T) {
+	tests := []struct {
+		name    string
+		reviews string
+		want    bool
+	}{
+		{
+			name:    "approved at head",
+			reviews: `[{"user":{"login":"alice"},"state":"APPROVED","commit_id":"old"},{"user":{"login":"alice"},"state":"APPROVED","commit_id":"head"},{"user":{"login":"alice"},"state":"COMMENTED","commit_id":"head"}]`,
+			want:    true,
+		},
+		{
+			name:    "approved an older commit",
+			reviews: `[{"user":{"login":"alice"},"state":"APPROVED","commit_id":"old"}]`,
+		},
+		{
+			name:    "changes requested after approving",
+			reviews: `[{"user":{"login":"alice"},"state":"APPROVED","commit_id":"head"},{"user":{"login":"alice"},"state":"CHANGES_REQUESTED","commit_id":"head"}]`,
+		},
+		{
+			name:    "someone else approved",
+			reviews: `[{"user":{"login":"bob"},"state":"APPROVED","commit_id":"head"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userCalls := 0
+			srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/user":
+					userCalls++
+					fmt.Fprint(w, `{"login":"alice"}`)
+				case "/repos/o/r/pulls/1/reviews":
+					fmt.Fprint(w, tt.reviews)
+				default:
+					http.NotFound(w, r)
+				}
+			})
+			defer srv.Close()
+			g := newTestClient(t, srv)
+
+			pr := &github.PullRequest{
+				Number: github.Ptr(1),
+				Base: &github.PullRequestBranch{
+					Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}},
+				},
+				Head: &github.PullRequestBranch{SHA: github.Ptr("head")},
+			}
+			for i := 0; i < 2; i++ {
+				got, err := g.HasApproved(context.Background(), pr)
+				if err != nil {
+					t.Fatalf("HasApproved: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("HasApproved = %v, want %v", got, tt.want)
+				}
+			}
+			if userCalls != 1 {
+				t.Errorf("fetched the current user %d times, want 1", userCalls)
+			}
+		})
+	}
+}
+
+func TestCurrentUserRetriesAfterError(t *testing.T) {
+	calls := 0
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"Bad credentials"}`)
+			return
+		}
+		fmt.Fprint(w, `{"login":"alice"}`)
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	if _, err := g.CurrentUser(context.Background()); err == nil {
+		t.Fatal("CurrentUser with bad credentials succeeded, want error")
+	}
+	login, err := g.CurrentUser(context.Background())
+	if err != nil || login != "alice" {
+		t.Fatalf("CurrentUser = %q, %v; want alice", login, err)
+	}
+}
+
+func TestDismissReview(t *testing.T) {
+	var dismissed []string
+	var dismissal github.PullRequestReviewDismissalRequest
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/user":
+			fmt.Fprint(w, `{"login":"alice"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/o/r/pulls/1/reviews":
+			fmt.Fprint(w, `{"id":42}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/pulls/1/reviews":
+			fmt.Fprint(w, `[{"id":7,"user":{"login":"alice"},"state":"APPROVED"},{"id":8,"user":{"login":"bob"},"state":"APPROVED"}]`)
+		case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/dismissals"):
+			dismissed = append(dismissed, r.URL.Path)
+			_ = json.NewDecoder(r.Body).Decode(&dismissal)
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+	pr := &github.PullRequest{
+		Number:  github.Ptr(1),
+		HTMLURL: github.Ptr("https://github.com/o/r/pull/1"),
+		Base: &github.PullRequestBranch{
+			Repo: &github.Repository{Name: github.Ptr("r"), Owner: &github.User{Login: github.Ptr("o")}},
+		},
+	}
+
+	// the review created by ApprovePr is dismissed without listing reviews
+	if _, err := g.ApprovePr(context.Background(), pr, ApproveOptions{ApproveOnly: true}); err != nil {
+		t.Fatalf("ApprovePr: %v", err)
+	}
+	if err := g.DismissReview(context.Background(), pr, "oops"); err != nil {
+		t.Fatalf("DismissReview: %v", err)
+	}
+	// afterwards the user's latest approval is looked up
+	if err := g.DismissReview(context.Background(), pr, "oops"); err != nil {
+		t.Fatalf("DismissReview: %v", err)
+	}
+	want := []string{"/repos/o/r/pulls/1/reviews/42/dismissals", "/repos/o/r/pulls/1/reviews/7/dismissals"}
+	if strings.Join(dismissed, ",") != strings.Join(want, ",") {
+		t.Errorf("dismissed %v, want %v", dismissed, want)
+	}
+	if dismissal.GetMessage() != "oops" {
+		t.Errorf("dismissal message = %q, want oops", dismissal.GetMessage())
+	}
+}
+
+func TestDisableAutoMerge(t *testing.T) {
+	var req struct {
+		Query     string            `json:"query"`
+		Variables map[string]string `json:"variables"`
+	}
+	srv := newAPIServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprint(w, `{"data":{"disablePullRequestAutoMerge":{"pullRequest":{"id":"node"}}}}`)
+	})
+	defer srv.Close()
+	g := newTestClient(t, srv)
+
+	pr := &github.PullRequest{NodeID: github.Ptr("node"), HTMLURL: github.Ptr("https://github.com/o/r/pull/1")}
+	if err := g.DisableAutoMerge(context.Background(), pr); err != nil {
+		t.Fatalf("DisableAutoMerge: %v", err)
+	}
+	if !strings.Contains(req.Query, "disablePullRequestAutoMerge") || req.Variables["pullId"] != "node" {
+		t.Errorf("sent %+v, want disablePullRequestAutoMerge of node", req)
+	}
+	if err := g.DisableAutoMerge(context.Background(), &github.PullRequest{}); err == nil {
+		t.Error("DisableAutoMerge of a PR without node ID succeeded")
+	}
+}
@@ -1,6 +1,139 @@
 package gh
 
-import "strings"
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// isIgnoredPath reports whether file matches any of patterns, using the
+// syntax described on DefaultIgnorePaths.
+func isIgnoredPath(file string, patterns []string) bool {
+	for _, p := range patterns {
+		switch {
+		case strings.HasSuffix(p, "/"):
+			if strings.HasPrefix(file, p) || strings.Contains(file, "/"+p) {
+				return true
+			}
+		case !strings.Contains(p, "/"):
+			if ok, _ := path.Match(p, path.Base(file)); ok {
+				return true
+			}
+		default:
+			if ok, _ := path.Match(p, file); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseHunkHeader extracts the old and new start lines from a unified diff
+// hunk header such as "@@ -10,7 +12,8 @@ func foo()". Missing or malformed
+// numbers are returned as 0.
+func parseHunkHeader(line string) (oldStart, newStart int) {
+	fields := strings.Fields(line)
+	for _, f := range fields[1:] {
+		if f == "@@" {
+			break
+		}
+		start, _, _ := strings.Cut(f[1:], ",")
+		n, _ := strconv.Atoi(start)
+		switch f[0] {
+		case '-':
+			oldStart = n
+		case '+':
+			newStart = n
+		}
+	}
+	return oldStart, newStart
+}
+
+// maxDiffLineLen caps how much of a single diff line is kept in memory.
+// Longer lines (minified or generated files) keep this much followed by the
+// length and SHA-256 of the rest, so they still hash by their full content.
+const maxDiffLineLen = 1 << 20
+
+// newDiffScanner returns a scanner over the lines of a unified diff. Unlike
+// bufio.ScanLines it keeps carriage returns, which are part of the content,
+// and never fails on overlong lines (see maxDiffLineLen).
+func newDiffScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxDiffLineLen+1)
+	split := &diffLineSplitter{max: maxDiffLineLen}
+	sc.Split(split.split)
+	return sc
+}
+
+// diffLineSplitter splits lines like bufio.ScanLines, digesting the part of
+// a line beyond max instead of buffering it.
+type diffLineSplitter struct {
+	max    int
+	prefix []byte    // start of the overlong line being read, or nil
+	rest   hash.Hash // digest of the bytes past prefix
+	n      int       // number of bytes past prefix
+}
+
+func (s *diffLineSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	i := bytes.IndexByte(data, '\n')
+	if s.prefix == nil {
+		switch {
+		case i >= 0 && i <= s.max:
+			return i + 1, data[:i], nil
+		case i < 0 && len(data) < s.max:
+			if atEOF && len(data) > 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		}
+		s.prefix = append([]byte(nil), data[:s.max]...)
+		s.rest = sha256.New()
+		return s.max, nil, nil
+	}
+	end := len(data)
+	if i >= 0 {
+		end = i
+	}
+	s.rest.Write(data[:end])
+	s.n += end
+	if i < 0 && !atEOF {
+		return end, nil, nil
+	}
+	line := fmt.Appendf(s.prefix, " [%d more bytes, sha256:%x]", s.n, s.rest.Sum(nil))
+	s.prefix, s.rest, s.n = nil, nil, 0
+	if i >= 0 {
+		end++
+	}
+	return end, line, nil
+}
+
+// binaryChangeLine describes a binary file change for hashing and display,
+// e.g. "binary change in logo.png (index 1a2b3c4..5d6e7f8)". indexLine is the
+// file's "index" header line, if the diff had one.
+func binaryChangeLine(file, indexLine string) string {
+	line := "binary change in " + file
+	if fields := strings.Fields(indexLine); len(fields) >= 2 {
+		line += " (index " + fields[1] + ")"
+	}
+	return line
+}
+
+// renameChangeLine describes a renamed or copied file for hashing and
+// display, e.g. "renamed old.go → new.go".
+func renameChangeLine(from, to string, copied bool) string {
+	verb := "renamed"
+	if copied {
+		verb = "copied"
+	}
+	return fmt.Sprintf("%s %s → %s", verb, from, to)
+}
 
 // normalizeHunkLine normalizes a diff line (prefixed with + or -) so that
 // cosmetic differences don't produce different hashes. Leading whitespace after
@@ -22,34 +155,126 @@ func normalizeHunkLine(line, filename string) string {
 	return line[:1] + content
 }
 
+// ignoreWhitespaceChanges returns the hunk lines used for hashing when
+// whitespace is ignored: trailing whitespace is trimmed and a removed line
+// re-added with identical content at the same position of the added run that
+// follows it (a pure re-indent, since normalizeHunkLine already strips
+// indentation) is dropped along with its counterpart. Lines that move are
+// kept, so a reorder still hashes as a change. A whitespace-only hunk
+// reduces to no lines at all.
+func ignoreWhitespaceChanges(lines []string) []string {
+	trimmed := make([]string, len(lines))
+	for i, l := range lines {
+		trimmed[i] = strings.TrimRight(l, " \t\r")
+	}
+
+	var out []string
+	for i := 0; i < len(trimmed); {
+		if trimmed[i][0] != '-' {
+			out = append(out, trimmed[i])
+			i++
+			continue
+		}
+		// pair a run of removals with the run of additions right after it
+		removedEnd := i
+		for removedEnd < len(trimmed) && trimmed[removedEnd][0] == '-' {
+			removedEnd++
+		}
+		addedEnd := removedEnd
+		for addedEnd < len(trimmed) && trimmed[addedEnd][0] == '+' {
+			addedEnd++
+		}
+		removed, added := trimmed[i:removedEnd], trimmed[removedEnd:addedEnd]
+		same := make([]bool, min(len(removed), len(added)))
+		for k := range same {
+			same[k] = removed[k][1:] == added[k][1:]
+		}
+		for k, l := range removed {
+			if k >= len(same) || !same[k] {
+				out = append(out, l)
+			}
+		}
+		for k, l := range added {
+			if k >= len(same) || !same[k] {
+				out = append(out, l)
+			}
+		}
+		i = addedEnd
+	}
+	return out
+}
+
 func isYAMLFile(filename string) bool {
 	lower := strings.ToLower(filename)
 	return strings.HasSuffix(lower, ".yml") || strings.HasSuffix(lower, ".yaml")
 }
 
-func cleanDependabotMessage(input string) string {
+// cleanBotMessage strips HTML tags and bot boilerplate (see stripBotNoise)
+// from a PR description or comment.
+func cleanBotMessage(input string) string {
 	withoutHtml := removeHtmlTags(input)
-	cleaned := removeDependabotTrailingCommand(withoutHtml)
+	cleaned := stripBotNoise(withoutHtml)
 	return removeMultipleNewlines(cleaned)
 }
 
+var (
+	// htmlTagRe matches what looks like an HTML tag: a name right after the
+	// "<", then attributes or the end. "a < b > c" and "Map<K, V>" don't.
+	htmlTagRe     = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?>`)
+	htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+	inlineCodeRe  = regexp.MustCompile("`[^`\n]*`")
+)
+
+// removeHtmlTags strips HTML tags and comments from markdown, leaving fenced
+// code blocks and inline code spans untouched.
 func removeHtmlTags(input string) string {
 	var b strings.Builder
 	b.Grow(len(input))
-	inTag := false
-	for _, char := range input {
-		switch {
-		case char == '<':
-			inTag = true
-		case char == '>':
-			inTag = false
-		case !inTag:
-			b.WriteRune(char)
+	lines := strings.SplitAfter(input, "\n")
+	for i := 0; i < len(lines); {
+		// collect a run of prose lines, then copy the fenced block after it
+		start := i
+		for i < len(lines) && !isCodeFence(lines[i]) {
+			i++
+		}
+		b.WriteString(stripTagsOutsideCode(strings.Join(lines[start:i], "")))
+		if i == len(lines) {
+			break
+		}
+		fence := strings.TrimSpace(lines[i])[:3]
+		b.WriteString(lines[i])
+		for i++; i < len(lines); i++ {
+			b.WriteString(lines[i])
+			if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+				i++
+				break
+			}
 		}
 	}
 	return b.String()
 }
 
+// isCodeFence reports whether line opens or closes a fenced code block.
+func isCodeFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// stripTagsOutsideCode removes HTML comments and tags from prose, skipping
+// inline code spans.
+func stripTagsOutsideCode(prose string) string {
+	prose = htmlCommentRe.ReplaceAllString(prose, "")
+	var b strings.Builder
+	last := 0
+	for _, span := range inlineCodeRe.FindAllStringIndex(prose, -1) {
+		b.WriteString(htmlTagRe.ReplaceAllString(prose[last:span[0]], ""))
+		b.WriteString(prose[span[0]:span[1]])
+		last = span[1]
+	}
+	b.WriteString(htmlTagRe.ReplaceAllString(prose[last:], ""))
+	return b.String()
+}
+
 func removeDependabotTrailingCommand(input string) string {
 	if strings.TrimSpace(input) == "" {
 		return input
@@ -1,6 +1,13 @@
 package gh
 
-import "testing"
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
 
 func TestNormalizeHunkLine(t *testing.T) {
 	tests := []struct {
@@ -112,3 +119,140 @@ You can trigger Dependabot actions by commenting on this PR:
 		t.Fatalf("no-block variant failed: got %q", got)
 	}
 }
+
+func TestIgnoreWhitespaceChanges(t *testing.T) {
+	original := []string{"-foo := 1", "+foo := 2"}
+	variants := map[string][]string{
+		"trailing whitespace":   {"-foo := 1  ", "+foo := 2\t"},
+		"with re-indented line": {"-foo := 1", "-bar()", "+foo := 2", "+bar()"},
+	}
+	want := strings.Join(ignoreWhitespaceChanges(original), "\n")
+	for name, lines := range variants {
+		if got := strings.Join(ignoreWhitespaceChanges(lines), "\n"); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+
+	if got := ignoreWhitespaceChanges([]string{"-x", "+x  ", "-y", "+y"}); len(got) != 0 {
+		t.Errorf("whitespace-only hunk = %q, want no lines", got)
+	}
+	// a line removed twice but re-added once keeps one removal
+	if got := strings.Join(ignoreWhitespaceChanges([]string{"-x", "-x", "+x"}), "\n"); got != "-x" {
+		t.Errorf("unbalanced hunk = %q, want %q", got, "-x")
+	}
+	// reordered lines are a change, not a re-indent
+	reorder := []string{"-a()", "-b()", "+b()", "+a()"}
+	if got := ignoreWhitespaceChanges(reorder); !slices.Equal(got, reorder) {
+		t.Errorf("reordered hunk = %q, want %q", got, reorder)
+	}
+	if got := ignoreWhitespaceChanges([]string{"-x", "+y", "-y", "+x"}); len(got) != 4 {
+		t.Errorf("moved line hunk = %q, want all 4 lines", got)
+	}
+}
+
+func TestParseHunkHeader(t *testing.T) {
+	tests := []struct {
+		line             string
+		wantOld, wantNew int
+	}{
+		{"@@ -10,7 +12,8 @@ func foo()", 10, 12},
+		{"@@ -1 +1 @@", 1, 1},
+		{"@@ -0,0 +1,3 @@", 0, 1},
+		{"@@ garbage @@", 0, 0},
+	}
+	for _, tt := range tests {
+		oldStart, newStart := parseHunkHeader(tt.line)
+		if oldStart != tt.wantOld || newStart != tt.wantNew {
+			t.Errorf("parseHunkHeader(%q) = %d, %d; want %d, %d", tt.line, oldStart, newStart, tt.wantOld, tt.wantNew)
+		}
+	}
+}
+
+func TestIsIgnoredPath(t *testing.T) {
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{"go.sum", true},
+		{"tools/go.sum", true},
+		{"web/package-lock.json", true},
+		{"vendor/github.com/x/y.go", true},
+		{"web/node_modules/left-pad/index.js", true},
+		{"go.mod", false},
+		{"pkg/vendored.go", false},
+		{"docs/generated/api.md", true},
+		{"docs/api.md", false},
+	}
+	patterns := append([]string{"docs/generated/*.md"}, DefaultIgnorePaths...)
+	for _, tt := range tests {
+		if got := isIgnoredPath(tt.file, patterns); got != tt.want {
+			t.Errorf("isIgnoredPath(%q) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+	if isIgnoredPath("go.sum", nil) {
+		t.Error("isIgnoredPath with no patterns matched go.sum")
+	}
+}
+
+func TestDiffLineSplitter(t *testing.T) {
+	long := strings.Repeat("x", 10)
+	input := "+short\r\n" + long + "\n-end"
+	sc := bufio.NewScanner(strings.NewReader(input))
+	sc.Buffer(make([]byte, 0, 4), 9)
+	split := &diffLineSplitter{max: 8}
+	sc.Split(split.split)
+	var got []string
+	for sc.Scan() {
+		got = append(got, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	want := []string{
+		"+short\r",
+		fmt.Sprintf("xxxxxxxx [2 more bytes, sha256:%x]", sha256.Sum256([]byte("xx"))),
+		"-end",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestDiffLineSplitterLongLastLine(t *testing.T) {
+	sc := bufio.NewScanner(strings.NewReader("abcdefghij"))
+	sc.Buffer(make([]byte, 0, 4), 5)
+	split := &diffLineSplitter{max: 4}
+	sc.Split(split.split)
+	var got []string
+	for sc.Scan() {
+		got = append(got, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(got) != 1 || !strings.HasPrefix(got[0], "abcd [6 more bytes, sha256:") {
+		t.Errorf("lines = %q, want one truncated line", got)
+	}
+}
+
+func TestRemoveHtmlTags(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"comparison", "if a < b > c then", "if a < b > c then"},
+		{"generics", "returns Map<String, Int> now", "returns Map<String, Int> now"},
+		{"details block", "<details>\n<summary>Release notes</summary>\n\nFixed a < b > c.\n</details>", "\nRelease notes\n\nFixed a < b > c.\n"},
+		{"attributes and void tags", `<a href="https://x">link</a><br/>end`, "linkend"},
+		{"comment", "keep <!-- rebase-check --> this", "keep  this"},
+		{"inline code", "use `<div>` here <b>now</b>", "use `<div>` here now"},
+		{"fenced code", "<p>intro</p>\n```html\n<div class=\"x\"></div>\n```\n<p>outro</p>", "intro\n```html\n<div class=\"x\"></div>\n```\noutro"},
+		{"unclosed fence", "<i>x</i>\n~~~\n<b>kept</b>", "x\n~~~\n<b>kept</b>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := removeHtmlTags(tt.input); got != tt.want {
+				t.Errorf("removeHtmlTags(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package gui
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mallendem/gh-pr-review/pkg/browser"
+)
+
+// openedMsg reports the outcome of openCmd.
+type openedMsg struct {
+	url string
+	err error
+}
+
+// openCmd opens url in the default browser.
+func openCmd(url string) tea.Cmd {
+	return func() tea.Msg {
+		return openedMsg{url: url, err: browser.Open(url)}
+	}
+}
+
+// openStatus describes the outcome of openCmd for the status line. Without
+// a browser (e.g. over SSH) it shows the URL so it can be opened by hand.
+func openStatus(msg openedMsg) string {
+	switch {
+	case errors.Is(msg.err, browser.ErrNoBrowser):
+		return "no browser available; PR is at " + msg.url
+	case msg.err != nil:
+		return "open failed: " + msg.err.Error()
+	}
+	return "opened " + msg.url
+}
@@ -0,0 +1,32 @@
+package gui
+
+import (
+	"os"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// copiedMsg reports the outcome of copyCmd.
+type copiedMsg struct {
+	text string
+	err  error
+}
+
+// copyCmd copies text to the system clipboard. Over SSH, or when no local
+// clipboard tool is available, it falls back to an OSC 52 escape sequence,
+// which asks the terminal emulator to set its clipboard instead.
+func copyCmd(text string) tea.Cmd {
+	return func() tea.Msg {
+		if os.Getenv("SSH_TTY") == "" {
+			if err := clipboard.WriteAll(text); err == nil {
+				return copiedMsg{text: text}
+			}
+		}
+		// stderr reaches the same terminal without going through the
+		// renderer's stdout stream
+		_, err := osc52.New(text).WriteTo(os.Stderr)
+		return copiedMsg{text: text, err: err}
+	}
+}
@@ -2,16 +2,29 @@ package gui
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v72/github"
 	"github.com/mallendem/gh-pr-review/pkg/approve"
 	"github.com/mallendem/gh-pr-review/pkg/gh"
 )
@@ -24,6 +37,7 @@ import (
 type settings struct {
 	reviewComment string // comment to leave on approved PRs
 	contextLines  int    // number of context lines to show around changes
+	compactWidth  int    // terminal width below which the compact layout is used; 0 never
 }
 
 // defaultSettings returns settings with default values.
@@ -31,12 +45,13 @@ func defaultSettings() settings {
 	return settings{
 		reviewComment: "This change has been reviewed by a human with a batch tool.",
 		contextLines:  10,
+		compactWidth:  100,
 	}
 }
 
 // loadSettingsFromFile reads ~/.gh-pr-approver if it exists and overrides
 // defaults. The file uses a simple "key = value" format (one per line).
-// Supported keys: review_comment, context_lines.
+// Supported keys: review_comment, context_lines, compact_width.
 func loadSettingsFromFile() settings {
 	s := defaultSettings()
 	home, err := os.UserHomeDir()
@@ -68,6 +83,10 @@ func loadSettingsFromFile() settings {
 			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
 				s.contextLines = n
 			}
+		case "compact_width":
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				s.compactWidth = n
+			}
 		}
 	}
 	return s
@@ -81,29 +100,130 @@ type model struct {
 	hashes       []string
 	changeMap    gh.HashChangeMap
 	rawChangeMap gh.HashRawChangeMap
+	commitMap    gh.HashCommitMap
 	hashPrMap    gh.HashPrMap
 	prMap        map[string][]string
 	verifiedMap  gh.PrVerifiedMap
+	teamMap      gh.PrTeamMap             // PRs only requested from one of your teams
+	reasonMap    gh.PrReasonMap           // why each PR is in the queue, with --reasons
+	checkStates  map[string]gh.CheckState // by PR URL; only with --require-green
 	client       *gh.GhClient
 
+	// approvalStates are by PR URL; only with --sort ready, which also
+	// orders the hashes by them
+	approvalStates map[string]gh.ApprovalState
+
+	// authors maps the hashes under review to whose changes they are
+	// reviewed as; hashes are grouped by it and, with several users, the
+	// Changes title names it
+	authors map[string]string
+
+	// mergeStates are by PR URL, as GitHub reported them at load time
+	mergeStates map[string]gh.MergeState
+
+	// headChanged holds the PRs (by URL) a commit left unapproved because
+	// their head moved since the queue was loaded; reloading clears it
+	headChanged map[string]bool
+	// autoMerged holds the PRs (by URL) a commit enabled auto-merge on, for
+	// undoing it along with the approval
+	autoMerged map[string]bool
+
 	approved  map[string]bool
 	declined  map[string]bool
 	prSkipped map[string]bool
 	committed map[string]bool
+
+	// deferred hashes were skipped for this session: they are neither
+	// approved nor declined, so their PRs are not staged, and they are not
+	// saved with the session
+	deferred map[string]bool
+
 	propagate bool
 	hashIndex int // which hash list item is selected
 	col       int // 0-left(hash),1-middle(change),2-right(prs)
 
-	status string
-	dryRun bool
+	status      string
+	approveOpts gh.ApproveOptions
+
+	// ctx is canceled when the user quits so in-flight GitHub calls stop
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// fetchWarnings lists PRs that failed to load, and partialWarning says
+	// the queue was cut short by --load-deadline; each gets a footer line
+	fetchWarnings  []string
+	partialWarning string
+
+	// Background loading state: the queue is fetched by load after the
+	// program starts and the spinner is shown until a loadedMsg arrives.
+	loading  bool
+	loadUser string
+	loadErr  error
+	load     tea.Cmd
+	spinner  spinner.Model
+
+	// approveOut buffers what the client prints while approving
+	approveOut *bytes.Buffer
+
+	// Background commit state: commitMsgs delivers commitProgressMsg values
+	// and a final commitDoneMsg from the goroutine running the approvals.
+	committing     bool
+	commitMsgs     chan tea.Msg
+	commitProgress progress.Model
+	commitN        int
+	commitTotal    int
+	commitPR       string
+
+	// approvalErr tallies the failed and successful approvals of every
+	// commit so far, for Run to return once the GUI exits.
+	approvalErr approve.ApprovalError
+
+	// Refreshing (see refresh.go): reload refetches the queue, bypassing the
+	// saved snapshot, every watch interval (0 only on demand) and its result
+	// is merged into the model. refreshedAt is when the queue shown was
+	// fetched.
+	reload      tea.Cmd
+	watch       time.Duration
+	refreshing  bool
+	refreshedAt time.Time
+
+	// Saved session (see session.go): decisions are written to sessionDir
+	// under sessionUser after every change; resume holds a saved session the
+	// user is being offered to restore. fresh skips that offer.
+	sessionDir  string
+	sessionUser string
+	fresh       bool
+	resume      *sessionState
+
+	// keys holds the approval view bindings (see keys.go)
+	keys keyMap
 
 	// Settings and confirmation
 	settings      settings
 	confirmCommit bool // when true, show confirmation dialog overlay
+	// editingComment is set while commentInput edits the review comment in
+	// the confirmation dialog
+	editingComment bool
+	commentInput   textinput.Model
 	settingsField int  // 0 = reviewComment, 1 = contextLines
 	settingsCursor int // cursor position within current settings field edit
 	settingsEdit  string // current edit buffer for settings field
 
+	// Hash filter: allHashes holds the unfiltered list while a filter is
+	// applied (nil otherwise) and hashes the matching subset. Approvals act on
+	// hash values, never list positions, so filtering can't misdirect them.
+	filtering   bool
+	filterInput textinput.Model
+	filterQuery string
+	allHashes   []string
+
+	// diffView switches the bottom pane from the PR body to the hunk diff
+	diffView bool
+	// fileView shows the selected PR's changed files instead of its body
+	// (--view files), from prFiles
+	fileView bool
+	prFiles  gh.PrFileMap
+
 	// Commit log popup
 	commitLog       []string
 	showCommitLog   bool
@@ -136,12 +256,16 @@ type model struct {
 	userScrollOffset int
 }
 
-// New creates and returns a Bubble Tea program configured for the user.
-func New(user string, propagate bool, dryRun bool) (*tea.Program, error) {
-	hashes, availableUsers, userHashPrMap, changeMap, hashPrMap, prMap, verifiedMap, hashFileMap, rawChangeMap, client, err := approve.PrepareGUI(user)
-	if err != nil {
-		return nil, err
-	}
+// New creates and returns a Bubble Tea program configured for the user. The
+// review queue is fetched in the background once the program starts, with a
+// spinner shown until it arrives. The program stops, and pending GitHub calls
+// are canceled, when ctx is done or the user quits.
+func New(ctx context.Context, user string, order approve.QueueSort, view gh.ChangeView, propagate bool, fresh bool, watch time.Duration, approveOpts gh.ApproveOptions, fetch gh.FetchOptions, opts ...gh.Option) (*tea.Program, error) {
+	modelCtx, cancel := context.WithCancel(ctx)
+	// ApprovePr's progress lines are collected here and shown in the commit
+	// log instead of being printed over the TUI.
+	approveOut := new(bytes.Buffer)
+	opts = append(opts[:len(opts):len(opts)], gh.WithOutput(approveOut))
 
 	// If user was provided (hashes already filtered), go straight to phase 1.
 	// Otherwise start in phase 0 (user selection).
@@ -151,44 +275,155 @@ func New(user string, propagate bool, dryRun bool) (*tea.Program, error) {
 	}
 
 	m := model{
-		phase:          phase,
-		hashes:         hashes,
-		changeMap:      changeMap,
-		rawChangeMap:   rawChangeMap,
-		hashPrMap:      hashPrMap,
-		prMap:          prMap,
-		verifiedMap:    verifiedMap,
-		client:         client,
-		approved:       map[string]bool{},
-		declined:       map[string]bool{},
-		prSkipped:      map[string]bool{},
-		committed:      map[string]bool{},
-		propagate:      propagate,
-		col:            0,
-		dryRun:         dryRun,
-		focusRow:       0,
-		stagedOffset:   0,
-		stagedPRList:   nil,
-		hashFileMap:    hashFileMap,
-		availableUsers: availableUsers,
-		userSelected:   map[string]bool{},
-		userCursor:     0,
-		userHashPrMap:  userHashPrMap,
-		settings:       loadSettingsFromFile(),
-	}
-	if phase == 1 {
-		// compute initial staged list so the UI shows consistent state immediately
-		m.updateStagedList()
+		phase:        phase,
+		loading:      true,
+		loadUser:     user,
+		spinner:      spinner.New(spinner.WithSpinner(spinner.Dot)),
+		load:         loadCmd(modelCtx, user, approveOpts.RequireGreen, order == approve.SortByReady, view == gh.ViewFiles, fetch, opts),
+		reload:       loadCmd(modelCtx, user, approveOpts.RequireGreen, order == approve.SortByReady, view == gh.ViewFiles, fetch, append(opts[:len(opts):len(opts)], gh.WithFreshQueue())),
+		watch:        watch,
+		approveOut:   approveOut,
+		approved:     map[string]bool{},
+		declined:     map[string]bool{},
+		prSkipped:    map[string]bool{},
+		committed:    map[string]bool{},
+		deferred:     map[string]bool{},
+		propagate:    propagate,
+		col:          0,
+		approveOpts:  approveOpts,
+		ctx:          modelCtx,
+		cancel:       cancel,
+		focusRow:     0,
+		stagedOffset: 0,
+		stagedPRList: nil,
+		userSelected: map[string]bool{},
+		userCursor:   0,
+		settings:     loadSettingsFromFile(),
+		keys:         loadKeyMapFromFile(),
+		fresh:        fresh,
+		fileView:     view == gh.ViewFiles,
+	}
+	if dir, err := defaultSessionDir(); err == nil {
+		m.sessionDir = dir
+	}
+	if approveOpts.Comment != "" {
+		m.settings.reviewComment = approveOpts.Comment
 	}
 	// viewport will be sized once we receive a WindowSizeMsg in Update
 	m.viewport = viewport.Model{}
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))
 	return p, nil
 }
 
+// loadedMsg carries the result of the background fetch started by Init.
+type loadedMsg struct {
+	hashes         []string
+	availableUsers []string
+	res            *gh.FetchResult
+	client         *gh.GhClient
+	rate           *github.Rate // nil if the rate limit could not be read
+	login          string       // empty if the authenticated user is unknown
+	checks         map[string]gh.CheckState
+	approvals      map[string]gh.ApprovalState
+	mergeStates    map[string]gh.MergeState
+	files          gh.PrFileMap // only in the files view
+	err            error
+}
+
+// loadCmd fetches the review queue (and the remaining rate limit) off the UI
+// goroutine, with the merge state of every PR. With checks it also looks up
+// the CI state of every PR, with approvals their approval state, and with
+// files the files each changes.
+func loadCmd(ctx context.Context, user string, checks, approvals, files bool, fetch gh.FetchOptions, opts []gh.Option) tea.Cmd {
+	return func() tea.Msg {
+		hashes, availableUsers, res, client, err := approve.PrepareGUI(ctx, user, fetch, opts...)
+		if err != nil {
+			return loadedMsg{err: err}
+		}
+		msg := loadedMsg{hashes: hashes, availableUsers: availableUsers, res: res, client: client}
+		if rate, err := client.RateLimit(ctx); err == nil {
+			msg.rate = rate
+		}
+		if login, err := client.CurrentUser(ctx); err == nil {
+			msg.login = login
+		}
+		var prs []*github.PullRequest
+		for _, pr := range res.HashPrMap.PRsByURL() {
+			prs = append(prs, pr)
+		}
+		if checks {
+			msg.checks = client.CombinedStatuses(ctx, prs)
+		}
+		if approvals {
+			msg.approvals = client.ApprovalStates(ctx, prs)
+		}
+		msg.mergeStates = client.MergeStates(ctx, prs)
+		if files {
+			msg.files = client.PrFiles(ctx, prs)
+		}
+		return msg
+	}
+}
+
+// applyLoaded fills the model from a finished background fetch.
+func (m *model) applyLoaded(msg loadedMsg) {
+	m.loading = false
+	if msg.err != nil {
+		m.loadErr = msg.err
+		m.status = "error: " + msg.err.Error()
+		return
+	}
+	res := msg.res
+	m.setQueue(msg)
+	m.hashes = m.orderHashes(m.loadUser, msg.hashes)
+	if msg.rate != nil {
+		m.status = approve.RateLimitSummary(msg.rate)
+		if msg.rate.Remaining < gh.LowRateLimitThreshold {
+			m.status = "warning: low " + m.status
+		}
+	}
+	if summary := res.FilterSummary(); summary != "" {
+		m.status = strings.TrimPrefix(m.status+" · "+summary, " · ")
+	}
+	if msg.login != "" {
+		m.status = strings.TrimSuffix("authenticated as "+msg.login+" · "+m.status, " · ")
+	}
+	if m.phase == 1 {
+		// compute initial staged list so the UI shows consistent state immediately
+		m.updateStagedList()
+		m.startSession(m.loadUser)
+	}
+}
+
+// setQueue replaces the fetched queue data with msg's, leaving the hash list
+// and the decisions on it to the caller.
+func (m *model) setQueue(msg loadedMsg) {
+	res := msg.res
+	m.changeMap = res.ChangeMap
+	m.rawChangeMap = res.RawChangeMap
+	m.commitMap = res.CommitMap
+	m.hashPrMap = res.HashPrMap
+	m.prMap = res.PrMap
+	m.verifiedMap = res.VerifiedMap
+	m.teamMap = res.TeamMap
+	m.reasonMap = res.ReasonMap
+	m.client = msg.client
+	m.fetchWarnings = res.FailureLines()
+	m.partialWarning = res.PartialWarning()
+	m.hashFileMap = res.HashFileMap
+	m.availableUsers = msg.availableUsers
+	m.checkStates = msg.checks
+	m.approvalStates = msg.approvals
+	m.mergeStates = msg.mergeStates
+	m.prFiles = msg.files
+	m.userHashPrMap = res.UserHashPrMap
+	m.headChanged = nil
+	m.refreshedAt = time.Now()
+}
+
 // Init implements tea.Model
 func (m model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.spinner.Tick, m.load, m.watchTick())
 }
 
 // Update implements tea.Model
@@ -196,9 +431,40 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		k := msg.String()
-		if k == "q" || k == "esc" {
+		// the comment input takes every key, including q and esc
+		if m.editingComment && k != "ctrl+c" {
+			return m.updateCommentInput(msg)
+		}
+		if m.filtering && k != "ctrl+c" {
+			return m.updateFilterInput(msg)
+		}
+		// with a hash filter applied, esc clears it instead of quitting
+		if k == "esc" && m.allHashes != nil && m.phase == 1 && !m.confirmCommit && !m.showCommitLog {
+			m.clearHashFilter()
+			return m, nil
+		}
+		if k == "ctrl+c" || m.keys.quit.matches(k) {
+			m.cancel()
 			return m, tea.Quit
 		}
+		// nothing else to interact with until the queue has loaded, and the
+		// approval maps must stay untouched while a commit runs
+		if m.loading || m.loadErr != nil || m.committing {
+			return m, nil
+		}
+
+		if m.resume != nil {
+			return m.updateResumePrompt(k)
+		}
+
+		// settings fields and popups take plain keys
+		if m.keys.refresh.matches(k) && m.phase != 2 && !m.showCommitLog && !m.confirmCommit {
+			if cmd := m.startRefresh(); cmd != nil {
+				return m, cmd
+			}
+			m.status = "a refresh is already running"
+			return m, nil
+		}
 
 		// Phase 0: user selection
 		if m.phase == 0 {
@@ -222,21 +488,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Phase 1: approval view (existing logic)
 		// toggle focus between rows
-		if k == "tab" {
+		if m.keys.switchRow.matches(k) {
 			m.focusRow = (m.focusRow + 1) % 2
 			return m, nil
 		}
 
-		// horizontal scroll for changes with alt+a / alt+d
-		if k == "alt+a" {
+		// horizontal scroll for changes
+		if m.keys.hscrollLeft.matches(k) {
 			if m.changeHOffset > 0 {
 				m.changeHOffset--
 			}
 			return m, nil
 		}
-		if k == "alt+d" {
+		if m.keys.hscrollRight.matches(k) {
 			_, midWidth, _, _ := m.columnWidths()
-			contentW := max(midWidth-4, 10)
+			contentW := changesContentWidth(midWidth)
 			maxLen := 0
 			for _, cl := range m.changesForFileTab() {
 				maxLen = max(maxLen, len(cl))
@@ -248,14 +514,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// column navigation (works in both rows)
-		if k == "a" { // left
+		if m.keys.left.matches(k) {
 			if m.col > 0 {
 				m.col--
 			}
 			m.focusRow = 0
 			return m, nil
 		}
-		if k == "d" { // right
+		if m.keys.right.matches(k) {
 			if m.col < 3 {
 				m.col++
 			}
@@ -263,13 +529,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		// If bottom row is focused, w/s should scroll the PR body viewport
+		// If bottom row is focused, up/down should scroll the PR body viewport
 		if m.focusRow == 1 {
-			if k == "w" {
+			if m.keys.up.matches(k) {
 				m.viewport.LineUp(1)
 				return m, nil
 			}
-			if k == "s" {
+			if m.keys.down.matches(k) {
 				m.viewport.LineDown(1)
 				return m, nil
 			}
@@ -281,9 +547,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.viewport.PageDown()
 				return m, nil
 			}
+			if k == "home" {
+				m.viewport.GotoTop()
+				return m, nil
+			}
+			if k == "end" {
+				m.viewport.GotoBottom()
+				return m, nil
+			}
 		}
 
-		// when top row is focused, w/s navigate hashes
+		// when top row is focused, up/down navigate hashes
 		if m.focusRow == 0 {
 			// behavior depends on which top column is active:
 			// - col 0 (hashes): w/s move the selection
@@ -291,7 +565,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// - col 2 (PRs): w/s scroll the PR pane
 			// - col 3 (staged): w/s scroll the staged pane
 			if m.col == 0 {
-				if k == "w" { // up (selection)
+				if m.keys.up.matches(k) { // selection
 					if m.hashIndex > 0 {
 						m.hashIndex--
 						// update viewport content when selection changes
@@ -302,7 +576,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 					return m, nil
 				}
-				if k == "s" { // down (selection)
+				if m.keys.down.matches(k) { // selection
 					if m.hashIndex < len(m.hashes)-1 {
 						m.hashIndex++
 						m.updateViewportContent()
@@ -311,6 +585,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 					return m, nil
 				}
+				visible := m.topVisibleLines()
+				if i, ok := pageTarget(k, m.hashIndex, len(m.hashes)-1, visible); ok {
+					if len(m.hashes) > 0 && i != m.hashIndex {
+						m.hashIndex = i
+						m.updateViewportContent()
+						ensureOffset(&m.hashOffset, m.hashIndex, visible)
+					}
+					return m, nil
+				}
 			} else if m.col == 1 {
 				// file tab navigation
 				if k == "e" {
@@ -331,74 +614,52 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 				// changes pane scroll
-				if k == "w" {
+				if m.keys.up.matches(k) {
 					if m.changeOffset > 0 {
 						m.changeOffset--
 					}
 					return m, nil
 				}
-				if k == "s" {
-					// bound by number of change lines for active file tab
-					changes := m.changesForFileTab()
-					if len(changes) > 0 {
-						visible := m.topVisibleLines() - 2 // minus title and tab bar
-						if visible < 1 {
-							visible = 1
-						}
-						maxOff := len(changes) - visible
-						if maxOff < 0 {
-							maxOff = 0
-						}
-						if m.changeOffset < maxOff {
-							m.changeOffset++
-						}
+				visible, maxOff := m.changeScrollRange()
+				if m.keys.down.matches(k) {
+					if m.changeOffset < maxOff {
+						m.changeOffset++
 					}
 					return m, nil
 				}
+				if off, ok := pageTarget(k, m.changeOffset, maxOff, visible); ok {
+					m.changeOffset = off
+					return m, nil
+				}
 			} else if m.col == 2 {
 				// PRs pane scroll
-				if k == "w" {
+				if m.keys.up.matches(k) {
 					if m.prOffset > 0 {
 						m.prOffset--
 					}
 					return m, nil
 				}
-				if k == "s" {
-					sel := ""
-					if len(m.hashes) > 0 && m.hashIndex < len(m.hashes) {
-						sel = m.hashes[m.hashIndex]
-					}
-					if sel != "" {
-						if prs, ok := m.hashPrMap[sel]; ok {
-							visible := m.topVisibleLines() - 1
-							if visible < 1 {
-								visible = 1
-							}
-							maxOff := len(prs) - visible
-							if maxOff < 0 {
-								maxOff = 0
-							}
-							if m.prOffset < maxOff {
-								m.prOffset++
-							}
-						}
+				visible, maxOff := m.prScrollRange()
+				if m.keys.down.matches(k) {
+					if m.prOffset < maxOff {
+						m.prOffset++
 					}
 					return m, nil
 				}
+				if off, ok := pageTarget(k, m.prOffset, maxOff, visible); ok {
+					m.prOffset = off
+					return m, nil
+				}
 			} else if m.col == 3 {
 				// staged pane scroll
-				if k == "w" {
+				if m.keys.up.matches(k) {
 					if m.stagedOffset > 0 {
 						m.stagedOffset--
 					}
 					return m, nil
 				}
-				if k == "s" {
-					sel := ""
-					if len(m.hashes) > 0 && m.hashIndex < len(m.hashes) {
-						sel = m.hashes[m.hashIndex]
-					}
-					if sel != "" {
+				if m.keys.down.matches(k) {
+					if m.selectedHash() != "" {
 						// staged pane scroll should be based on staged PRs (not hashes)
 						stagedKeys := m.stagedPrKeys()
 						visible := m.topVisibleLines() - 1
@@ -416,57 +677,113 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 			}
-			if k == "x" { // approve
-				if m.hashIndex >= 0 && m.hashIndex < len(m.hashes) {
-					h := m.hashes[m.hashIndex]
+			if m.keys.approve.matches(k) {
+				if h := m.selectedHash(); h != "" {
 					// mark approved and remove any declined marker for this hash
 					delete(m.declined, h)
+					delete(m.deferred, h)
 					m.approved[h] = true
 					if m.propagate {
-						// auto-approve linked hashes (quiet)
-						approve.ApproveLinkedHashes(h, m.approved, m.declined, m.hashPrMap, m.prMap, true)
+						// auto-approve linked hashes (quiet), except those skipped
+						approve.ApproveLinkedHashes(io.Discard, h, m.approved, m.declined, m.hashPrMap, m.prMap)
+						for dh := range m.deferred {
+							delete(m.approved, dh)
+						}
 					}
 					m.status = fmt.Sprintf("approved %s", h[:6])
+					m.recomputePrSkipped()
+					m.saveSession()
 					// ensure UI reflects the change immediately
-					// reconcile any PRs that were skipped earlier and may now be eligible
-					m.reconcilePrSkipped()
 					m.updateStagedList()
 					m.updateViewportContent()
 				}
 				return m, nil
 			}
-			if k == "f" { // decline selected hash with 'f'
-				if m.hashIndex >= 0 && m.hashIndex < len(m.hashes) {
-					h := m.hashes[m.hashIndex]
+			if m.keys.decline.matches(k) {
+				if h := m.selectedHash(); h != "" {
 					// mark declined and remove any approved marker for this hash
 					delete(m.approved, h)
 					m.declined[h] = true
 					// auto-decline linked hashes quietly and mark PRs skipped
-					approve.DeclineLinkedHashes(h, m.declined, m.prSkipped, m.hashPrMap, m.prMap, true)
+					approve.DeclineLinkedHashes(io.Discard, h, m.declined, m.prSkipped, m.hashPrMap, m.prMap)
 					// remove any hashes that got marked declined from approved map to keep state consistent
 					for dh := range m.declined {
 						if m.approved[dh] {
 							delete(m.approved, dh)
 						}
+						delete(m.deferred, dh)
 					}
 					// update staged PR list and UI
-					// reconcile skipped PRs in case some were unskipped by downstream effects
-					m.reconcilePrSkipped()
+					m.recomputePrSkipped()
 					m.updateStagedList()
 					m.status = fmt.Sprintf("declined %s", h[:6])
+					m.saveSession()
+					m.updateViewportContent()
+				}
+				return m, nil
+			}
+			if m.keys.skip.matches(k) {
+				if h := m.selectedHash(); h != "" {
+					// defer without deciding: unlike declining, nothing
+					// cascades and its PRs can still be staged later
+					if m.deferred[h] {
+						delete(m.deferred, h)
+						m.status = fmt.Sprintf("unskipped %s", h[:6])
+					} else {
+						delete(m.approved, h)
+						delete(m.declined, h)
+						m.deferred[h] = true
+						m.status = fmt.Sprintf("skipped %s for this session", h[:6])
+					}
+					m.recomputePrSkipped()
+					m.saveSession()
+					m.updateStagedList()
 					m.updateViewportContent()
 				}
 				return m, nil
 			}
+			if m.keys.copyURL.matches(k) {
+				url := m.selectedPR()
+				if url == "" {
+					m.status = "no PR to copy"
+					return m, nil
+				}
+				return m, copyCmd(url)
+			}
+			if m.keys.uncommit.matches(k) {
+				return m.uncommitSelectedPR()
+			}
+			if m.keys.openURL.matches(k) {
+				url := m.selectedPR()
+				if url == "" {
+					m.status = "no PR to open"
+					return m, nil
+				}
+				return m, openCmd(url)
+			}
+			if k == "/" { // filter the hash list
+				m.filterInput = textinput.New()
+				m.filterInput.Prompt = "/"
+				m.filterInput.Placeholder = "hash, file or changed text"
+				m.filterInput.SetValue(m.filterQuery)
+				m.filterInput.CursorEnd()
+				m.filtering = true
+				return m, m.filterInput.Focus()
+			}
+			if k == "v" { // toggle the bottom pane between PR body and diff
+				m.diffView = !m.diffView
+				m.viewport.SetContent(m.bottomPaneContent())
+				m.viewport.GotoTop()
+				return m, nil
+			}
 			if k == "p" { // open settings panel
 				m.phase = 2
 				m.settingsField = 0
 				m.loadCurrentSettingsField()
 				return m, nil
 			}
-			if k == "c" { // commit changes — show confirmation dialog
-				filtered := m.buildFilteredPrMap()
-				if len(filtered) > 0 {
+			if m.keys.commit.matches(k) { // show confirmation dialog
+				if len(m.buildFilteredPrMap())+len(m.buildDeclinedPrMap()) > 0 {
 					m.confirmCommit = true
 				} else {
 					m.status = "no staged PRs to commit"
@@ -475,6 +792,72 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case spinner.TickMsg:
+		if !m.loading {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case copiedMsg:
+		if msg.err != nil {
+			m.status = "copy failed: " + msg.err.Error()
+		} else {
+			m.status = "copied " + msg.text
+		}
+		return m, nil
+
+	case dismissedMsg:
+		if msg.err != nil {
+			m.status = "undo failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.uncommitPR(msg.prKey)
+		m.status = dismissedStatus(msg)
+		if msg.autoMergeErr == nil {
+			delete(m.autoMerged, msg.prKey)
+		}
+		return m, nil
+
+	case openedMsg:
+		m.status = openStatus(msg)
+		return m, nil
+
+	case commitProgressMsg:
+		m.commitN, m.commitTotal, m.commitPR = msg.n, msg.total, msg.prKey
+		cmd := m.commitProgress.SetPercent(float64(msg.n-1) / float64(max(msg.total, 1)))
+		return m, tea.Batch(cmd, waitForCommit(m.commitMsgs))
+
+	case progress.FrameMsg:
+		pm, cmd := m.commitProgress.Update(msg)
+		m.commitProgress = pm.(progress.Model)
+		return m, cmd
+
+	case commitDoneMsg:
+		m.finishCommit(msg)
+		return m, nil
+
+	case watchTickMsg:
+		cmd := m.startRefresh()
+		return m, tea.Batch(cmd, m.watchTick())
+
+	case refreshedMsg:
+		m.applyRefreshed(msg)
+		if m.termWidth > 0 {
+			// the warnings footer may have come or gone
+			return m.Update(tea.WindowSizeMsg{Width: m.termWidth, Height: m.termHeight})
+		}
+		return m, nil
+
+	case loadedMsg:
+		m.applyLoaded(msg)
+		if m.termWidth > 0 {
+			// redo the layout now that warnings and PR bodies are known
+			return m.Update(tea.WindowSizeMsg{Width: m.termWidth, Height: m.termHeight})
+		}
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		// store terminal size, compute layout widths and initialize viewport
 		m.termWidth = msg.Width
@@ -484,6 +867,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// header (status line) = 1, footer (hint) = 1
 		headerH := 1
 		footerH := 1
+		footerH += len(m.warningLines())
 		reserved := headerH + footerH
 
 		// bottom outer height (including border/padding) should be roughly 1/3 of terminal but leave room for reserved lines
@@ -526,22 +910,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// updateViewportContent updates the viewport with the PR body of the currently selected PR (first PR for selected hash)
+// updateViewportContent updates the viewport with the PR body of the currently
+// selected PR (first PR for selected hash), or the hunk diff in diff view
 func (m *model) updateViewportContent() {
-	selectedHash := m.selectedHash()
-	body := ""
-	if selectedHash != "" {
-		if prs, ok := m.hashPrMap[selectedHash]; ok && len(prs) > 0 {
-			if b, err := m.client.GetPrComment(prs[0]); err == nil {
-				body = b
-			} else {
-				body = "(no body)"
-			}
-		} else {
-			body = "(no PR)"
-		}
-	}
-	m.viewport.SetContent(body)
+	m.clampHashSelection()
+	m.viewport.SetContent(m.bottomPaneContent())
 	// reset viewport scroll to top so the beginning of the PR body is visible
 	m.viewport.GotoTop()
 	// reset top-column offsets for the newly selected hash so related panes start at top
@@ -554,6 +927,85 @@ func (m *model) updateViewportContent() {
 	m.updateStagedList()
 }
 
+// diffLineStyle colors a diff line by its prefix: additions green, removals
+// red, and hunk headers and elided-context markers dim.
+func diffLineStyle(line string) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	switch {
+	case strings.HasPrefix(line, "+"):
+		return style.Foreground(lipgloss.Color("10"))
+	case strings.HasPrefix(line, "-"):
+		return style.Foreground(lipgloss.Color("9"))
+	case line == "..." || strings.HasPrefix(line, "@@"):
+		return style.Foreground(lipgloss.Color("8"))
+	}
+	return style
+}
+
+// bottomPaneName names what the bottom pane currently shows.
+func (m model) bottomPaneName() string {
+	if m.diffView {
+		return "diff"
+	}
+	if m.fileView {
+		return "files"
+	}
+	return "PR body"
+}
+
+// bottomPaneContent returns what the bottom viewport shows for the selected
+// hash: the first PR's body, or its changed files in the files view, or its
+// full hunk with +/- lines colored when diffView is on.
+func (m model) bottomPaneContent() string {
+	selectedHash := m.selectedHash()
+	if selectedHash == "" {
+		return ""
+	}
+	if m.diffView {
+		hunk, ok := m.changeMap[selectedHash]
+		if !ok {
+			return "(no diff)"
+		}
+		lines := hunk.Lines
+		if raw, ok := m.rawChangeMap[selectedHash]; ok && len(raw) > 0 {
+			lines = raw
+		}
+		out := []string{diffLineStyle("@@").Render(hunk.Header())}
+		for _, l := range lines {
+			out = append(out, diffLineStyle(l).Render(l))
+		}
+		return strings.Join(out, "\n")
+	}
+	prs, ok := m.hashPrMap[selectedHash]
+	if !ok || len(prs) == 0 {
+		return "(no PR)"
+	}
+	if m.fileView {
+		return m.filesContent(m.selectedPR())
+	}
+	if b, err := m.client.GetPrComment(m.ctx, prs[0]); err == nil {
+		return b
+	}
+	return "(no body)"
+}
+
+// filesContent lists the files prKey changes, each with its +/- counts and
+// the start of its patch colored like the diff view.
+func (m model) filesContent(prKey string) string {
+	files, ok := m.prFiles[prKey]
+	if !ok {
+		return "(files could not be listed)"
+	}
+	out := []string{fmt.Sprintf("%s: %d file(s)", shortenPRURL(prKey), len(files))}
+	for _, f := range files {
+		out = append(out, "", lipgloss.NewStyle().Bold(true).Render(f.Summary()))
+		for _, l := range f.PatchSnippet(gh.FilePatchLines) {
+			out = append(out, diffLineStyle(l).Render(l))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
 // updateStagedList recomputes and stores the list of PR keys that would be approved
 func (m *model) updateStagedList() {
 	m.stagedPRList = m.stagedPrKeys()
@@ -561,6 +1013,10 @@ func (m *model) updateStagedList() {
 
 // View implements tea.Model
 func (m model) View() string {
+	m.clampHashSelection()
+	if m.loading || m.loadErr != nil {
+		return m.viewLoading()
+	}
 	if m.phase == 0 {
 		return m.viewUserSelection()
 	}
@@ -570,10 +1026,16 @@ func (m model) View() string {
 	if m.showCommitLog {
 		return m.viewCommitLog()
 	}
+	if m.resume != nil {
+		return m.viewResumePrompt()
+	}
 	// If confirmation dialog is showing, render it as an overlay
 	if m.confirmCommit {
 		return m.viewConfirmation()
 	}
+	if len(m.hashes) == 0 && m.allHashes == nil && !m.filtering {
+		return m.viewEmptyQueue()
+	}
 
 	leftWidth, midWidth, prWidth, stagedWidth := m.columnWidths()
 
@@ -592,13 +1054,10 @@ func (m model) View() string {
 		topBlockH = 1
 	}
 	yellowBorder := lipgloss.NewStyle().BorderForeground(lipgloss.Color("11"))
-	baseCol := func(w int) lipgloss.Style {
-		return lipgloss.NewStyle().Width(w).Height(topBlockH).MaxHeight(topBlockH + 2).Border(lipgloss.NormalBorder()).PaddingLeft(1).PaddingRight(1)
-	}
-	left := baseCol(leftWidth)
-	mid := baseCol(midWidth)
-	prStyle := baseCol(prWidth)
-	stagedStyle := baseCol(stagedWidth)
+	left := topColumnStyle(leftWidth, topBlockH)
+	mid := topColumnStyle(midWidth, topBlockH)
+	prStyle := topColumnStyle(prWidth, topBlockH)
+	stagedStyle := topColumnStyle(stagedWidth, topBlockH)
 	if m.focusRow == 0 {
 		switch m.col {
 		case 0:
@@ -615,11 +1074,20 @@ func (m model) View() string {
 	// build column title row
 	titleStyle := lipgloss.NewStyle().Bold(true)
 	leftTitle := titleStyle.Render("Hashes")
-	midTitle := titleStyle.Render("Changes")
+	if m.filterQuery != "" {
+		leftTitle = titleStyle.Render(fmt.Sprintf("Hashes /%s (%d/%d)", m.filterQuery, len(m.hashes), len(m.allHashes)))
+	}
 	rightTitle := titleStyle.Render("Related PRs")
 	stagedTitle := titleStyle.Render("Staged changes")
 
 	selectedHash := m.selectedHash()
+	midTitle := titleStyle.Render("Changes")
+	if sha := m.commitMap[selectedHash]; sha != "" {
+		midTitle = titleStyle.Render("Changes @ " + gh.ShortSHA(sha))
+	}
+	if author := m.hashAuthor(selectedHash); author != "" {
+		midTitle += titleStyle.Render(" · " + author)
+	}
 
 	// left column: show hashes (6 chars)
 	var leftLines []string
@@ -636,28 +1104,21 @@ func (m model) View() string {
 			marker = "✓"
 		} else if m.declined[h] {
 			marker = "x"
+		} else if m.deferred[h] {
+			marker = "~"
 		}
 		line := fmt.Sprintf("%s %s", marker, short)
 		if m.approved[h] {
 			line = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(line)
 		} else if m.declined[h] {
 			line = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(line)
+		} else if m.deferred[h] {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(line)
 		}
 		fullLeft = append(fullLeft, line)
 	}
 
 	visible := m.topVisibleLines()
-	// clamp offsets
-	if m.hashOffset < 0 {
-		m.hashOffset = 0
-	}
-	if m.hashOffset > len(fullLeft)-visible {
-		m.hashOffset = len(fullLeft) - visible
-	}
-	if m.hashOffset < 0 {
-		m.hashOffset = 0
-	}
-
 	window := sliceForWindow(fullLeft, m.hashOffset, visible)
 	// append windowed lines and apply selection highlight if selection is within window
 	for i, line := range window {
@@ -704,10 +1165,7 @@ func (m model) View() string {
 			}
 			win := sliceForWindow(fullChanges, m.changeOffset, visible)
 			// compute content width for changes (reserve padding inside mid box)
-			contentW := midWidth - 4
-			if contentW < 10 {
-				contentW = 10
-			}
+			contentW := changesContentWidth(midWidth)
 			for _, cl := range win {
 				display := cl
 				// apply horizontal offset: show substring of the line
@@ -732,14 +1190,8 @@ func (m model) View() string {
 				if len([]rune(cl)) > m.changeHOffset+contentW {
 					display = display + "»"
 				}
-				// apply coloring based on line prefix
-				if strings.HasPrefix(cl, "+") {
-					display = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(display)
-				} else if strings.HasPrefix(cl, "-") {
-					display = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(display)
-				} else if cl == "..." {
-					display = lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(display)
-				}
+				// apply coloring based on the untruncated line's prefix
+				display = diffLineStyle(cl).Render(display)
 				midLines = append(midLines, display)
 			}
 		} else {
@@ -748,6 +1200,7 @@ func (m model) View() string {
 	}
 
 	// PRs column: Related PRs for selected hash
+	now := time.Now()
 	var prLines []string
 	prLines = append(prLines, rightTitle)
 	var fullPRs []string
@@ -756,6 +1209,10 @@ func (m model) View() string {
 			for i, pr := range prs {
 				prKey := pr.GetHTMLURL()
 				fullPRs = append(fullPRs, m.renderPRLabel(prKey, i))
+				// the content area loses the padding and the scrollbar
+				if detail := prDetail(pr, now, prWidth-4); detail != "" {
+					fullPRs = append(fullPRs, lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(detail))
+				}
 				// show linked hashes for this PR
 				if linkedHashes, ok := m.prMap[prKey]; ok {
 					for j, lh := range linkedHashes {
@@ -876,6 +1333,9 @@ func (m model) View() string {
 	stagedBox := stagedStyle.Render(strings.Join(stagedWindow, "\n"))
 
 	top := lipgloss.JoinHorizontal(lipgloss.Top, leftBox, midBox, prBox, stagedBox)
+	if m.compact() {
+		top = lipgloss.JoinHorizontal(lipgloss.Top, leftBox, []string{midBox, prBox, stagedBox}[m.contextCol()-1])
+	}
 
 	// bottom: show the viewport content (scrollable PR body). When focused, visually indicate focus.
 	// Use the stored bottomHeight (including borders) for consistent sizing
@@ -894,24 +1354,48 @@ func (m model) View() string {
 	}
 	bodyView := m.viewport.View()
 	if bodyView == "" {
-		bodyView = "(no PR body)"
+		bodyView = "(no " + m.bottomPaneName() + ")"
 	}
 
 	// footer with keybind hints (bottom-left)
-	hint := "tab: switch row • a/d: left/right • w/s: up/down • e/r: file tabs • x: approve • f: decline • c: commit • p: settings • q: quit • alt+a/d: hscroll"
-	footer := lipgloss.NewStyle().Padding(0, 1).Render(hint)
+	hint := m.keys.hint()
+	if m.filtering {
+		hint = m.filterInput.View() + "  (enter: keep filter • esc: clear)"
+	} else if m.allHashes != nil {
+		hint = "esc: clear filter • " + hint
+	}
+	// the layout reserves a single footer line, so clip rather than wrap
+	footerStyle := lipgloss.NewStyle().Padding(0, 1)
+	if m.termWidth > 0 {
+		footerStyle = footerStyle.MaxWidth(m.termWidth)
+	}
+	footer := footerStyle.Render(hint)
+	for _, warning := range slices.Backward(m.warningLines()) {
+		footer = lipgloss.JoinVertical(lipgloss.Left, lipgloss.NewStyle().Padding(0, 1).Render(warning), footer)
+	}
 
 	bottom := bottomStyle.Render(bodyView)
 
+	// the status line is clipped like the footer so it can't widen the layout
+	statusStyle := lipgloss.NewStyle()
+	if m.termWidth > 0 {
+		statusStyle = statusStyle.MaxWidth(m.termWidth)
+	}
+
 	// join everything with footer below; no extra spacer lines so the layout fits the terminal exactly
 	return lipgloss.JoinVertical(lipgloss.Left,
-		fmt.Sprintf("Column: %d | Selected hash: %s | Status: %s", m.col+1, func() string {
+		statusStyle.Render(fmt.Sprintf("%s | Selected hash: %s | Pane: %s | %s | Status: %s", m.columnName(), func() string {
 			if selectedHash == "" {
 				return "-"
 			} else {
 				return selectedHash[:6]
 			}
-		}(), m.status),
+		}(), m.bottomPaneName(), m.reviewTally(len(stagedPRs))+" | "+m.refreshStatus(), func() string {
+			if m.committing {
+				return m.commitStatus()
+			}
+			return m.status
+		}())),
 		top,
 		bottom,
 		footer,
@@ -919,13 +1403,63 @@ func (m model) View() string {
 }
 
 // Run starts the GUI program and blocks until it exits.
-func Run(user string, propagate bool, dryRun bool) error {
-	p, err := New(user, propagate, dryRun)
+func Run(ctx context.Context, user string, order approve.QueueSort, view gh.ChangeView, propagate bool, fresh bool, watch time.Duration, approveOpts gh.ApproveOptions, fetch gh.FetchOptions, opts ...gh.Option) error {
+	p, err := New(ctx, user, order, view, propagate, fresh, watch, approveOpts, fetch, opts...)
 	if err != nil {
 		return err
 	}
-	_, err = p.Run()
-	return err
+	// Log lines written while the TUI owns the terminal would corrupt it.
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	final, err := p.Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+	if fm, ok := final.(model); ok {
+		if fm.loadErr != nil {
+			return fm.loadErr
+		}
+		if len(fm.approvalErr.Failed) > 0 {
+			return &fm.approvalErr
+		}
+	}
+	return nil
+}
+
+// pageTarget returns where a paging key moves pos within [0, last] in a pane
+// showing visible lines: a page up or down for pgup/pgdown, and the ends for
+// home/end. ok is false for any other key.
+func pageTarget(k string, pos, last, visible int) (target int, ok bool) {
+	switch k {
+	case "pgup":
+		target = pos - max(visible, 1)
+	case "pgdown":
+		target = pos + max(visible, 1)
+	case "home":
+		target = 0
+	case "end":
+		target = last
+	default:
+		return pos, false
+	}
+	return max(min(target, last), 0), true
+}
+
+// changeScrollRange returns how many change lines fit in the changes pane
+// and the largest offset that still fills it, for the active file tab.
+func (m model) changeScrollRange() (visible, maxOff int) {
+	visible = max(m.topVisibleLines()-2, 1) // minus title and tab bar
+	return visible, max(len(m.changesForFileTab())-visible, 0)
+}
+
+// prScrollRange returns how many PRs fit in the Related PRs pane and the
+// largest offset that still fills it, for the selected hash.
+func (m model) prScrollRange() (visible, maxOff int) {
+	visible = max(m.topVisibleLines()-1, 1) // minus title
+	return visible, max(len(m.hashPrMap[m.selectedHash()])-visible, 0)
 }
 
 // ensureOffset ensures the given offset keeps the given index visible within the top-visible range.
@@ -940,6 +1474,16 @@ func ensureOffset(offset *int, index int, visible int) {
 	}
 }
 
+// clampHashSelection keeps hashIndex within hashes and hashOffset on a
+// window that shows it, however hashes last changed, e.g. by a refresh or a
+// filter shrinking the list.
+func (m *model) clampHashSelection() {
+	m.hashIndex = max(min(m.hashIndex, len(m.hashes)-1), 0)
+	visible := m.topVisibleLines()
+	m.hashOffset = max(min(m.hashOffset, len(m.hashes)-visible), 0)
+	ensureOffset(&m.hashOffset, m.hashIndex, visible)
+}
+
 // topVisibleLines computes the number of lines visible in the top columns based on the current terminal size and allocated top height.
 func (m model) topVisibleLines() int {
 	// Compute visible lines in the top columns (excluding the title line).
@@ -979,26 +1523,11 @@ func sliceForWindow(items []string, offset, visible int) []string {
 	return items[offset:end]
 }
 
-// isPRFullyApproved returns true if all hashes for the PR are approved (none declined).
-func (m *model) isPRFullyApproved(phashes []string) bool {
-	if len(m.approved) == 0 {
-		return false
-	}
-	for _, ph := range phashes {
-		if m.declined[ph] || !m.approved[ph] {
-			return false
-		}
-	}
-	return true
-}
-
+// stagedPrKeys returns the PRs whose hashes are all approved.
 func (m *model) stagedPrKeys() []string {
 	var stagedPRs []string
 	for prKey, phashes := range m.prMap {
-		if m.isPRFullyApproved(phashes) {
-			if m.prSkipped[prKey] {
-				delete(m.prSkipped, prKey)
-			}
+		if approve.ShouldApprovePR(prKey, phashes, m.approved, m.declined, nil) {
 			stagedPRs = append(stagedPRs, prKey)
 		}
 	}
@@ -1033,35 +1562,148 @@ func (m *model) prApprovalState(prKey string) (bool, bool, bool) {
 func (m *model) renderPRLabel(prKey string, idx int) string {
 	verifiedIcon := approve.VerifiedIcon(m.verifiedMap[prKey])
 	label := fmt.Sprintf("[%d] %s %s", idx+1, verifiedIcon, prKey)
-	allApproved, anyDeclined, committed := m.prApprovalState(prKey)
-	if committed {
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Render(label)
+	if tag := m.teamMap.Tag(prKey); tag != "" {
+		label += fmt.Sprintf(" (%s)", tag)
+	}
+	if tag := m.reasonMap.Tag(prKey); tag != "" {
+		label += fmt.Sprintf(" (%s)", tag)
 	}
-	if anyDeclined {
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(label)
+	if m.headChanged[prKey] {
+		label += " (PR changed since load — re-review)"
 	}
-	if allApproved {
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(label)
+	approvals, known := m.approvalStates[prKey]
+	if known {
+		label += fmt.Sprintf(" (%s)", approvals)
+	}
+	checks, checked := m.checkStates[prKey]
+	merge := m.mergeStates[prKey]
+	switch merge {
+	case gh.MergeStateMerged, gh.MergeStateClosed:
+		label += fmt.Sprintf(" (%s)", merge)
+	case gh.MergeStateChecksFailing:
+		if !checked {
+			label += fmt.Sprintf(" (%s)", merge)
+		}
+	}
+
+	style := lipgloss.NewStyle()
+	allApproved, anyDeclined, committed := m.prApprovalState(prKey)
+	switch {
+	case committed:
+		style = style.Foreground(lipgloss.Color("6"))
+	case anyDeclined:
+		style = style.Foreground(lipgloss.Color("9"))
+	case m.headChanged[prKey]:
+		style = style.Foreground(lipgloss.Color("13"))
+	case checked && checks != gh.CheckSuccess:
+		// with --require-green these won't be approved, so flag them
+		label += fmt.Sprintf(" (checks %s)", checks)
+		style = style.Foreground(lipgloss.Color("13"))
+	case allApproved:
+		style = style.Foreground(lipgloss.Color("10"))
+	case known && approvals.Missing() == 1:
+		// one approval away from mergeable: approving this one merges it
+		style = style.Foreground(lipgloss.Color("11"))
+	}
+	// nothing left to review on a PR that is no longer open
+	if merge == gh.MergeStateMerged || merge == gh.MergeStateClosed {
+		style = style.Strikethrough(true)
+	}
+	label = style.Render(label)
+	if merge == gh.MergeStateConflicts {
+		label = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("●") + " " + label
 	}
 	return label
 }
 
-func (m *model) reconcilePrSkipped() {
+// prDetail returns the line shown under pr's label in the Related PRs column:
+// its author and how long ago it was opened, e.g. "  alice · 3d". When both
+// don't fit in width, the age alone is shown, as the author is also in the
+// changes title.
+func prDetail(pr *github.PullRequest, now time.Time, width int) string {
+	var parts []string
+	if author := pr.GetUser().GetLogin(); author != "" {
+		parts = append(parts, author)
+	}
+	age := ""
+	if created := pr.GetCreatedAt(); !created.IsZero() {
+		age = prAge(now.Sub(created.Time))
+		parts = append(parts, age)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	detail := "  " + strings.Join(parts, " · ")
+	if age != "" && lipgloss.Width(detail) > width {
+		return "  " + age
+	}
+	return detail
+}
+
+// prAge formats how old a PR is in its largest whole unit: minutes, hours,
+// days, or weeks past two weeks, e.g. "40m", "5h", "3d" or "6w".
+func prAge(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", max(int(d/time.Minute), 0))
+	case d < day:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 14*day:
+		return fmt.Sprintf("%dd", int(d/day))
+	default:
+		return fmt.Sprintf("%dw", int(d/(7*day)))
+	}
+}
+
+// recomputePrSkipped rebuilds prSkipped from the declined hashes: a PR is
+// skipped exactly when one of its hashes is declined. Staging thus depends
+// only on the current decisions, not on the order they were made in.
+func (m *model) recomputePrSkipped() {
+	if m.prSkipped == nil {
+		m.prSkipped = map[string]bool{}
+	}
+	clear(m.prSkipped)
 	for prKey, phashes := range m.prMap {
-		if m.prSkipped[prKey] && m.isPRFullyApproved(phashes) {
-			delete(m.prSkipped, prKey)
+		if slices.ContainsFunc(phashes, func(h string) bool { return m.declined[h] }) {
+			m.prSkipped[prKey] = true
 		}
 	}
 }
 
 // selectedHash returns the currently selected hash or empty string.
 func (m model) selectedHash() string {
-	if len(m.hashes) > 0 && m.hashIndex < len(m.hashes) {
+	if m.hashIndex >= 0 && m.hashIndex < len(m.hashes) {
 		return m.hashes[m.hashIndex]
 	}
 	return ""
 }
 
+// selectedPR returns the URL of the PR to act on for the selected hash: the
+// one at the top of the Related PRs column when that column is focused and
+// scrolled, otherwise the first linked PR.
+func (m model) selectedPR() string {
+	prs := m.hashPrMap[m.selectedHash()]
+	if len(prs) == 0 {
+		return ""
+	}
+	if m.col == 2 && m.focusRow == 0 {
+		// each PR takes one label line, its detail line if any, and one
+		// line per linked hash
+		line := 0
+		for _, pr := range prs {
+			line += 1 + len(m.prMap[pr.GetHTMLURL()])
+			if prDetail(pr, time.Now(), math.MaxInt) != "" {
+				line++
+			}
+			if m.prOffset < line {
+				return pr.GetHTMLURL()
+			}
+		}
+	}
+	return prs[0].GetHTMLURL()
+}
+
 // changeFilesForHash returns the unique file paths where the selected hash's
 // change is applied, across all PRs that contain it. Each entry is formatted
 // as "repo#num:file" so you can see which PR/repo each file belongs to.
@@ -1094,7 +1736,8 @@ func shortenPRURL(url string) string {
 	return url
 }
 
-// changesForFileTab returns the change lines for the selected hash.
+// changesForFileTab returns the change lines for the selected hash, headed by
+// the hunk's @@ location line.
 // When contextLines > 0, it uses the raw change map (with context lines)
 // and filters to show only N context lines around changes.
 func (m model) changesForFileTab() []string {
@@ -1102,12 +1745,18 @@ func (m model) changesForFileTab() []string {
 	if sel == "" {
 		return nil
 	}
+	hunk, ok := m.changeMap[sel]
+	lines := hunk.Lines
 	if m.settings.contextLines >= 0 {
 		if raw, ok := m.rawChangeMap[sel]; ok && len(raw) > 0 {
-			return filterContextLines(raw, m.settings.contextLines)
+			lines = filterContextLines(raw, m.settings.contextLines)
 		}
 	}
-	return m.changeMap[sel]
+	if !ok || len(lines) == 0 {
+		return lines
+	}
+	header := fmt.Sprintf("@@ -%d +%d @@", hunk.OldStart, hunk.NewStart)
+	return append([]string{header}, lines...)
 }
 
 // renderFileTabs renders the file tab bar for the changes panel.
@@ -1122,10 +1771,8 @@ func (m model) renderFileTabs(midWidth int) string {
 	if tabIdx < 0 || tabIdx >= len(files) {
 		tabIdx = 0
 	}
-	contentW := midWidth - 4
-	if contentW < 10 {
-		contentW = 10
-	}
+	// the tab bar has no scrollbar, so it gets the whole content area
+	contentW := max(midWidth-topColumnStyle(0, 1).GetHorizontalPadding(), 1)
 
 	// Try rendering all tabs inline
 	var parts []string
@@ -1165,7 +1812,7 @@ func (m model) renderFileTabs(midWidth int) string {
 		name = "…" + name[len(name)-maxNameLen+1:]
 	}
 	tabLine := prefix + lipgloss.NewStyle().Bold(true).Render(name) + suffix
-	return tabLine
+	return lipgloss.NewStyle().MaxWidth(contentW).Render(tabLine)
 }
 
 // updateChangeFileTab resets the file tab to the first entry when the hash changes.
@@ -1173,12 +1820,59 @@ func (m *model) updateChangeFileTab() {
 	m.changeFileTab = 0
 }
 
-// columnWidths computes the 4-column layout widths (left, mid, pr, staged).
-func (m model) columnWidths() (int, int, int, int) {
+// minColumnWidth is the narrowest a top-row column is shrunk to, padding
+// included, when the terminal is too narrow for the preferred widths.
+const minColumnWidth = 10
+
+// topColumnStyle is the bordered block each top-row column is rendered in.
+// width is its lipgloss Width, which includes the padding but not the border.
+func topColumnStyle(width, height int) lipgloss.Style {
+	return lipgloss.NewStyle().Width(width).Height(height).MaxHeight(height + 2).Border(lipgloss.NormalBorder()).PaddingLeft(1).PaddingRight(1)
+}
+
+// changesContentWidth is how many characters of a change line the changes
+// column shows at width midWidth: its content area minus the scrollbar. Both
+// rendering and the horizontal scroll bound use it so they agree.
+func changesContentWidth(midWidth int) int {
+	return max(midWidth-topColumnStyle(0, 1).GetHorizontalPadding()-2, 1)
+}
+
+// compact reports whether the terminal is narrower than the compact_width
+// setting, so the top row drops to two columns: the hashes and one context
+// pane showing the changes, the related PRs or the staged changes.
+func (m model) compact() bool {
+	return m.termWidth > 0 && m.termWidth < m.settings.compactWidth
+}
+
+// contextCol returns the column shown in the compact layout's context pane:
+// the focused one, or the changes while the hashes are focused.
+func (m model) contextCol() int {
+	return max(m.col, 1)
+}
+
+// columnName describes the focused column for the status line; in the
+// compact layout it also names what the context pane shows.
+func (m model) columnName() string {
+	name := fmt.Sprintf("Column: %d", m.col+1)
+	if m.compact() {
+		name += " | View: " + [...]string{"Changes", "Related PRs", "Staged changes"}[m.contextCol()-1]
+	}
+	return name
+}
+
+// columnWidths computes the 4-column layout widths (left, mid, pr, staged) to
+// pass to topColumnStyle, so that the columns and their borders fill the
+// terminal width. In the compact layout mid, pr and staged are all the width
+// of the context pane. When the changes column would drop below minColumnWidth,
+// the PR, staged and hash columns give up width in that order. A terminal
+// narrower than four minimal columns still overflows.
+func (m model) columnWidths() (left, mid, pr, staged int) {
 	termW := m.termWidth
 	if termW == 0 {
 		termW = 120
 	}
+	border := topColumnStyle(0, 1).GetHorizontalBorderSize()
+	avail := termW - 4*border
 
 	leftMax := 0
 	for _, h := range m.hashes {
@@ -1196,50 +1890,51 @@ func (m model) columnWidths() (int, int, int, int) {
 			maxPR = max(maxPR, len(pr.GetHTMLURL()))
 		}
 	}
+	if m.compact() {
+		// the context pane takes everything the hashes leave
+		context := max(termW-2*border-leftWidth, minColumnWidth)
+		return leftWidth, context, context, context
+	}
+
 	prWidth := max(maxPR+6, 20)
 	stagedWidth := min(36, termW/4)
 	prWidth = min(prWidth, termW/3)
 
-	midWidth := termW - leftWidth - prWidth - stagedWidth - 6
-	if midWidth < 10 {
-		extra := 10 - midWidth
-		if prWidth-extra > 10 {
-			prWidth -= extra
-		} else {
-			prWidth = 10
-		}
-		midWidth = termW - leftWidth - prWidth - stagedWidth - 6
-		if midWidth < 10 {
-			midWidth = 10
-			stagedWidth = min(stagedWidth, 20)
+	midWidth := avail - leftWidth - prWidth - stagedWidth
+	for _, w := range []*int{&prWidth, &stagedWidth, &leftWidth} {
+		if midWidth >= minColumnWidth {
+			break
 		}
+		give := min(minColumnWidth-midWidth, max(*w-minColumnWidth, 0))
+		*w -= give
+		midWidth += give
 	}
-	return leftWidth, midWidth, prWidth, stagedWidth
+	return leftWidth, max(midWidth, minColumnWidth), prWidth, stagedWidth
 }
 
 // --- Phase 0: User selection ---
 
 // updateUserSelection handles key input during the user selection phase.
 func (m model) updateUserSelection(k string) (tea.Model, tea.Cmd) {
-	switch k {
-	case "w", "up":
+	switch {
+	case m.keys.up.matches(k):
 		if m.userCursor > 0 {
 			m.userCursor--
 			visible := m.userSelectionVisibleLines()
 			ensureOffset(&m.userScrollOffset, m.userCursor, visible)
 		}
-	case "s", "down":
+	case m.keys.down.matches(k):
 		if m.userCursor < len(m.availableUsers)-1 {
 			m.userCursor++
 			visible := m.userSelectionVisibleLines()
 			ensureOffset(&m.userScrollOffset, m.userCursor, visible)
 		}
-	case " ", "x":
+	case k == " " || m.keys.approve.matches(k):
 		if m.userCursor >= 0 && m.userCursor < len(m.availableUsers) {
 			u := m.availableUsers[m.userCursor]
 			m.userSelected[u] = !m.userSelected[u]
 		}
-	case "enter":
+	case k == "enter":
 		// collect selected users
 		var selected []string
 		for _, u := range m.availableUsers {
@@ -1252,10 +1947,12 @@ func (m model) updateUserSelection(k string) (tea.Model, tea.Cmd) {
 		}
 		// filter hashes for selected users
 		joined := strings.Join(selected, ",")
-		m.hashes = approve.CollectHashesForUsers(joined, m.userHashPrMap)
+		m.hashes = m.orderHashes(joined, approve.CollectHashesForUsers(joined, m.userHashPrMap))
+		m.allHashes, m.filterQuery = nil, ""
 		m.phase = 1
 		m.updateStagedList()
 		m.updateViewportContent()
+		m.startSession(joined)
 	}
 	return m, nil
 }
@@ -1285,6 +1982,88 @@ func (m model) userPRCount(user string) int {
 	return len(seen)
 }
 
+// viewLoading renders the spinner shown while the review queue is fetched,
+// or the fetch error if it failed.
+func (m model) viewLoading() string {
+	if m.loadErr != nil {
+		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+		return errStyle.Render(m.status) + "\n\nq: quit"
+	}
+	who := "all users"
+	if m.loadUser != "" {
+		who = m.loadUser
+	}
+	msg := fmt.Sprintf("%s Loading PRs for %s…", m.spinner.View(), who)
+	if m.termWidth == 0 {
+		return msg
+	}
+	return lipgloss.Place(m.termWidth, m.termHeight, lipgloss.Center, lipgloss.Center, msg)
+}
+
+// reviewTally summarizes the review so far for the status line, e.g. "3
+// approved, 1 declined, 2 skipped of 20 hashes, 2 PR(s) staged", leaving
+// out skipped ones if there are none. While filtering it counts the whole
+// queue, not just the matching hashes.
+func (m model) reviewTally(staged int) string {
+	hashes := m.hashes
+	if m.allHashes != nil {
+		hashes = m.allHashes
+	}
+	approved, declined, deferred := 0, 0, 0
+	for _, h := range hashes {
+		if m.approved[h] {
+			approved++
+		} else if m.declined[h] {
+			declined++
+		} else if m.deferred[h] {
+			deferred++
+		}
+	}
+	if deferred > 0 {
+		return fmt.Sprintf("%d approved, %d declined, %d skipped of %d hashes, %d PR(s) staged", approved, declined, deferred, len(hashes), staged)
+	}
+	return fmt.Sprintf("%d approved, %d declined of %d hashes, %d PR(s) staged", approved, declined, len(hashes), staged)
+}
+
+// viewEmptyQueue replaces the panes when the queue has no hashes to review.
+func (m model) viewEmptyQueue() string {
+	who := m.loadUser
+	if who == "" {
+		var selected []string
+		for _, u := range m.availableUsers {
+			if m.userSelected[u] {
+				selected = append(selected, u)
+			}
+		}
+		who = strings.Join(selected, ", ")
+	}
+	msg := fmt.Sprintf("No review requests for %s 🎉 (press %s to quit)", who, m.keys.quit.help())
+	if warnings := m.warningLines(); len(warnings) > 0 {
+		msg += "\n\n" + strings.Join(warnings, "\n")
+	}
+	if m.termWidth == 0 {
+		return msg
+	}
+	return lipgloss.Place(m.termWidth, m.termHeight, lipgloss.Center, lipgloss.Center, msg)
+}
+
+// warningLines renders the footer lines warning about how the queue loaded:
+// a partial queue first, then the PRs that failed to load.
+func (m model) warningLines() []string {
+	var lines []string
+	if m.partialWarning != "" {
+		lines = append(lines, "⚠ "+m.partialWarning)
+	}
+	if len(m.fetchWarnings) > 0 {
+		lines = append(lines, fmt.Sprintf("⚠ %d PR(s) failed to load: %s", len(m.fetchWarnings), strings.Join(m.fetchWarnings, "; ")))
+	}
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).MaxWidth(max(m.termWidth-2, 10))
+	for i, line := range lines {
+		lines[i] = style.Render(line)
+	}
+	return lines
+}
+
 // viewUserSelection renders the user selection panel.
 func (m model) viewUserSelection() string {
 	titleStyle := lipgloss.NewStyle().Bold(true)
@@ -1460,40 +2239,46 @@ func (m model) viewSettings() string {
 func (m *model) buildFilteredPrMap() map[string][]string {
 	filtered := make(map[string][]string)
 	for prKey, phashes := range m.prMap {
-		if m.prSkipped[prKey] {
-			continue
-		}
-		allDeclined := true
-		for _, ph := range phashes {
-			if !m.declined[ph] {
-				allDeclined = false
-				break
-			}
+		if approve.ShouldApprovePR(prKey, phashes, m.approved, m.declined, m.prSkipped) {
+			filtered[prKey] = phashes
 		}
-		if allDeclined {
+	}
+	return filtered
+}
+
+// buildDeclinedPrMap returns the PRs that get a "request changes" review on
+// commit: with --submit-declines, skipped PRs whose hashes were all declined
+// and not yet submitted.
+func (m *model) buildDeclinedPrMap() map[string][]string {
+	declined := make(map[string][]string)
+	if !m.approveOpts.SubmitDeclines {
+		return declined
+	}
+	for prKey, phashes := range m.prMap {
+		if !m.prSkipped[prKey] || len(phashes) == 0 {
 			continue
 		}
-		allApproved := true
+		allDeclined, allCommitted := true, true
 		for _, ph := range phashes {
-			if m.declined[ph] {
-				allApproved = false
-				break
-			}
-			if len(m.approved) > 0 {
-				if !m.approved[ph] {
-					allApproved = false
-					break
-				}
-			} else {
-				allApproved = false
-				break
-			}
+			allDeclined = allDeclined && m.declined[ph]
+			allCommitted = allCommitted && m.committed[ph]
 		}
-		if allApproved {
-			filtered[prKey] = phashes
+		if allDeclined && !allCommitted {
+			declined[prKey] = phashes
 		}
 	}
-	return filtered
+	return declined
+}
+
+// drainApproveOutput returns and clears the lines the client printed while
+// approving.
+func (m model) drainApproveOutput() []string {
+	if m.approveOut == nil || m.approveOut.Len() == 0 {
+		return nil
+	}
+	out := strings.Split(strings.TrimRight(m.approveOut.String(), "\n"), "\n")
+	m.approveOut.Reset()
+	return out
 }
 
 // updateConfirmation handles key input during the confirmation dialog.
@@ -1501,46 +2286,469 @@ func (m model) updateConfirmation(k string) (tea.Model, tea.Cmd) {
 	switch k {
 	case "y":
 		filtered := m.buildFilteredPrMap()
-		var logs []string
-		if len(filtered) > 0 {
-			logs = approve.ProcessApprovals(filtered, m.approved, m.declined, m.prSkipped, m.hashPrMap, m.client, m.dryRun, m.settings.reviewComment)
-			for _, phashes := range filtered {
-				for _, ph := range phashes {
-					m.committed[ph] = true
-				}
-			}
-			m.reconcilePrSkipped()
-			m.updateStagedList()
+		for prKey, phashes := range m.buildDeclinedPrMap() {
+			filtered[prKey] = phashes
 		}
 		m.confirmCommit = false
-		m.status = "committed approvals"
-		m.viewport.GotoTop()
-		m.updateViewportContent()
-		// show commit log popup if there's anything to show
-		if len(logs) > 0 {
-			m.commitLog = logs
-			m.commitLogOffset = 0
-			m.showCommitLog = true
+		if len(filtered) == 0 {
+			m.status = "committed approvals"
+			return m, nil
 		}
+		return m, m.startCommit(filtered)
 	case "n":
 		m.confirmCommit = false
 		m.status = "commit cancelled"
+	case "e":
+		m.commentInput = textinput.New()
+		m.commentInput.Placeholder = "no comment"
+		m.commentInput.SetValue(m.settings.reviewComment)
+		m.commentInput.CursorEnd()
+		m.editingComment = true
+		return m, m.commentInput.Focus()
+	}
+	return m, nil
+}
+
+// commitProgressMsg reports that the background commit reached PR n of total.
+type commitProgressMsg struct {
+	n, total int
+	prKey    string
+}
+
+// commitDoneMsg carries the outcome of the background commit.
+type commitDoneMsg struct {
+	filtered map[string][]string
+	logs     []string
+	results  []gh.ApproveResult
+	changed  []string // PRs not approved as they changed since load
+	err      error
+}
+
+// startCommit approves or declines the PRs in filtered in the background so
+// the UI keeps redrawing; progress arrives as commitProgressMsg values and
+// the result as a commitDoneMsg.
+func (m *model) startCommit(filtered map[string][]string) tea.Cmd {
+	approveOpts := m.approveOpts
+	approveOpts.Comment = m.settings.reviewComment
+	ctx, ch := m.ctx, make(chan tea.Msg)
+	send := func(msg tea.Msg) {
+		select {
+		case ch <- msg:
+		case <-ctx.Done():
+		}
+	}
+	approved, declined, prSkipped, hashPrMap := m.approved, m.declined, m.prSkipped, m.hashPrMap
+	client := m.client
+	go func() {
+		logs, results, changed, err := approve.ProcessApprovalsWithProgress(ctx, filtered, approved, declined, prSkipped, hashPrMap, client, approveOpts, func(n, total int, prKey string) {
+			send(commitProgressMsg{n: n, total: total, prKey: prKey})
+		})
+		send(commitDoneMsg{filtered: filtered, logs: logs, results: results, changed: changed, err: err})
+	}()
+
+	m.committing = true
+	m.commitMsgs = ch
+	m.commitN, m.commitTotal, m.commitPR = 0, len(filtered), ""
+	m.commitProgress = progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage())
+	m.commitProgress.Width = 20
+	return waitForCommit(ch)
+}
+
+// waitForCommit returns the next message from the background commit.
+func waitForCommit(ch chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// finishCommit records a completed commit and shows its log, which includes
+// any per-PR errors, in the commit log popup.
+func (m *model) finishCommit(msg commitDoneMsg) {
+	m.committing = false
+	m.commitMsgs = nil
+	logs := append(m.drainApproveOutput(), msg.logs...)
+	for _, prKey := range msg.changed {
+		if m.headChanged == nil {
+			m.headChanged = map[string]bool{}
+		}
+		m.headChanged[prKey] = true
+	}
+	for _, res := range msg.results {
+		if res.AutoMerge {
+			if m.autoMerged == nil {
+				m.autoMerged = map[string]bool{}
+			}
+			m.autoMerged[res.PR] = true
+		}
+	}
+	for prKey, phashes := range msg.filtered {
+		// a changed PR stays staged until it is re-reviewed
+		if m.headChanged[prKey] {
+			continue
+		}
+		for _, ph := range phashes {
+			m.committed[ph] = true
+		}
+	}
+	m.recomputePrSkipped()
+	m.updateStagedList()
+	m.status = commitSummary(msg.results)
+	var ae *approve.ApprovalError
+	if errors.As(msg.err, &ae) {
+		m.approvalErr.Failed = append(m.approvalErr.Failed, ae.Failed...)
+		m.approvalErr.Succeeded += ae.Succeeded
+		m.status += " (" + ae.Error() + ")"
+	} else {
+		m.approvalErr.Succeeded += len(msg.results)
+	}
+	m.saveSession()
+	m.viewport.GotoTop()
+	m.updateViewportContent()
+	if len(logs) > 0 {
+		m.commitLog = logs
+		m.commitLogOffset = 0
+		m.showCommitLog = true
+	}
+}
+
+// commitSummary is the status line after a commit, naming the created
+// reviews, e.g. "approved → review #12345".
+func commitSummary(results []gh.ApproveResult) string {
+	if len(results) == 0 {
+		return "committed approvals"
+	}
+	ids := make([]string, len(results))
+	for i, res := range results {
+		ids[i] = fmt.Sprintf("#%d", res.ReviewID)
+	}
+	if len(ids) == 1 {
+		return "approved → review " + ids[0]
+	}
+	return fmt.Sprintf("approved %d PRs → reviews %s", len(ids), strings.Join(ids, ", "))
+}
+
+// commitStatus renders the progress bar and current PR of a running commit.
+func (m model) commitStatus() string {
+	if m.commitN == 0 {
+		return m.commitProgress.View() + " starting commit…"
+	}
+	return fmt.Sprintf("%s approving %d/%d: %s", m.commitProgress.View(), m.commitN, m.commitTotal, m.commitPR)
+}
+
+// updateFilterInput handles keys while the hash filter is being typed. The
+// list narrows on every keystroke; enter keeps the filter and returns to
+// navigation, esc clears it.
+func (m model) updateFilterInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.filtering = false
+		return m, nil
+	case "esc":
+		m.filtering = false
+		m.clearHashFilter()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	m.applyHashFilter(m.filterInput.Value())
+	return m, cmd
+}
+
+// orderHashes sorts hashes by readiness with --sort ready, when approval
+// states were loaded, keeping the hashes of each of users (comma-separated)
+// together, and records whose they are in m.authors.
+func (m *model) orderHashes(users string, hashes []string) []string {
+	m.authors = approve.HashAuthors(users, m.userHashPrMap)
+	if m.approvalStates != nil {
+		approve.SortHashesByReadiness(hashes, m.hashPrMap, m.approvalStates)
+	}
+	approve.GroupHashesByAuthor(hashes, m.authors)
+	return hashes
+}
+
+// hashAuthor returns whose changes h is reviewed as when the hashes under
+// review are by more than one author, and "" otherwise.
+func (m model) hashAuthor(h string) string {
+	for _, a := range m.authors {
+		if a != m.authors[h] {
+			return m.authors[h]
+		}
+	}
+	return ""
+}
+
+// applyHashFilter narrows hashes to those whose hash, file or change lines
+// contain query (case-insensitively), keeping the selected hash selected if
+// it still matches.
+func (m *model) applyHashFilter(query string) {
+	if m.allHashes == nil {
+		m.allHashes = m.hashes
+	}
+	selected := m.selectedHash()
+	m.filterQuery = strings.TrimSpace(query)
+	q := strings.ToLower(m.filterQuery)
+	var matched []string
+	for _, h := range m.allHashes {
+		if q == "" || m.hashMatches(h, q) {
+			matched = append(matched, h)
+		}
+	}
+	m.setHashes(matched, selected)
+}
+
+// clearHashFilter restores the full hash list.
+func (m *model) clearHashFilter() {
+	if m.allHashes == nil {
+		return
+	}
+	selected := m.selectedHash()
+	all := m.allHashes
+	m.allHashes, m.filterQuery = nil, ""
+	m.setHashes(all, selected)
+}
+
+// setHashes replaces the visible hash list, moving the selection to selected
+// when present and to the top otherwise.
+func (m *model) setHashes(hashes []string, selected string) {
+	m.hashes = hashes
+	m.hashIndex = 0
+	for i, h := range hashes {
+		if h == selected {
+			m.hashIndex = i
+			break
+		}
+	}
+	m.hashOffset = 0
+	ensureOffset(&m.hashOffset, m.hashIndex, m.topVisibleLines())
+	m.updateViewportContent()
+}
+
+// hashMatches reports whether lowercase query q occurs in h, its file or its
+// change lines.
+func (m model) hashMatches(h, q string) bool {
+	if strings.Contains(h, q) {
+		return true
+	}
+	hunk, ok := m.changeMap[h]
+	if !ok {
+		return false
+	}
+	if strings.Contains(strings.ToLower(hunk.File), q) {
+		return true
+	}
+	for _, l := range hunk.Lines {
+		if strings.Contains(strings.ToLower(l), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCommentInput handles keys while the review comment is being edited.
+// enter keeps the new comment for this session (an empty one approves
+// without a comment), esc discards it.
+func (m model) updateCommentInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.settings.reviewComment = strings.TrimSpace(m.commentInput.Value())
+		m.editingComment = false
+		return m, nil
+	case "esc":
+		m.editingComment = false
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.commentInput, cmd = m.commentInput.Update(msg)
+	return m, cmd
+}
+
+// --- Undoing commits ---
+
+// dismissReviewReason is the message shown on GitHub for undone approvals.
+const dismissReviewReason = "Approval withdrawn via gh-pr-review."
+
+// dismissedMsg reports the outcome of dismissing the approval of prKey and,
+// if a commit enabled it, disabling its auto-merge.
+type dismissedMsg struct {
+	prKey        string
+	err          error
+	autoMerge    bool  // auto-merge was on
+	autoMergeErr error // disabling it failed, so it is still on
+}
+
+// uncommitSelectedPR withdraws the approval of the selected PR if it was
+// committed in this session, dismissing the review on GitHub and disabling
+// the auto-merge the commit enabled.
+func (m model) uncommitSelectedPR() (tea.Model, tea.Cmd) {
+	prKey := m.selectedPR()
+	if _, _, committed := m.prApprovalState(prKey); prKey == "" || !committed {
+		m.status = "selected PR was not committed in this session"
+		return m, nil
+	}
+	if m.approveOpts.DryRun {
+		m.uncommitPR(prKey)
+		m.status = "[dry-run] would dismiss your approval of " + prKey
+		return m, nil
+	}
+	pr := m.findPR(prKey)
+	ctx, client, autoMerge := m.ctx, m.client, m.autoMerged[prKey]
+	m.status = "dismissing your approval of " + prKey + "…"
+	return m, func() tea.Msg {
+		msg := dismissedMsg{prKey: prKey, autoMerge: autoMerge}
+		if msg.err = client.DismissReview(ctx, pr, dismissReviewReason); msg.err == nil && autoMerge {
+			msg.autoMergeErr = client.DisableAutoMerge(ctx, pr)
+		}
+		return msg
+	}
+}
+
+// dismissedStatus describes a withdrawn approval for the status line,
+// warning when auto-merge could not be disabled.
+func dismissedStatus(msg dismissedMsg) string {
+	switch {
+	case msg.autoMergeErr != nil:
+		return fmt.Sprintf("warning: dismissed your approval of %s, but auto-merge is still on: %v", msg.prKey, msg.autoMergeErr)
+	case msg.autoMerge:
+		return "dismissed your approval of " + msg.prKey + " and disabled auto-merge"
+	default:
+		return "dismissed your approval of " + msg.prKey
+	}
+}
+
+// uncommitPR moves prKey back from committed to staged. Hashes shared with
+// another committed PR stay committed.
+func (m *model) uncommitPR(prKey string) {
+	keep := map[string]bool{}
+	for _, h := range m.prMap[prKey] {
+		for _, pr := range m.hashPrMap[h] {
+			other := pr.GetHTMLURL()
+			if _, _, committed := m.prApprovalState(other); other != prKey && committed {
+				for _, oh := range m.prMap[other] {
+					keep[oh] = true
+				}
+			}
+		}
+	}
+	for _, h := range m.prMap[prKey] {
+		if !keep[h] {
+			delete(m.committed, h)
+		}
+	}
+	m.updateStagedList()
+	m.updateViewportContent()
+	m.saveSession()
+}
+
+// findPR returns the PR object for prKey.
+func (m model) findPR(prKey string) *github.PullRequest {
+	for _, h := range m.prMap[prKey] {
+		for _, pr := range m.hashPrMap[h] {
+			if pr.GetHTMLURL() == prKey {
+				return pr
+			}
+		}
+	}
+	return nil
+}
+
+// --- Saved sessions ---
+
+// startSession begins saving decisions for users and offers to restore a
+// previously saved session for them, pruned to what is still in the queue.
+func (m *model) startSession(users string) {
+	m.sessionUser = sessionKey(users)
+	if m.fresh || m.sessionDir == "" || m.sessionUser == "" {
+		return
+	}
+	s, err := loadSession(m.sessionDir, m.sessionUser)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			m.status = "warning: could not read saved session: " + err.Error()
+		}
+		return
+	}
+	s.prune(m.hashes, m.prMap)
+	if !s.empty() {
+		m.resume = &s
+	}
+}
+
+// saveSession writes the current decisions, so quitting or a crash doesn't
+// lose them.
+func (m *model) saveSession() {
+	if m.sessionDir == "" || m.sessionUser == "" {
+		return
+	}
+	s := newSessionState(m.sessionUser, m.approved, m.declined, m.prSkipped, m.committed)
+	if err := saveSession(m.sessionDir, s); err != nil {
+		m.status = "warning: could not save session: " + err.Error()
+	}
+}
+
+// updateResumePrompt handles the answer to the resume prompt.
+func (m model) updateResumePrompt(k string) (tea.Model, tea.Cmd) {
+	switch k {
+	case "y":
+		for _, h := range m.resume.Approved {
+			m.approved[h] = true
+		}
+		for _, h := range m.resume.Declined {
+			m.declined[h] = true
+		}
+		// PRs approved before quitting must not be staged, and approved, again
+		for _, h := range m.resume.Committed {
+			m.committed[h] = true
+		}
+		// the saved skipped PRs follow from the declined hashes
+		m.resume = nil
+		m.recomputePrSkipped()
+		m.updateStagedList()
+		m.updateViewportContent()
+		m.status = "resumed saved session"
+		// write back the pruned session
+		m.saveSession()
+	case "n":
+		m.resume = nil
+		m.status = "started a fresh session"
+		if err := removeSession(m.sessionDir, m.sessionUser); err != nil {
+			m.status = "warning: could not remove saved session: " + err.Error()
+		}
 	}
 	return m, nil
 }
 
+// viewResumePrompt renders the offer to restore a saved session.
+func (m model) viewResumePrompt() string {
+	s := m.resume
+	lines := []string{
+		lipgloss.NewStyle().Bold(true).Render("Resume your saved session?"),
+		"",
+		fmt.Sprintf("  Saved %s for %s", s.Saved.Local().Format("2006-01-02 15:04"), s.User),
+		fmt.Sprintf("  %d approved and %d declined hash(es) still in the queue", len(s.Approved), len(s.Declined)),
+		"",
+		lipgloss.NewStyle().Bold(true).Render("  Press 'y' to resume, 'n' to start over"),
+	}
+	dialogStyle := lipgloss.NewStyle().
+		Width(60).
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(lipgloss.Color("11")).
+		Padding(1, 2)
+	dialog := dialogStyle.Render(strings.Join(lines, "\n"))
+	return lipgloss.Place(m.termWidth, m.termHeight, lipgloss.Center, lipgloss.Center, dialog)
+}
+
 // --- Commit log popup ---
 
 // updateCommitLog handles key input while the commit log popup is shown.
 func (m model) updateCommitLog(k string) (tea.Model, tea.Cmd) {
-	switch k {
-	case "enter", "q", "esc":
+	switch {
+	case k == "enter" || m.keys.quit.matches(k):
 		m.showCommitLog = false
-	case "w", "up":
+	case m.keys.up.matches(k):
 		if m.commitLogOffset > 0 {
 			m.commitLogOffset--
 		}
-	case "s", "down":
+	case m.keys.down.matches(k):
 		visible := m.commitLogVisibleLines()
 		maxOff := len(m.commitLog) - visible
 		if maxOff < 0 {
@@ -1549,13 +2757,13 @@ func (m model) updateCommitLog(k string) (tea.Model, tea.Cmd) {
 		if m.commitLogOffset < maxOff {
 			m.commitLogOffset++
 		}
-	case "pgup":
+	case k == "pgup":
 		visible := m.commitLogVisibleLines()
 		m.commitLogOffset -= visible
 		if m.commitLogOffset < 0 {
 			m.commitLogOffset = 0
 		}
-	case "pgdown":
+	case k == "pgdown":
 		visible := m.commitLogVisibleLines()
 		maxOff := len(m.commitLog) - visible
 		if maxOff < 0 {
@@ -1638,11 +2846,31 @@ func (m model) viewConfirmation() string {
 		lines = append(lines, fmt.Sprintf("  %s %s", approve.VerifiedIcon(m.verifiedMap[prKey]), shortenPRURL(prKey)))
 	}
 	lines = append(lines, "")
-	if m.settings.reviewComment != "" {
-		lines = append(lines, fmt.Sprintf("  Review comment: %s", m.settings.reviewComment))
+	if declined := m.buildDeclinedPrMap(); len(declined) > 0 {
+		var declinedKeys []string
+		for k := range declined {
+			declinedKeys = append(declinedKeys, k)
+		}
+		sort.Strings(declinedKeys)
+		lines = append(lines, lipgloss.NewStyle().Bold(true).Render("Request changes on:"))
+		for _, prKey := range declinedKeys {
+			lines = append(lines, "  "+shortenPRURL(prKey))
+		}
+		lines = append(lines, "")
+	}
+	if m.editingComment {
+		lines = append(lines, "  Review comment: "+m.commentInput.View())
+		lines = append(lines, "")
+		lines = append(lines, lipgloss.NewStyle().Bold(true).Render("  Press enter to keep the comment, esc to discard changes"))
+	} else {
+		if m.settings.reviewComment != "" {
+			lines = append(lines, fmt.Sprintf("  Review comment: %s", m.settings.reviewComment))
+		} else {
+			lines = append(lines, "  Review comment: (none)")
+		}
 		lines = append(lines, "")
+		lines = append(lines, lipgloss.NewStyle().Bold(true).Render("  Press 'y' to confirm, 'n' to cancel, 'e' to edit the comment"))
 	}
-	lines = append(lines, lipgloss.NewStyle().Bold(true).Render("  Press 'y' to confirm, 'n' to cancel"))
 
 	content := strings.Join(lines, "\n")
 
@@ -0,0 +1,531 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v72/github"
+	"github.com/mallendem/gh-pr-review/pkg/gh"
+)
+
+// layoutModel returns an approval view over one hash shared by PRs with long
+// URLs, so the PR column asks for more width than narrow terminals have.
+func layoutModel() model {
+	var prs []*github.PullRequest
+	for i := range 3 {
+		url := fmt.Sprintf("https://github.com/some-organization/a-rather-long-repository-name/pull/%d", 1000+i)
+		prs = append(prs, &github.PullRequest{HTMLURL: github.Ptr(url), Body: github.Ptr("body")})
+	}
+	hash := strings.Repeat("ab", 32)
+	m := model{
+		phase:     1,
+		approved:  map[string]bool{},
+		declined:  map[string]bool{},
+		prSkipped: map[string]bool{},
+		committed: map[string]bool{},
+		settings:  defaultSettings(),
+		keys:      defaultKeyMap(),
+		ctx:       context.Background(),
+	}
+	m.applyLoaded(loadedMsg{
+		hashes: []string{hash},
+		client: new(gh.GhClient), // PR bodies are read without requests
+		res: &gh.FetchResult{
+			HashPrMap:   gh.HashPrMap{hash: prs},
+			PrMap:       gh.PrHashMap{prs[0].GetHTMLURL(): {hash}},
+			ChangeMap:   gh.HashChangeMap{hash: {File: "a.go", Lines: []string{"+" + strings.Repeat("x", 300)}}},
+			HashFileMap: gh.HashFileMap{hash: {prs[0].GetHTMLURL(): "a.go"}},
+		},
+	})
+	return m
+}
+
+func TestColumnWidthsFitTerminal(t *testing.T) {
+	border := topColumnStyle(0, 1).GetHorizontalBorderSize()
+	for _, compactWidth := range []int{defaultSettings().compactWidth, 0} {
+		for _, width := range []int{48, 60, 80, 100, 120, 200} {
+			m := layoutModel()
+			m.settings.compactWidth = compactWidth
+			m.termWidth = width
+			left, mid, pr, staged := m.columnWidths()
+			total := left + mid + pr + staged + 4*border
+			if m.compact() {
+				total = left + mid + 2*border
+			}
+			if total > width {
+				t.Errorf("width %d (compact %t): columns %d+%d+%d+%d take %d", width, m.compact(), left, mid, pr, staged, total)
+			}
+			for name, w := range map[string]int{"changes": mid, "PR": pr, "staged": staged} {
+				if w < minColumnWidth {
+					t.Errorf("width %d: %s column is %d wide, want at least %d", width, name, w, minColumnWidth)
+				}
+			}
+
+			next, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: 30})
+			for i, line := range strings.Split(next.View(), "\n") {
+				if w := lipgloss.Width(line); w > width {
+					t.Errorf("width %d (compact %t): line %d is %d wide: %q", width, m.compact(), i, w, line)
+				}
+			}
+		}
+	}
+}
+
+func TestColumnWidthsShrinkCascade(t *testing.T) {
+	m := layoutModel()
+	m.settings.compactWidth = 0
+	m.termWidth = 120
+	_, _, widePR, wideStaged := m.columnWidths()
+
+	// too narrow for the preferred PR width: the PR column shrinks first
+	m.termWidth = 60
+	_, mid, pr, staged := m.columnWidths()
+	if mid != minColumnWidth || pr >= widePR || staged != 60/4 {
+		t.Errorf("width 60: mid %d, pr %d, staged %d; want the PR column shrunk to fit mid %d", mid, pr, staged, minColumnWidth)
+	}
+
+	// PR already at its minimum: the staged column gives up width next
+	m.termWidth = 48
+	_, mid, pr, staged = m.columnWidths()
+	if mid != minColumnWidth || pr != minColumnWidth || staged >= wideStaged {
+		t.Errorf("width 48: mid %d, pr %d, staged %d; want PR at %d and staged shrunk", mid, pr, staged, minColumnWidth)
+	}
+}
+
+func TestCompactLayout(t *testing.T) {
+	var next tea.Model = layoutModel()
+	next, _ = next.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	view := next.View()
+	if !strings.Contains(view, "View: Changes") || strings.Contains(view, "Related PRs") {
+		t.Errorf("compact view with the hashes focused should show only the changes:\n%s", view)
+	}
+	// moving right cycles the context pane through the other columns
+	next, _ = next.Update(tea.KeyMsg{Type: tea.KeyRight})
+	next, _ = next.Update(tea.KeyMsg{Type: tea.KeyRight})
+	view = next.View()
+	if !strings.Contains(view, "View: Related PRs") || strings.Contains(view, "Staged changes") {
+		t.Errorf("compact view with the PRs focused should show only the PRs:\n%s", view)
+	}
+
+	next, _ = next.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	if view := next.View(); strings.Contains(view, "View:") || !strings.Contains(view, "Staged changes") {
+		t.Errorf("wide view should show every column:\n%s", view)
+	}
+}
+
+func TestChangesHScrollMatchesRendering(t *testing.T) {
+	for _, width := range []int{48, 120} {
+		var next tea.Model = layoutModel()
+		next, _ = next.Update(tea.WindowSizeMsg{Width: width, Height: 30})
+		for range 400 {
+			next, _ = next.Update(tea.KeyMsg{Type: tea.KeyRight, Alt: true})
+		}
+		m := next.(model)
+		_, mid, _, _ := m.columnWidths()
+		longest := 0
+		for _, cl := range m.changesForFileTab() {
+			longest = max(longest, len(cl))
+		}
+		if want := longest - changesContentWidth(mid); m.changeHOffset != want {
+			t.Errorf("width %d: scrolled to %d, want %d so the end of the line is just visible", width, m.changeHOffset, want)
+		}
+		// a change line wider than the column would wrap and push the
+		// layout past the terminal height
+		if lines := strings.Count(m.View(), "\n") + 1; lines > 30 {
+			t.Errorf("width %d: view is %d lines, want at most 30", width, lines)
+		}
+	}
+}
+
+func TestEmptyQueue(t *testing.T) {
+	m := model{
+		phase:     1,
+		loadUser:  "alice",
+		approved:  map[string]bool{},
+		declined:  map[string]bool{},
+		prSkipped: map[string]bool{},
+		committed: map[string]bool{},
+		settings:  defaultSettings(),
+		keys:      defaultKeyMap(),
+		ctx:       context.Background(),
+	}
+	m.applyLoaded(loadedMsg{client: new(gh.GhClient), res: &gh.FetchResult{}})
+	var next tea.Model = m
+	next, _ = next.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	if view := next.View(); !strings.Contains(view, "No review requests for alice") {
+		t.Errorf("empty queue view:\n%s", view)
+	}
+
+	// no key may reach for a hash that isn't there
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyDown}, {Type: tea.KeyUp}, {Type: tea.KeyPgDown}, {Type: tea.KeyEnd},
+		{Type: tea.KeyRunes, Runes: []rune("x")}, {Type: tea.KeyRunes, Runes: []rune("f")},
+		{Type: tea.KeyRunes, Runes: []rune("v")}, {Type: tea.KeyRight, Alt: true},
+	}
+	for col := range 4 {
+		for _, k := range keys {
+			next, _ = next.Update(k)
+			_ = next.View()
+		}
+		if col < 3 {
+			next, _ = next.Update(tea.KeyMsg{Type: tea.KeyRight})
+		}
+	}
+}
+
+func TestReviewTally(t *testing.T) {
+	var next tea.Model = layoutModel()
+	next, _ = next.Update(tea.WindowSizeMsg{Width: 200, Height: 30})
+	if view := next.View(); !strings.Contains(view, "0 approved, 0 declined of 1 hashes, 0 PR(s) staged") {
+		t.Errorf("tally before reviewing missing:\n%s", view)
+	}
+	next, _ = next.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if view := next.View(); !strings.Contains(view, "1 approved, 0 declined of 1 hashes, 1 PR(s) staged") {
+		t.Errorf("tally after approving missing:\n%s", view)
+	}
+	// the status line stays a single line on a narrow terminal
+	next, _ = next.Update(tea.WindowSizeMsg{Width: 40, Height: 30})
+	if header, _, _ := strings.Cut(next.View(), "\n"); lipgloss.Width(header) > 40 {
+		t.Errorf("status line %q is wider than the terminal", header)
+	}
+}
+
+func TestRenderPRLabelMergeState(t *testing.T) {
+	m := layoutModel()
+	var urls []string
+	for _, pr := range m.hashPrMap[m.hashes[0]] {
+		urls = append(urls, pr.GetHTMLURL())
+	}
+	m.mergeStates = map[string]gh.MergeState{
+		urls[0]: gh.MergeStateConflicts,
+		urls[1]: gh.MergeStateMerged,
+		urls[2]: gh.MergeStateChecksFailing,
+	}
+	if got := m.renderPRLabel(urls[0], 0); !strings.HasPrefix(got, "● ") {
+		t.Errorf("PR with conflicts = %q, want a dot in front", got)
+	}
+	if got := m.renderPRLabel(urls[1], 1); !strings.HasSuffix(got, "(merged)") {
+		t.Errorf("merged PR = %q, want it marked merged", got)
+	}
+	if got := m.renderPRLabel(urls[2], 2); !strings.HasSuffix(got, "(checks failing)") {
+		t.Errorf("PR with failing checks = %q, want them flagged", got)
+	}
+	// --require-green's own check state takes over from the merge state
+	m.checkStates = map[string]gh.CheckState{urls[2]: gh.CheckFailure}
+	if got := m.renderPRLabel(urls[2], 2); strings.Count(got, "checks") != 1 {
+		t.Errorf("PR with failing checks = %q, want them flagged once", got)
+	}
+}
+
+func TestPartialQueueWarning(t *testing.T) {
+	m := model{
+		phase:     1,
+		loadUser:  "alice",
+		approved:  map[string]bool{},
+		declined:  map[string]bool{},
+		prSkipped: map[string]bool{},
+		committed: map[string]bool{},
+		settings:  defaultSettings(),
+		keys:      defaultKeyMap(),
+		ctx:       context.Background(),
+	}
+	msg := refreshQueue("aaaaaaaa")
+	msg.res.Unloaded = 7
+	m.applyLoaded(msg)
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 160, Height: 40})
+	m = next.(model)
+
+	lines := m.warningLines()
+	if len(lines) != 1 || !strings.Contains(lines[0], "7 PR(s) not loaded") {
+		t.Fatalf("warning lines = %q, want only the partial queue warning", lines)
+	}
+	view := m.View()
+	if !strings.Contains(view, "7 PR(s) not loaded") || strings.Contains(view, "failed to load") {
+		t.Errorf("view does not warn of the partial queue alone:\n%s", view)
+	}
+}
+
+func TestHeadChangedBadge(t *testing.T) {
+	m := model{
+		phase:     1,
+		loadUser:  "alice",
+		approved:  map[string]bool{},
+		declined:  map[string]bool{},
+		prSkipped: map[string]bool{},
+		committed: map[string]bool{},
+		settings:  defaultSettings(),
+		keys:      defaultKeyMap(),
+		ctx:       context.Background(),
+	}
+	m.applyLoaded(refreshQueue("aaaaaaaa", "bbbbbbbb"))
+	pr1, pr2 := "https://github.com/o/r/pull/1", "https://github.com/o/r/pull/2"
+	m.finishCommit(commitDoneMsg{
+		filtered: map[string][]string{pr1: {"aaaaaaaa"}, pr2: {"bbbbbbbb"}},
+		changed:  []string{pr2},
+	})
+	if !m.committed["aaaaaaaa"] || m.committed["bbbbbbbb"] {
+		t.Errorf("committed = %v, want only the unchanged PR's hash", m.committed)
+	}
+	if got := m.renderPRLabel(pr2, 1); !strings.Contains(got, "changed since load") {
+		t.Errorf("changed PR = %q, want the re-review badge", got)
+	}
+	if got := m.renderPRLabel(pr1, 0); strings.Contains(got, "changed since load") {
+		t.Errorf("unchanged PR = %q, want no badge", got)
+	}
+	m.applyLoaded(refreshQueue("aaaaaaaa", "bbbbbbbb"))
+	if got := m.renderPRLabel(pr2, 1); strings.Contains(got, "changed since load") {
+		t.Errorf("reloaded PR = %q, want the badge cleared", got)
+	}
+}
+
+func TestDismissedStatus(t *testing.T) {
+	const pr = "https://github.com/o/r/pull/1"
+	tests := []struct {
+		msg  dismissedMsg
+		want string
+	}{
+		{dismissedMsg{prKey: pr}, "dismissed your approval of " + pr},
+		{dismissedMsg{prKey: pr, autoMerge: true}, "dismissed your approval of " + pr + " and disabled auto-merge"},
+		{dismissedMsg{prKey: pr, autoMerge: true, autoMergeErr: fmt.Errorf("boom")}, "warning: dismissed your approval of " + pr + ", but auto-merge is still on: boom"},
+	}
+	for _, tt := range tests {
+		if got := dismissedStatus(tt.msg); got != tt.want {
+			t.Errorf("dismissedStatus(%+v) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestSkipHash(t *testing.T) {
+	m := model{
+		phase:     1,
+		loadUser:  "alice",
+		approved:  map[string]bool{},
+		declined:  map[string]bool{},
+		prSkipped: map[string]bool{},
+		committed: map[string]bool{},
+		deferred:  map[string]bool{},
+		propagate: true,
+		settings:  defaultSettings(),
+		keys:      defaultKeyMap(),
+		ctx:       context.Background(),
+	}
+	m.applyLoaded(refreshQueue("aaaaaaaa", "bbbbbbbb"))
+	press := func(k tea.KeyMsg) {
+		t.Helper()
+		next, _ := m.Update(k)
+		m = next.(model)
+	}
+	pr1 := "https://github.com/o/r/pull/1"
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	press(tea.KeyMsg{Type: tea.KeySpace})
+	if !m.deferred["aaaaaaaa"] || m.approved["aaaaaaaa"] || m.declined["aaaaaaaa"] || m.prSkipped[pr1] {
+		t.Errorf("after skipping: deferred %v, approved %v, declined %v, prSkipped %v", m.deferred, m.approved, m.declined, m.prSkipped)
+	}
+	if staged := m.stagedPrKeys(); len(staged) != 0 {
+		t.Errorf("staged %v with a skipped hash", staged)
+	}
+	if got := m.reviewTally(0); got != "0 approved, 0 declined, 1 skipped of 2 hashes, 0 PR(s) staged" {
+		t.Errorf("tally = %q", got)
+	}
+
+	// a skipped hash can still be approved and its PR staged
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if m.deferred["aaaaaaaa"] || !m.approved["aaaaaaaa"] {
+		t.Errorf("after approving: deferred %v, approved %v", m.deferred, m.approved)
+	}
+	if staged := m.stagedPrKeys(); !slices.Equal(staged, []string{pr1}) {
+		t.Errorf("staged %v, want %s", staged, pr1)
+	}
+
+	// space toggles
+	press(tea.KeyMsg{Type: tea.KeySpace})
+	press(tea.KeyMsg{Type: tea.KeySpace})
+	if m.deferred["aaaaaaaa"] || m.approved["aaaaaaaa"] {
+		t.Errorf("after skipping twice: deferred %v, approved %v", m.deferred, m.approved)
+	}
+}
+
+func TestPrSkippedFollowsDecisions(t *testing.T) {
+	// one PR with two hashes, so decisions on one affect the other's PR
+	res := &gh.FetchResult{
+		UserHashPrMap: gh.GhPrHashMap{"alice": {}},
+		HashPrMap:     gh.HashPrMap{},
+		PrMap:         gh.PrHashMap{},
+		ChangeMap:     gh.HashChangeMap{},
+	}
+	pr := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/1")}
+	hashes := []string{"aaaaaaaa", "bbbbbbbb"}
+	for _, h := range hashes {
+		res.UserHashPrMap["alice"][h] = []*github.PullRequest{pr}
+		res.HashPrMap[h] = []*github.PullRequest{pr}
+		res.ChangeMap[h] = gh.Hunk{File: h + ".go", Lines: []string{"+" + h}}
+	}
+	res.PrMap[pr.GetHTMLURL()] = hashes
+
+	for _, tt := range []struct {
+		name        string
+		keys        string // x approves and f declines the selected hash, j moves down
+		wantSkipped bool
+		wantStaged  bool
+	}{
+		{"approve then decline", "xjf", true, false},
+		{"decline then approve", "fx", true, false}, // b stays declined through a
+		{"decline then approve the other", "jfkx", true, false},
+		{"decline then approve both", "fxjx", false, true},
+		{"approve both then decline", "xjxkf", true, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{
+				phase:     1,
+				loadUser:  "alice",
+				approved:  map[string]bool{},
+				declined:  map[string]bool{},
+				prSkipped: map[string]bool{},
+				committed: map[string]bool{},
+				deferred:  map[string]bool{},
+				settings:  defaultSettings(),
+				keys:      defaultKeyMap(),
+				ctx:       context.Background(),
+			}
+			m.applyLoaded(loadedMsg{hashes: slices.Clone(hashes), res: res, client: new(gh.GhClient)})
+			for _, r := range tt.keys {
+				next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+				m = next.(model)
+			}
+			if got := m.prSkipped[pr.GetHTMLURL()]; got != tt.wantSkipped {
+				t.Errorf("skipped = %v, want %v (approved %v, declined %v)", got, tt.wantSkipped, m.approved, m.declined)
+			}
+			if got := len(m.stagedPrKeys()) == 1; got != tt.wantStaged {
+				t.Errorf("staged = %v, want %v", got, tt.wantStaged)
+			}
+		})
+	}
+}
+
+func TestHashSelectionClamped(t *testing.T) {
+	m := model{
+		phase:     1,
+		loadUser:  "alice",
+		approved:  map[string]bool{},
+		declined:  map[string]bool{},
+		prSkipped: map[string]bool{},
+		committed: map[string]bool{},
+		settings:  defaultSettings(),
+		keys:      defaultKeyMap(),
+		ctx:       context.Background(),
+		termWidth: 120, termHeight: 30,
+	}
+	m.applyLoaded(refreshQueue("aaaaaaaa", "bbbbbbbb", "cccccccc"))
+	m.hashIndex, m.hashOffset = 2, 2
+
+	// the list shrank behind the selection's back
+	m.hashes = m.hashes[:1]
+	_ = m.View()
+	m.updateViewportContent()
+	if m.hashIndex != 0 || m.hashOffset != 0 || m.selectedHash() != "aaaaaaaa" {
+		t.Errorf("hashIndex %d, hashOffset %d, selected %q; want the last remaining hash", m.hashIndex, m.hashOffset, m.selectedHash())
+	}
+
+	m.hashes, m.hashIndex, m.hashOffset = nil, 3, -1
+	_ = m.View()
+	m.updateViewportContent()
+	if m.hashIndex != 0 || m.hashOffset != 0 {
+		t.Errorf("hashIndex %d, hashOffset %d on an empty list, want 0, 0", m.hashIndex, m.hashOffset)
+	}
+}
+
+func TestFileView(t *testing.T) {
+	m := model{
+		phase:     1,
+		loadUser:  "alice",
+		approved:  map[string]bool{},
+		declined:  map[string]bool{},
+		prSkipped: map[string]bool{},
+		committed: map[string]bool{},
+		settings:  defaultSettings(),
+		keys:      defaultKeyMap(),
+		ctx:       context.Background(),
+		fileView:  true,
+	}
+	msg := refreshQueue("aaaaaaaa")
+	msg.files = gh.PrFileMap{"https://github.com/o/r/pull/1": {
+		{Filename: "a.go", Status: "modified", Additions: 1, Deletions: 1, Patch: "@@ -1 +1 @@\n-old\n+new"},
+	}}
+	m.applyLoaded(msg)
+
+	if got := m.bottomPaneName(); got != "files" {
+		t.Errorf("bottom pane = %q, want files", got)
+	}
+	content := m.bottomPaneContent()
+	for _, s := range []string{"o/r#1: 1 file(s)", "a.go (+1 -1, modified)", "+new"} {
+		if !strings.Contains(content, s) {
+			t.Errorf("files pane missing %q:\n%s", s, content)
+		}
+	}
+	m.diffView = true
+	if got := m.bottomPaneName(); got != "diff" {
+		t.Errorf("bottom pane with the diff toggled = %q, want diff", got)
+	}
+}
+
+func TestPRDetail(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	pr := &github.PullRequest{
+		User:      &github.User{Login: github.Ptr("alice")},
+		CreatedAt: &github.Timestamp{Time: now.Add(-3*24*time.Hour - time.Hour)},
+	}
+	tests := []struct {
+		name  string
+		pr    *github.PullRequest
+		width int
+		want  string
+	}{
+		{"wide", pr, 40, "  alice · 3d"},
+		{"narrow keeps the age", pr, 8, "  3d"},
+		{"no age", &github.PullRequest{User: pr.User}, 4, "  alice"},
+		{"nothing known", &github.PullRequest{}, 40, ""},
+	}
+	for _, tt := range tests {
+		if got := prDetail(tt.pr, now, tt.width); got != tt.want {
+			t.Errorf("%s: prDetail = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	for d, want := range map[time.Duration]string{
+		-time.Minute:           "0m",
+		40 * time.Minute:       "40m",
+		5 * time.Hour:          "5h",
+		13 * 24 * time.Hour:    "13d",
+		6 * 7 * 24 * time.Hour: "6w",
+	} {
+		if got := prAge(d); got != want {
+			t.Errorf("prAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestSelectedPRCountsDetailLines(t *testing.T) {
+	created := &github.Timestamp{Time: time.Now().Add(-time.Hour)}
+	pr1 := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/1"), CreatedAt: created}
+	pr2 := &github.PullRequest{HTMLURL: github.Ptr("https://github.com/o/r/pull/2"), CreatedAt: created}
+	m := model{
+		phase:     1,
+		hashes:    []string{"aaaaaaaa"},
+		hashPrMap: gh.HashPrMap{"aaaaaaaa": {pr1, pr2}},
+		prMap:     map[string][]string{pr1.GetHTMLURL(): {"aaaaaaaa"}, pr2.GetHTMLURL(): {"aaaaaaaa"}},
+		col:       2,
+	}
+	// PR 1 takes its label, its age and its hash: lines 0-2
+	for offset, want := range map[int]string{2: pr1.GetHTMLURL(), 3: pr2.GetHTMLURL()} {
+		m.prOffset = offset
+		if got := m.selectedPR(); got != want {
+			t.Errorf("prOffset %d selects %s, want %s", offset, got, want)
+		}
+	}
+}
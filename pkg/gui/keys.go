@@ -0,0 +1,222 @@
+package gui
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// binding is the set of keys, in tea.KeyMsg.String() form, bound to one action.
+type binding []string
+
+// matches reports whether k triggers the binding.
+func (b binding) matches(k string) bool {
+	for _, bk := range b {
+		if bk == k {
+			return true
+		}
+	}
+	return false
+}
+
+// help renders the binding for the footer hint, e.g. "w/k/↑".
+func (b binding) help() string {
+	names := make([]string, len(b))
+	for i, k := range b {
+		names[i] = keyHelpName(k)
+	}
+	return strings.Join(names, "/")
+}
+
+// arrowSymbols are shown in place of arrow key names in the footer hint.
+var arrowSymbols = map[string]string{"left": "←", "right": "→", "up": "↑", "down": "↓"}
+
+// keyHelpName returns how key k is shown in the footer hint.
+func keyHelpName(k string) string {
+	if s, ok := arrowSymbols[k]; ok {
+		return s
+	}
+	if rest, ok := strings.CutPrefix(k, "alt+"); ok {
+		return "alt+" + keyHelpName(rest)
+	}
+	if k == " " {
+		return "space"
+	}
+	return k
+}
+
+// keyMap holds the remappable key bindings of the approval view.
+type keyMap struct {
+	approve      binding
+	decline      binding
+	skip         binding
+	commit       binding
+	up           binding
+	down         binding
+	left         binding
+	right        binding
+	switchRow    binding
+	hscrollLeft  binding
+	hscrollRight binding
+	copyURL      binding
+	openURL      binding
+	uncommit     binding
+	refresh      binding
+	quit         binding
+}
+
+// defaultKeyMap returns the built-in bindings: w/a/s/d with vim-style and
+// arrow key aliases.
+func defaultKeyMap() keyMap {
+	return keyMap{
+		approve:      binding{"x"},
+		decline:      binding{"f"},
+		skip:         binding{" "},
+		commit:       binding{"c"},
+		up:           binding{"w", "k", "up"},
+		down:         binding{"s", "j", "down"},
+		left:         binding{"a", "h", "left"},
+		right:        binding{"d", "l", "right"},
+		switchRow:    binding{"tab"},
+		hscrollLeft:  binding{"alt+a", "alt+h", "alt+left"},
+		hscrollRight: binding{"alt+d", "alt+l", "alt+right"},
+		copyURL:      binding{"y"},
+		openURL:      binding{"o"},
+		uncommit:     binding{"u"},
+		refresh:      binding{"R"},
+		quit:         binding{"q", "esc"},
+	}
+}
+
+// keyMapPath returns the key binding file in the user's config dir.
+func keyMapPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh-pr-review", "keys"), nil
+}
+
+// loadKeyMapFromFile reads the key binding file if it exists and overrides
+// defaults. Like ~/.gh-pr-approver it uses "action = key, key" lines, e.g.
+// "quit = ctrl+q". Supported actions: approve, decline, skip, commit, up,
+// down, left, right, switch_row, hscroll_left, hscroll_right, copy_url,
+// open_url, uncommit, refresh, quit.
+func loadKeyMapFromFile() keyMap {
+	p, err := keyMapPath()
+	if err != nil {
+		return defaultKeyMap()
+	}
+	return loadKeyMap(p)
+}
+
+// loadKeyMap applies the bindings in the file at path over the defaults.
+// Unknown actions and empty key lists are ignored.
+func loadKeyMap(path string) keyMap {
+	km := defaultKeyMap()
+	f, err := os.Open(path)
+	if err != nil {
+		return km
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		action, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		b := parseBinding(value)
+		if len(b) == 0 {
+			continue
+		}
+		if dst := km.action(strings.TrimSpace(action)); dst != nil {
+			*dst = b
+		}
+	}
+	return km
+}
+
+// action returns the binding for a config file action name, or nil if the
+// name is unknown.
+func (km *keyMap) action(name string) *binding {
+	switch name {
+	case "approve":
+		return &km.approve
+	case "decline":
+		return &km.decline
+	case "skip":
+		return &km.skip
+	case "commit":
+		return &km.commit
+	case "up":
+		return &km.up
+	case "down":
+		return &km.down
+	case "left":
+		return &km.left
+	case "right":
+		return &km.right
+	case "switch_row":
+		return &km.switchRow
+	case "hscroll_left":
+		return &km.hscrollLeft
+	case "hscroll_right":
+		return &km.hscrollRight
+	case "copy_url":
+		return &km.copyURL
+	case "open_url":
+		return &km.openURL
+	case "uncommit":
+		return &km.uncommit
+	case "refresh":
+		return &km.refresh
+	case "quit":
+		return &km.quit
+	}
+	return nil
+}
+
+// parseBinding splits a comma separated key list. "space" stands for the
+// space bar, which can't be written on its own.
+func parseBinding(value string) binding {
+	var b binding
+	for _, k := range strings.Split(value, ",") {
+		k = strings.TrimSpace(k)
+		if k == "space" {
+			k = " "
+		}
+		if k != "" {
+			b = append(b, k)
+		}
+	}
+	return b
+}
+
+// hint builds the footer help line from the configured bindings.
+func (km keyMap) hint() string {
+	return strings.Join([]string{
+		km.switchRow.help() + ": switch row",
+		km.left.help() + " " + km.right.help() + ": left/right",
+		km.up.help() + " " + km.down.help() + ": up/down",
+		"pgup/pgdown home/end: page/ends",
+		"e/r: file tabs",
+		km.approve.help() + ": approve",
+		km.decline.help() + ": decline",
+		km.skip.help() + ": skip",
+		km.commit.help() + ": commit",
+		km.uncommit.help() + ": undo commit",
+		km.copyURL.help() + "/" + km.openURL.help() + ": copy/open PR",
+		"/: filter",
+		"v: diff/body",
+		"p: settings",
+		km.refresh.help() + ": refresh",
+		km.quit.help() + ": quit",
+		km.hscrollLeft.help() + " " + km.hscrollRight.help() + ": hscroll",
+	}, " • ")
+}
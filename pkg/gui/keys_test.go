@@ -0,0 +1,49 @@
+package gui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadKeyMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	conf := "# remap quit\nquit = ctrl+q\napprove = space, y\nunknown = z\ndecline =\n"
+	if err := os.WriteFile(path, []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	km := loadKeyMap(path)
+
+	if !km.quit.matches("ctrl+q") || km.quit.matches("q") {
+		t.Errorf("quit = %v, want only ctrl+q", km.quit)
+	}
+	if !km.approve.matches(" ") || !km.approve.matches("y") {
+		t.Errorf("approve = %v, want space and y", km.approve)
+	}
+	// empty values keep the default
+	if !km.decline.matches("f") {
+		t.Errorf("decline = %v, want default f", km.decline)
+	}
+	if !km.up.matches("k") || !km.up.matches("up") {
+		t.Errorf("up = %v, want defaults", km.up)
+	}
+}
+
+func TestLoadKeyMapMissingFile(t *testing.T) {
+	km := loadKeyMap(filepath.Join(t.TempDir(), "missing"))
+	if !km.commit.matches("c") {
+		t.Errorf("commit = %v, want default c", km.commit)
+	}
+}
+
+func TestKeyMapHint(t *testing.T) {
+	km := defaultKeyMap()
+	km.approve = binding{" "}
+	hint := km.hint()
+	for _, want := range []string{"space: approve", "w/k/↑ s/j/↓: up/down", "alt+a/alt+h/alt+←"} {
+		if !strings.Contains(hint, want) {
+			t.Errorf("hint %q missing %q", hint, want)
+		}
+	}
+}
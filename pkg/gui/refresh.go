@@ -0,0 +1,133 @@
+package gui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mallendem/gh-pr-review/pkg/approve"
+)
+
+// DefaultWatchInterval is how often --watch refreshes the review queue.
+const DefaultWatchInterval = 2 * time.Minute
+
+// watchTickMsg asks for a --watch refresh.
+type watchTickMsg struct{}
+
+// refreshedMsg carries a refetched queue to merge into the one shown.
+type refreshedMsg loadedMsg
+
+// watchTick schedules the next --watch refresh, or returns nil without
+// --watch.
+func (m model) watchTick() tea.Cmd {
+	if m.watch <= 0 {
+		return nil
+	}
+	return tea.Tick(m.watch, func(time.Time) tea.Msg {
+		return watchTickMsg{}
+	})
+}
+
+// startRefresh refetches the queue in the background. It returns nil while
+// a refresh is already running, and while the queue can't be swapped out:
+// before it first loaded, during a commit or the resume prompt.
+func (m *model) startRefresh() tea.Cmd {
+	if m.refreshing || m.loading || m.loadErr != nil || m.committing || m.resume != nil || m.reload == nil {
+		return nil
+	}
+	m.refreshing = true
+	reload := m.reload
+	return func() tea.Msg {
+		return refreshedMsg(reload().(loadedMsg))
+	}
+}
+
+// applyRefreshed merges a refetched queue into the model. Decisions on
+// hashes and PRs still in the queue are kept and the rest dropped, unless
+// the queue is partial or some PRs failed to load, since their hashes may
+// only be missing for now. The selected hash stays selected if it is still
+// there. A commit started since
+// the refresh was requested wins: its result is discarded.
+func (m *model) applyRefreshed(msg refreshedMsg) {
+	m.refreshing = false
+	if msg.err != nil {
+		m.status = "refresh failed: " + msg.err.Error()
+		return
+	}
+	if m.committing || m.resume != nil {
+		return
+	}
+	before := m.hashes
+	if m.allHashes != nil {
+		before = m.allHashes
+	}
+	selected := m.selectedHash()
+
+	m.setQueue(loadedMsg(msg))
+	hashes, users := msg.hashes, m.loadUser
+	if m.sessionUser != "" {
+		// users picked in the selection panel, or given with --user
+		users = m.sessionUser
+		hashes = approve.CollectHashesForUsers(users, m.userHashPrMap)
+	}
+	hashes = m.orderHashes(users, hashes)
+	if msg.res.Unloaded == 0 && len(msg.res.Failures) == 0 {
+		for _, decisions := range []map[string]bool{m.approved, m.declined, m.committed, m.deferred} {
+			for h := range decisions {
+				if _, ok := m.hashPrMap[h]; !ok {
+					delete(decisions, h)
+				}
+			}
+		}
+	}
+	m.recomputePrSkipped()
+
+	if m.allHashes != nil {
+		m.allHashes = hashes
+		m.applyHashFilter(m.filterQuery)
+	} else if m.phase != 0 {
+		m.setHashes(hashes, selected)
+	}
+	if m.selectedHash() != selected {
+		m.changeOffset, m.changeHOffset, m.changeFileTab, m.prOffset = 0, 0, 0, 0
+	}
+	ensureOffset(&m.hashOffset, m.hashIndex, m.topVisibleLines())
+	m.userCursor = min(m.userCursor, max(len(m.availableUsers)-1, 0))
+	ensureOffset(&m.userScrollOffset, m.userCursor, m.userSelectionVisibleLines())
+
+	m.updateStagedList()
+	m.updateViewportContent()
+	m.saveSession()
+	if m.phase != 0 {
+		m.status = refreshSummary(before, hashes)
+	}
+}
+
+// refreshSummary describes how a refresh changed the hash list, e.g.
+// "refreshed: 2 new, 1 gone".
+func refreshSummary(before, after []string) string {
+	old := make(map[string]bool, len(before))
+	for _, h := range before {
+		old[h] = true
+	}
+	added := 0
+	for _, h := range after {
+		if old[h] {
+			delete(old, h)
+		} else {
+			added++
+		}
+	}
+	return fmt.Sprintf("refreshed: %d new, %d gone", added, len(old))
+}
+
+// refreshStatus says when the queue shown was fetched, for the status line.
+func (m model) refreshStatus() string {
+	if m.refreshing {
+		return "Refreshing…"
+	}
+	if m.refreshedAt.IsZero() {
+		return "Not refreshed"
+	}
+	return "Refreshed " + m.refreshedAt.Format("15:04:05")
+}
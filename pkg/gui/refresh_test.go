@@ -0,0 +1,95 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v72/github"
+	"github.com/mallendem/gh-pr-review/pkg/gh"
+)
+
+// refreshQueue returns a queue where alice has one PR per hash.
+func refreshQueue(hashes ...string) loadedMsg {
+	res := &gh.FetchResult{
+		UserHashPrMap: gh.GhPrHashMap{"alice": {}},
+		HashPrMap:     gh.HashPrMap{},
+		PrMap:         gh.PrHashMap{},
+		ChangeMap:     gh.HashChangeMap{},
+	}
+	for i, h := range hashes {
+		pr := &github.PullRequest{HTMLURL: github.Ptr(fmt.Sprintf("https://github.com/o/r/pull/%d", i+1))}
+		res.UserHashPrMap["alice"][h] = []*github.PullRequest{pr}
+		res.HashPrMap[h] = []*github.PullRequest{pr}
+		res.PrMap[pr.GetHTMLURL()] = []string{h}
+		res.ChangeMap[h] = gh.Hunk{File: h + ".go", Lines: []string{"+" + h}}
+	}
+	return loadedMsg{hashes: hashes, res: res, client: new(gh.GhClient)}
+}
+
+func TestApplyRefreshed(t *testing.T) {
+	m := model{
+		phase:     1,
+		loadUser:  "alice",
+		approved:  map[string]bool{},
+		declined:  map[string]bool{},
+		prSkipped: map[string]bool{},
+		committed: map[string]bool{},
+		settings:  defaultSettings(),
+		keys:      defaultKeyMap(),
+		ctx:       context.Background(),
+	}
+	m.applyLoaded(refreshQueue("a", "b", "c"))
+	m.approved["a"], m.approved["b"] = true, true
+	m.declined["c"] = true
+	m.hashIndex = 1 // b
+
+	m.refreshing = true
+	m.applyRefreshed(refreshedMsg(refreshQueue("d", "c", "b")))
+	if m.refreshing {
+		t.Error("still refreshing after the result was applied")
+	}
+	if m.approved["a"] || !m.approved["b"] || !m.declined["c"] {
+		t.Errorf("approved %v, declined %v; want b approved and c declined, a dropped", m.approved, m.declined)
+	}
+	if got := m.selectedHash(); got != "b" {
+		t.Errorf("selected %q after refresh, want b", got)
+	}
+	if m.status != "refreshed: 1 new, 1 gone" {
+		t.Errorf("status = %q", m.status)
+	}
+	if m.refreshedAt.IsZero() {
+		t.Error("refreshedAt not set")
+	}
+
+	// a partial refresh keeps decisions on hashes it didn't load
+	partial := refreshQueue("d")
+	partial.res.Unloaded = 2
+	m.applyRefreshed(refreshedMsg(partial))
+	if !m.approved["b"] || !m.declined["c"] {
+		t.Errorf("partial refresh dropped decisions: approved %v, declined %v", m.approved, m.declined)
+	}
+	m.applyRefreshed(refreshedMsg(refreshQueue("d", "c", "b")))
+
+	// a commit started meanwhile keeps the queue it is approving
+	m.committing = true
+	m.applyRefreshed(refreshedMsg(refreshQueue("e")))
+	if len(m.hashes) != 3 || !m.approved["b"] {
+		t.Errorf("refresh applied during a commit: hashes %v, approved %v", m.hashes, m.approved)
+	}
+}
+
+func TestStartRefresh(t *testing.T) {
+	m := model{reload: func() tea.Msg { return refreshQueue("a") }}
+	if m.startRefresh() == nil || !m.refreshing {
+		t.Fatal("startRefresh did not start a refresh")
+	}
+	if m.startRefresh() != nil {
+		t.Error("startRefresh started a second refresh while one runs")
+	}
+	m.refreshing, m.committing = false, true
+	if m.startRefresh() != nil {
+		t.Error("startRefresh started a refresh during a commit")
+	}
+}
@@ -0,0 +1,157 @@
+package gui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sessionState is the approve/decline progress saved for a set of users so
+// a review can be resumed after quitting or a crash.
+type sessionState struct {
+	User      string    `json:"user"`
+	Saved     time.Time `json:"saved"`
+	Approved  []string  `json:"approved,omitempty"`   // hashes
+	Declined  []string  `json:"declined,omitempty"`   // hashes
+	PrSkipped []string  `json:"pr_skipped,omitempty"` // PR URLs
+	Committed []string  `json:"committed,omitempty"`  // hashes of approved PRs
+}
+
+// defaultSessionDir returns where GUI sessions are saved: gh-pr-review/sessions
+// under $XDG_STATE_HOME, or ~/.local/state when it is unset.
+func defaultSessionDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "gh-pr-review", "sessions"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "gh-pr-review", "sessions"), nil
+}
+
+// sessionKey normalizes a comma separated user list so the same users share
+// a session regardless of order.
+func sessionKey(users string) string {
+	var list []string
+	for _, u := range strings.Split(users, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			list = append(list, u)
+		}
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+// sessionPath returns the state file for user in dir.
+func sessionPath(dir, user string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == ',' {
+			return r
+		}
+		return '_'
+	}, user)
+	return filepath.Join(dir, name+".json")
+}
+
+// newSessionState captures the decision maps of a review.
+func newSessionState(user string, approved, declined, prSkipped, committed map[string]bool) sessionState {
+	return sessionState{
+		User:      user,
+		Saved:     time.Now(),
+		Approved:  trueKeys(approved),
+		Declined:  trueKeys(declined),
+		PrSkipped: trueKeys(prSkipped),
+		Committed: trueKeys(committed),
+	}
+}
+
+// trueKeys returns the sorted keys of m that are set.
+func trueKeys(m map[string]bool) []string {
+	var keys []string
+	for k, v := range m {
+		if v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// prune drops hashes that are no longer in the queue and PRs that no longer
+// request review.
+func (s *sessionState) prune(hashes []string, prMap map[string][]string) {
+	present := make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		present[h] = true
+	}
+	keep := func(list []string, ok func(string) bool) []string {
+		var out []string
+		for _, v := range list {
+			if ok(v) {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	s.Approved = keep(s.Approved, func(h string) bool { return present[h] })
+	s.Declined = keep(s.Declined, func(h string) bool { return present[h] })
+	s.PrSkipped = keep(s.PrSkipped, func(pr string) bool { _, ok := prMap[pr]; return ok })
+	s.Committed = keep(s.Committed, func(h string) bool { return present[h] })
+}
+
+// empty reports whether s holds no decisions.
+func (s sessionState) empty() bool {
+	return len(s.Approved) == 0 && len(s.Declined) == 0 && len(s.PrSkipped) == 0 && len(s.Committed) == 0
+}
+
+// loadSession reads the saved session for user from dir. A missing file is
+// reported with an error satisfying errors.Is(err, fs.ErrNotExist).
+func loadSession(dir, user string) (sessionState, error) {
+	var s sessionState
+	data, err := os.ReadFile(sessionPath(dir, user))
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+// saveSession writes s to dir, replacing any previous session for its user.
+// The file is written under a temporary name and renamed so a crash never
+// leaves a truncated session behind.
+func saveSession(dir string, s sessionState) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), sessionPath(dir, s.User))
+}
+
+// removeSession deletes the saved session for user, if any.
+func removeSession(dir, user string) error {
+	err := os.Remove(sessionPath(dir, user))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package gui
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+func TestSessionRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	user := sessionKey("bob, alice")
+	if user != "alice,bob" {
+		t.Fatalf("sessionKey = %q, want alice,bob", user)
+	}
+	if _, err := loadSession(dir, user); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("loadSession before save: err = %v, want not exist", err)
+	}
+
+	s := newSessionState(user, map[string]bool{"a": true, "b": false}, map[string]bool{"c": true}, map[string]bool{"pr2": true}, map[string]bool{"a": true})
+	if err := saveSession(dir, s); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadSession(dir, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Approved, []string{"a"}) || !reflect.DeepEqual(got.Declined, []string{"c"}) || !reflect.DeepEqual(got.PrSkipped, []string{"pr2"}) || !reflect.DeepEqual(got.Committed, []string{"a"}) {
+		t.Errorf("loaded %+v, want approved [a], declined [c], skipped [pr2], committed [a]", got)
+	}
+
+	if err := removeSession(dir, user); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeSession(dir, user); err != nil {
+		t.Errorf("removing a missing session: %v", err)
+	}
+}
+
+func TestSessionPrune(t *testing.T) {
+	s := sessionState{Approved: []string{"a", "gone"}, Declined: []string{"old"}, PrSkipped: []string{"pr1", "pr9"}, Committed: []string{"a", "gone"}}
+	s.prune([]string{"a", "b"}, map[string][]string{"pr1": {"a"}})
+	if !reflect.DeepEqual(s.Approved, []string{"a"}) || s.Declined != nil || !reflect.DeepEqual(s.PrSkipped, []string{"pr1"}) || !reflect.DeepEqual(s.Committed, []string{"a"}) {
+		t.Errorf("pruned %+v, want approved [a], no declines, skipped [pr1], committed [a]", s)
+	}
+	s.prune(nil, nil)
+	if !s.empty() {
+		t.Errorf("%+v not empty after pruning everything", s)
+	}
+}